@@ -0,0 +1,42 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// ModeAuthorizer refuses actions the access mode at a path does not permit
+// before consulting the user's permissions, so a read-only or drop-box share
+// is enforced for every service that authorizes its work.
+type ModeAuthorizer struct {
+	next  ports.Authorizer
+	modes ports.ModeResolver
+}
+
+func NewModeAuthorizer(next ports.Authorizer, modes ports.ModeResolver) *ModeAuthorizer {
+	return &ModeAuthorizer{next: next, modes: modes}
+}
+
+func (a *ModeAuthorizer) Allowed(user string, action models.Action, path string) bool {
+	return a.modes.ModeFor(path).Permits(action) && a.next.Allowed(user, action, path)
+}
+
+// conflictPolicyFor adjusts policy to the access mode at path; without a
+// resolver policy is used as is.
+func conflictPolicyFor(modes ports.ModeResolver, policy models.ConflictPolicy, path string) models.ConflictPolicy {
+	if modes == nil {
+		return policy
+	}
+	return modes.ModeFor(path).ConflictPolicy(policy)
+}
+
+// reportedPath returns the path to tell an uploader their file was stored
+// at: stored, or sent where the access mode hides what is already there.
+func reportedPath(modes ports.ModeResolver, sent, stored string) string {
+	if modes != nil && modes.ModeFor(sent).HidesContents() {
+		return sent
+	}
+	return stored
+}
+
+var _ ports.Authorizer = (*ModeAuthorizer)(nil)
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// fixedMode applies one access mode everywhere.
+type fixedMode models.AccessMode
+
+func (m fixedMode) ModeFor(string) models.AccessMode { return models.AccessMode(m) }
+
+func TestModeAuthorizer(t *testing.T) {
+	tests := []struct {
+		mode   models.AccessMode
+		action models.Action
+		want   bool
+	}{
+		{models.AccessReadWrite, models.ActionDelete, true},
+		{models.AccessReadOnly, models.ActionRead, true},
+		{models.AccessReadOnly, models.ActionZip, true},
+		{models.AccessReadOnly, models.ActionWrite, false},
+		{models.AccessReadOnly, models.ActionDelete, false},
+		{models.AccessDropBox, models.ActionWrite, true},
+		{models.AccessDropBox, models.ActionList, false},
+		{models.AccessDropBox, models.ActionRead, false},
+		{models.AccessWriteOnce, models.ActionWrite, true},
+		{models.AccessWriteOnce, models.ActionDelete, false},
+	}
+	for _, tt := range tests {
+		authorizer := NewModeAuthorizer(acl.AllowAllAuthorizer{}, fixedMode(tt.mode))
+		if got := authorizer.Allowed("alice", tt.action, "/a.txt"); got != tt.want {
+			t.Errorf("%s: Allowed(%s) = %v, want %v", tt.mode, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestUploadServiceModes(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	upload := func(mode models.AccessMode) ([]models.FileUpload, error) {
+		modes := fixedMode(mode)
+		service := NewUploadService(fs.NewLocalFileRepository(root), NewModeAuthorizer(acl.AllowAllAuthorizer{}, modes), models.ConflictOverwrite).
+			WithModes(modes)
+		return service.Execute("alice", []models.UploadPart{testPart{"a.txt", "new"}})
+	}
+
+	if _, err := upload(models.AccessReadOnly); err == nil {
+		t.Error("Expected uploads to a read-only share to be refused")
+	} else if _, ok := err.(*errors.ForbiddenError); !ok {
+		t.Errorf("Expected ForbiddenError, got %T %v", err, err)
+	}
+
+	if _, err := upload(models.AccessWriteOnce); err == nil {
+		t.Error("Expected write-once to refuse overwriting")
+	} else if _, ok := err.(*errors.ConflictError); !ok {
+		t.Errorf("Expected ConflictError, got %T %v", err, err)
+	}
+
+	uploads, err := upload(models.AccessDropBox)
+	if err != nil {
+		t.Fatalf("Failed to upload to a drop box: %v", err)
+	}
+	// The uploader is not told that a.txt was taken
+	if len(uploads) != 1 || uploads[0].Filename != "a.txt" {
+		t.Errorf("Expected the name that was sent, got %+v", uploads)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(data) != "old" {
+		t.Errorf("Expected the existing file to be untouched, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "a (1).txt")); string(data) != "new" {
+		t.Errorf("Expected the drop box to keep both files, got %q", data)
+	}
+}
+
+func TestDropBoxHidesExistence(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	repo := fs.NewLocalFileRepository(root)
+	modes := fixedMode(models.AccessDropBox)
+	authorizer := NewModeAuthorizer(acl.AllowAllAuthorizer{}, modes)
+	download := NewDownloadFileService(repo, authorizer)
+	deleter := NewDeleteService(repo, authorizer)
+	mover := NewMoveService(repo, authorizer)
+	mkdir := NewCreateDirectoryService(repo, authorizer).WithModes(modes)
+
+	// Every operation must answer the same whether or not the path exists
+	for _, pair := range [][2]string{{"/a.txt", "/missing.txt"}, {"/docs", "/missing"}} {
+		operations := map[string]func(p string) error{
+			"download": func(p string) error { _, err := download.Execute("alice", p); return err },
+			"delete":   func(p string) error { return deleter.Execute("alice", p, true) },
+			"move":     func(p string) error { return mover.Execute("alice", p, "/moved", false) },
+			"mkdir":    func(p string) error { return mkdir.Execute("alice", p) },
+		}
+		for name, operation := range operations {
+			existing, missing := errorReason(operation(pair[0])), errorReason(operation(pair[1]))
+			if existing != missing {
+				t.Errorf("%s: %s gives %q but %s gives %q", name, pair[0], existing, pair[1], missing)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "secret" {
+		t.Errorf("Expected a.txt to be untouched, got %q %v", data, err)
+	}
+}
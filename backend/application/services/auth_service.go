@@ -0,0 +1,91 @@
+package services
+
+import (
+	stderrors "errors"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// AuthService exchanges credentials for JWT tokens and manages their lifecycle.
+type AuthService struct {
+	authProvider ports.AuthProvider
+	jwtProvider  ports.JWTProvider
+}
+
+func NewAuthService(authProvider ports.AuthProvider, jwtProvider ports.JWTProvider) *AuthService {
+	return &AuthService{authProvider: authProvider, jwtProvider: jwtProvider}
+}
+
+// Login checks the credentials and issues an access/refresh token pair.
+func (s *AuthService) Login(username, password string) (*models.TokenPair, error) {
+	if username == "" || !s.authProvider.Authenticate(username, password) {
+		return nil, &errors.UnauthorizedError{Reason: "invalid credentials"}
+	}
+	return s.issue(username)
+}
+
+// Refresh trades a valid refresh token for a new pair.
+// The presented refresh token is revoked so it cannot be replayed; of
+// several concurrent refreshes with it, only one succeeds. Users the auth
+// provider no longer knows get no new tokens.
+func (s *AuthService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	claims, err := s.jwtProvider.ValidateToken(refreshToken)
+	if err != nil {
+		return nil, &errors.UnauthorizedError{Reason: err.Error()}
+	}
+	if claims.TokenType != ports.TokenTypeRefresh {
+		return nil, &errors.UnauthorizedError{Reason: "not a refresh token"}
+	}
+	if !s.authProvider.UserExists(claims.Username) {
+		return nil, &errors.UnauthorizedError{Reason: "unknown user"}
+	}
+	if err := s.jwtProvider.RevokeToken(claims); err != nil {
+		if stderrors.Is(err, ports.ErrTokenRevoked) {
+			return nil, &errors.UnauthorizedError{Reason: err.Error()}
+		}
+		return nil, err
+	}
+	return s.issue(claims.Username)
+}
+
+// Logout revokes every still-valid token it is given.
+// Invalid or already expired tokens are ignored.
+func (s *AuthService) Logout(tokens ...string) error {
+	for _, token := range tokens {
+		if token == "" {
+			continue
+		}
+		claims, err := s.jwtProvider.ValidateToken(token)
+		if err != nil {
+			continue
+		}
+		if err := s.jwtProvider.RevokeToken(claims); err != nil && !stderrors.Is(err, ports.ErrTokenRevoked) {
+			return err
+		}
+	}
+	return nil
+}
+
+// issue generates a fresh token pair for username.
+func (s *AuthService) issue(username string) (*models.TokenPair, error) {
+	access, err := s.jwtProvider.GenerateToken(username)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.jwtProvider.GenerateRefreshToken(username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the expiries back from the tokens rather than duplicating the provider's settings
+	pair := &models.TokenPair{AccessToken: access, RefreshToken: refresh}
+	if claims, err := s.jwtProvider.ValidateToken(access); err == nil {
+		pair.AccessExpiresAt = claims.ExpiresAt
+	}
+	if claims, err := s.jwtProvider.ValidateToken(refresh); err == nil {
+		pair.RefreshExpiresAt = claims.ExpiresAt
+	}
+	return pair, nil
+}
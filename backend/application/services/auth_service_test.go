@@ -0,0 +1,52 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+)
+
+func TestRefreshIsSingleUse(t *testing.T) {
+	jwtProvider := auth.NewJWTProvider("test-secret-key", time.Hour)
+	service := NewAuthService(auth.NewStaticAuthProvider("admin", "secret"), jwtProvider)
+	pair, err := service.Login("admin", "secret")
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	// However the refreshes interleave, only one of them gets new tokens
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.Refresh(pair.RefreshToken); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one refresh to succeed, got %d", succeeded)
+	}
+}
+
+func TestRefreshRejectsRemovedUser(t *testing.T) {
+	jwtProvider := auth.NewJWTProvider("test-secret-key", time.Hour)
+	service := NewAuthService(auth.NewStaticAuthProvider("admin", "secret"), jwtProvider)
+
+	// A token issued while "bob" was still a user
+	refresh, err := jwtProvider.GenerateRefreshToken("bob")
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+	if _, err := service.Refresh(refresh); err == nil {
+		t.Error("Expected refresh for an unknown user to fail")
+	}
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// authorize returns a ForbiddenError unless user may perform action on path.
+func authorize(authorizer ports.Authorizer, user string, action models.Action, path string) error {
+	if !authorizer.Allowed(user, action, path) {
+		return &errors.ForbiddenError{Path: path, Action: string(action)}
+	}
+	return nil
+}
+
+// authorizeAny returns a ForbiddenError unless user may perform one of
+// actions on path. Services call it before looking at path, so a user who
+// may do nothing there cannot tell whether it exists.
+func authorizeAny(authorizer ports.Authorizer, user, path string, actions ...models.Action) error {
+	for _, action := range actions {
+		if authorizer.Allowed(user, action, path) {
+			return nil
+		}
+	}
+	return &errors.ForbiddenError{Path: path, Action: string(actions[0])}
+}
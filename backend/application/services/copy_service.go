@@ -0,0 +1,86 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+type CopyService struct {
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	quotas     *QuotaService
+}
+
+func NewCopyService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *CopyService {
+	return &CopyService{fileRepo: fileRepo, authorizer: authorizer}
+}
+
+// WithQuotas refuses copies that do not fit in the storage or user quota,
+// and counts the copied files against the user making them
+func (s *CopyService) WithQuotas(quotas *QuotaService) *CopyService {
+	s.quotas = quotas
+	return s
+}
+
+// Execute copies src to dst. Entries below a directory that the user cannot
+// read are left out, as they would be from a ZIP download.
+func (s *CopyService) Execute(user, src, dst string, overwrite bool) error {
+	if err := validateTransfer(src, dst); err != nil {
+		return err
+	}
+
+	if err := authorizeAny(s.authorizer, user, src, models.ActionRead, models.ActionList); err != nil {
+		return err
+	}
+	isDir, err := requireExisting(s.fileRepo, src)
+	if err != nil {
+		return err
+	}
+
+	action := models.ActionRead
+	if isDir {
+		action = models.ActionList
+	}
+	if err := authorize(s.authorizer, user, action, src); err != nil {
+		return err
+	}
+	if err := authorizeDestination(s.fileRepo, s.authorizer, user, dst, overwrite); err != nil {
+		return err
+	}
+
+	include := func(entryPath string, isDir bool) bool {
+		if isDir {
+			return s.authorizer.Allowed(user, models.ActionList, entryPath)
+		}
+		return s.authorizer.Allowed(user, models.ActionRead, entryPath)
+	}
+	if err := s.checkSpace(user, src, dst, overwrite, include); err != nil {
+		return err
+	}
+	if err := s.fileRepo.Copy(src, dst, overwrite, include); err != nil {
+		return err
+	}
+	if s.quotas != nil {
+		s.quotas.RecordTree(s.fileRepo, user, dst)
+	}
+	return nil
+}
+
+// checkSpace fails if the files include admits below src do not fit at
+// dst, after what they would replace there is gone.
+func (s *CopyService) checkSpace(user, src, dst string, overwrite bool, include func(path string, isDir bool) bool) error {
+	if s.quotas == nil {
+		return nil
+	}
+	size, err := treeSize(s.fileRepo, src, include)
+	if err != nil {
+		return err
+	}
+	var replaced int64
+	if overwrite {
+		if size, err := treeSize(s.fileRepo, dst, nil); err == nil {
+			replaced = size
+		}
+	}
+	return s.quotas.Check(user, dst, size, replaced)
+}
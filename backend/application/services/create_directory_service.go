@@ -0,0 +1,46 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+type CreateDirectoryService struct {
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	modes      ports.ModeResolver
+}
+
+func NewCreateDirectoryService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *CreateDirectoryService {
+	return &CreateDirectoryService{fileRepo: fileRepo, authorizer: authorizer}
+}
+
+// WithModes applies the access mode at each path, e.g. so a drop box does
+// not report that a directory already exists
+func (s *CreateDirectoryService) WithModes(modes ports.ModeResolver) *CreateDirectoryService {
+	s.modes = modes
+	return s
+}
+
+// Execute creates path and any missing parents. An existing file or
+// directory at path is a conflict, except in a drop box.
+func (s *CreateDirectoryService) Execute(user, path string) error {
+	if err := authorize(s.authorizer, user, models.ActionWrite, path); err != nil {
+		return err
+	}
+
+	exists, err := s.fileRepo.FileExists(path)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if conflictPolicyFor(s.modes, models.ConflictReject, path) == models.ConflictRename {
+			// A drop box never tells whether something is there; uploads
+			// into path land beside what it holds
+			return nil
+		}
+		return &errors.ConflictError{Path: path, Reason: "already exists"}
+	}
+	return s.fileRepo.CreateDirectory(path)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+type DeleteService struct {
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	trash      ports.TrashRepository
+	quotas     *QuotaService
+}
+
+func NewDeleteService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *DeleteService {
+	return &DeleteService{fileRepo: fileRepo, authorizer: authorizer}
+}
+
+// WithTrash moves deleted entries to trash instead of removing them
+func (s *DeleteService) WithTrash(trash ports.TrashRepository) *DeleteService {
+	s.trash = trash
+	return s
+}
+
+// WithQuotas stops counting deleted uploads against their uploader
+func (s *DeleteService) WithQuotas(quotas *QuotaService) *DeleteService {
+	s.quotas = quotas
+	return s
+}
+
+// Execute removes a file or directory. A directory with contents is only
+// removed when recursive is set, and only if the user may delete all of it.
+func (s *DeleteService) Execute(user, path string, recursive bool) error {
+	if isRoot(path) {
+		return errors.NewValidationError("path", path, "cannot delete the root directory")
+	}
+	if err := authorize(s.authorizer, user, models.ActionDelete, path); err != nil {
+		return err
+	}
+
+	exists, err := s.fileRepo.FileExists(path)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &errors.NotFoundError{Path: path}
+	}
+
+	isDir, err := s.fileRepo.IsDirectory(path)
+	if err != nil {
+		return err
+	}
+	if !isDir {
+		return s.remove(user, path, false)
+	}
+
+	if !recursive {
+		entries, err := s.fileRepo.ListDirectory(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &errors.ConflictError{Path: path, Reason: "directory is not empty"}
+		}
+		return s.remove(user, path, false)
+	}
+
+	if err := authorizeTree(s.fileRepo, s.authorizer, user, models.ActionDelete, path); err != nil {
+		return err
+	}
+	return s.remove(user, path, true)
+}
+
+// remove trashes path when there is a trash and deletes it otherwise.
+func (s *DeleteService) remove(user, path string, recursive bool) error {
+	var err error
+	if s.trash != nil {
+		_, err = s.trash.MoveToTrash(path, user)
+	} else {
+		err = s.fileRepo.Delete(path, recursive)
+	}
+	if err == nil && s.quotas != nil {
+		s.quotas.Forget(path)
+	}
+	return err
+}
@@ -7,29 +7,50 @@ import (
 )
 
 type DownloadFileService struct {
-	fileRepo ports.FileRepository
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	metrics    ports.Metrics
 }
 
-func NewDownloadFileService(fileRepo ports.FileRepository) *DownloadFileService {
-	return &DownloadFileService{fileRepo: fileRepo}
+func NewDownloadFileService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *DownloadFileService {
+	return &DownloadFileService{fileRepo: fileRepo, authorizer: authorizer, metrics: nopMetrics{}}
 }
 
-func (s *DownloadFileService) Execute(path string) (models.ReadCloser, string, error) {
+// WithMetrics counts the bytes of every download served
+func (s *DownloadFileService) WithMetrics(metrics ports.Metrics) *DownloadFileService {
+	s.metrics = metrics
+	return s
+}
+
+func (s *DownloadFileService) Execute(user, path string) (*models.FileContent, error) {
+	// Directories are listed or zipped instead, so either may be allowed
+	if err := authorizeAny(s.authorizer, user, path, models.ActionRead, models.ActionList); err != nil {
+		return nil, err
+	}
 	exists, err := s.fileRepo.FileExists(path)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	if !exists {
-		return nil, "", &errors.NotFoundError{Path: path}
+		return nil, &errors.NotFoundError{Path: path}
 	}
 
 	isDir, err := s.fileRepo.IsDirectory(path)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	if isDir {
-		return nil, "", nil // Delegate to list or zip
+		return nil, nil // Delegate to list or zip
 	}
 
-	return s.fileRepo.ServeFile(path)
+	if err := authorize(s.authorizer, user, models.ActionRead, path); err != nil {
+		return nil, err
+	}
+
+	file, err := s.fileRepo.ServeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file.Content = &meteredFile{ReadSeekCloser: file.Content, metrics: s.metrics}
+	return file, nil
 }
@@ -8,14 +8,26 @@ import (
 )
 
 type DownloadZipService struct {
-	fileRepo ports.FileRepository
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	metrics    ports.Metrics
 }
 
-func NewDownloadZipService(fileRepo ports.FileRepository) *DownloadZipService {
-	return &DownloadZipService{fileRepo: fileRepo}
+func NewDownloadZipService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *DownloadZipService {
+	return &DownloadZipService{fileRepo: fileRepo, authorizer: authorizer, metrics: nopMetrics{}}
 }
 
-func (s *DownloadZipService) Execute(path string) (models.ReadCloser, string, error) {
+// WithMetrics tracks active archive streams and the bytes they send
+func (s *DownloadZipService) WithMetrics(metrics ports.Metrics) *DownloadZipService {
+	s.metrics = metrics
+	return s
+}
+
+func (s *DownloadZipService) Execute(user, path string) (models.ReadCloser, string, error) {
+	// Files are downloaded instead, so either may be allowed
+	if err := authorizeAny(s.authorizer, user, path, models.ActionZip, models.ActionRead); err != nil {
+		return nil, "", err
+	}
 	isDir, err := s.fileRepo.IsDirectory(path)
 	if err != nil {
 		return nil, "", err
@@ -24,10 +36,22 @@ func (s *DownloadZipService) Execute(path string) (models.ReadCloser, string, er
 		return nil, "", nil // Not a dir → not zip
 	}
 
-	zipStream, err := s.fileRepo.ZipDirectory(path)
+	if err := authorize(s.authorizer, user, models.ActionZip, path); err != nil {
+		return nil, "", err
+	}
+
+	// Leave out files the user cannot read and directories they cannot list
+	include := func(entryPath string, isDir bool) bool {
+		if isDir {
+			return s.authorizer.Allowed(user, models.ActionList, entryPath)
+		}
+		return s.authorizer.Allowed(user, models.ActionRead, entryPath)
+	}
+
+	zipStream, err := s.fileRepo.ZipDirectory(path, include)
 	if err != nil {
 		return nil, "", err
 	}
 
-	return zipStream, filepath.Base(path) + ".zip", nil
+	return newMeteredZip(zipStream, s.metrics), filepath.Base(path) + ".zip", nil
 }
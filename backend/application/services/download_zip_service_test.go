@@ -0,0 +1,69 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// denyPrefixAuthorizer allows everything except paths under one directory.
+type denyPrefixAuthorizer struct{ denied string }
+
+func (a denyPrefixAuthorizer) Allowed(_ string, _ models.Action, path string) bool {
+	return path != a.denied && !strings.HasPrefix(path, a.denied+"/")
+}
+
+func TestDownloadZipServiceSkipsDeniedSubtrees(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"docs/a.txt", "docs/private/b.txt", "docs/public/c.txt"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := NewDownloadZipService(fs.NewLocalFileRepository(root), denyPrefixAuthorizer{denied: "/docs/private"})
+	stream, _, err := service.Execute("alice", "/docs")
+	if err != nil {
+		t.Fatalf("Failed to zip: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("Failed to read zip stream: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	want := []string{"./", "a.txt", "public/", "public/c.txt"}
+	if len(names) != len(want) {
+		t.Fatalf("Expected entries %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Expected entries %v, got %v", want, names)
+		}
+	}
+
+	if _, _, err := service.Execute("alice", "/docs/private"); err == nil {
+		t.Fatal("Expected zipping a denied directory to fail")
+	}
+}
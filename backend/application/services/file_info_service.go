@@ -0,0 +1,35 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+type FileInfoService struct {
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+}
+
+func NewFileInfoService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *FileInfoService {
+	return &FileInfoService{fileRepo: fileRepo, authorizer: authorizer}
+}
+
+// Execute returns the full metadata of a single file or directory.
+func (s *FileInfoService) Execute(user, path string) (*models.FileInfo, error) {
+	if err := authorizeAny(s.authorizer, user, path, models.ActionRead, models.ActionList); err != nil {
+		return nil, err
+	}
+	info, err := s.fileRepo.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	action := models.ActionRead
+	if info.IsDir {
+		action = models.ActionList
+	}
+	if err := authorize(s.authorizer, user, action, path); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// HealthService answers liveness, readiness and version queries.
+type HealthService struct {
+	build   models.BuildInfo
+	checks  []ports.ReadinessCheck
+	started time.Time
+}
+
+// NewHealthService starts the uptime clock; checks are run on every readiness query.
+func NewHealthService(build models.BuildInfo, checks ...ports.ReadinessCheck) *HealthService {
+	return &HealthService{build: build, checks: checks, started: time.Now()}
+}
+
+// Uptime returns how long the service has been running.
+func (s *HealthService) Uptime() time.Duration {
+	return time.Since(s.started)
+}
+
+// Ready runs every check and returns each one's outcome ("ok" or the error)
+// and whether all of them passed.
+func (s *HealthService) Ready() (map[string]string, bool) {
+	results := make(map[string]string, len(s.checks))
+	ready := true
+	for _, check := range s.checks {
+		if err := check.Check(); err != nil {
+			results[check.Name()] = err.Error()
+			ready = false
+			continue
+		}
+		results[check.Name()] = "ok"
+	}
+	return results, ready
+}
+
+// Version returns the build metadata of the running binary.
+func (s *HealthService) Version() models.BuildInfo {
+	return s.build
+}
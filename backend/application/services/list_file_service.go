@@ -1,20 +1,27 @@
 package services
 
 import (
+	"path"
+
 	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
 type ListFilesService struct {
-	fileRepo ports.FileRepository
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
 }
 
-func NewListFilesService(fileRepo ports.FileRepository) *ListFilesService {
-	return &ListFilesService{fileRepo: fileRepo}
+func NewListFilesService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *ListFilesService {
+	return &ListFilesService{fileRepo: fileRepo, authorizer: authorizer}
 }
 
-func (s *ListFilesService) Execute(path string) (*models.PageData, error) {
-	isDir, err := s.fileRepo.IsDirectory(path)
+func (s *ListFilesService) Execute(user, dir string) (*models.PageData, error) {
+	// Files are downloaded instead, so either may be allowed
+	if err := authorizeAny(s.authorizer, user, dir, models.ActionList, models.ActionRead); err != nil {
+		return nil, err
+	}
+	isDir, err := s.fileRepo.IsDirectory(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -22,10 +29,23 @@ func (s *ListFilesService) Execute(path string) (*models.PageData, error) {
 		return nil, nil // Not a directory → delegate to download
 	}
 
-	files, err := s.fileRepo.ListDirectory(path)
+	if err := authorize(s.authorizer, user, models.ActionList, dir); err != nil {
+		return nil, err
+	}
+
+	files, err := s.fileRepo.ListDirectory(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.PageData{Root: path, Files: files}, nil
+	// Hide entries the user could neither open nor browse
+	visible := files[:0]
+	for _, f := range files {
+		entryPath := path.Join("/", dir, f.Name)
+		if s.authorizer.Allowed(user, models.ActionRead, entryPath) || f.IsDir && s.authorizer.Allowed(user, models.ActionList, entryPath) {
+			visible = append(visible, f)
+		}
+	}
+
+	return &models.PageData{Root: dir, Files: visible}, nil
 }
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// nopMetrics is used until a service is given real metrics.
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, string, int, time.Duration) {}
+func (nopMetrics) AddBytesUploaded(int64)                            {}
+func (nopMetrics) AddBytesDownloaded(int64)                          {}
+func (nopMetrics) ZipStreamStarted()                                 {}
+func (nopMetrics) ZipStreamFinished()                                {}
+func (nopMetrics) UploadFailed(string)                               {}
+
+var _ ports.Metrics = nopMetrics{}
+
+// failureReason classifies an upload error for the failure counter.
+func failureReason(err error) string {
+	switch err.(type) {
+	case *errors.ForbiddenError:
+		return "forbidden"
+	case *errors.ConflictError:
+		return "conflict"
+	case *errors.ValidationError:
+		return "invalid"
+	case *errors.NotFoundError:
+		return "not_found"
+	case *errors.TooLargeError:
+		return "too_large"
+	case *errors.QuotaExceededError:
+		return "quota"
+	}
+	return "io_error"
+}
+
+// meteredFile counts the bytes read from a download as they are sent.
+type meteredFile struct {
+	models.ReadSeekCloser
+	metrics ports.Metrics
+}
+
+func (f *meteredFile) Read(p []byte) (int, error) {
+	n, err := f.ReadSeekCloser.Read(p)
+	f.metrics.AddBytesDownloaded(int64(n))
+	return n, err
+}
+
+// meteredZip counts archive bytes and marks the stream finished on Close.
+type meteredZip struct {
+	models.ReadCloser
+	metrics ports.Metrics
+	once    sync.Once
+}
+
+func newMeteredZip(stream models.ReadCloser, metrics ports.Metrics) *meteredZip {
+	metrics.ZipStreamStarted()
+	return &meteredZip{ReadCloser: stream, metrics: metrics}
+}
+
+func (z *meteredZip) Read(p []byte) (int, error) {
+	n, err := z.ReadCloser.Read(p)
+	z.metrics.AddBytesDownloaded(int64(n))
+	return n, err
+}
+
+func (z *meteredZip) Close() error {
+	z.once.Do(z.metrics.ZipStreamFinished)
+	return z.ReadCloser.Close()
+}
+
+var _ io.ReadCloser = (*meteredZip)(nil)
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+type MoveService struct {
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	quotas     *QuotaService
+}
+
+func NewMoveService(fileRepo ports.FileRepository, authorizer ports.Authorizer) *MoveService {
+	return &MoveService{fileRepo: fileRepo, authorizer: authorizer}
+}
+
+// WithQuotas keeps counting moved uploads against their uploader
+func (s *MoveService) WithQuotas(quotas *QuotaService) *MoveService {
+	s.quotas = quotas
+	return s
+}
+
+// Execute renames or moves src to dst. An existing dst is a conflict
+// unless overwrite is set, in which case it is replaced.
+func (s *MoveService) Execute(user, src, dst string, overwrite bool) error {
+	if err := validateTransfer(src, dst); err != nil {
+		return err
+	}
+
+	// Moving takes the source away, so it needs delete rights on all of it
+	if err := authorize(s.authorizer, user, models.ActionDelete, src); err != nil {
+		return err
+	}
+	isDir, err := requireExisting(s.fileRepo, src)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		if err := authorizeTree(s.fileRepo, s.authorizer, user, models.ActionDelete, src); err != nil {
+			return err
+		}
+	}
+	if err := authorizeDestination(s.fileRepo, s.authorizer, user, dst, overwrite); err != nil {
+		return err
+	}
+
+	if err := s.fileRepo.Move(src, dst, overwrite); err != nil {
+		return err
+	}
+	if s.quotas != nil {
+		s.quotas.Rename(src, dst)
+	}
+	return nil
+}
+
+// validateTransfer rejects moves and copies that cannot make sense.
+func validateTransfer(src, dst string) error {
+	if isRoot(src) {
+		return errors.NewValidationError("from", src, "cannot move or copy the root directory")
+	}
+	if isRoot(dst) {
+		return errors.NewValidationError("to", dst, "destination cannot be the root directory")
+	}
+	if isWithin(dst, src) {
+		return errors.NewValidationError("to", dst, "destination is inside the source")
+	}
+	return nil
+}
+
+// requireExisting returns a NotFoundError for missing paths and reports whether p is a directory.
+// Callers authorize p first, so the NotFoundError only tells those allowed to know.
+func requireExisting(fileRepo ports.FileRepository, p string) (bool, error) {
+	exists, err := fileRepo.FileExists(p)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		return false, &errors.NotFoundError{Path: p}
+	}
+	return fileRepo.IsDirectory(p)
+}
+
+// authorizeDestination checks write access to dst and, when dst already
+// exists, that the caller asked for and may perform its replacement.
+func authorizeDestination(fileRepo ports.FileRepository, authorizer ports.Authorizer, user, dst string, overwrite bool) error {
+	if err := authorize(authorizer, user, models.ActionWrite, dst); err != nil {
+		return err
+	}
+
+	exists, err := fileRepo.FileExists(dst)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if !overwrite {
+		return &errors.ConflictError{Path: dst, Reason: "destination already exists"}
+	}
+
+	isDir, err := fileRepo.IsDirectory(dst)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		return authorizeTree(fileRepo, authorizer, user, models.ActionDelete, dst)
+	}
+	return authorize(authorizer, user, models.ActionDelete, dst)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"path"
+	"strings"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// isRoot reports whether p names the repository root.
+func isRoot(p string) bool {
+	return path.Clean("/"+p) == "/"
+}
+
+// isWithin reports whether child is parent or lies below it.
+func isWithin(child, parent string) bool {
+	child, parent = path.Clean("/"+child), path.Clean("/"+parent)
+	return child == parent || parent == "/" || strings.HasPrefix(child, parent+"/")
+}
+
+// authorizeTree checks action on dir and every entry below it, so a
+// recursive operation cannot reach into a subtree the user is denied.
+func authorizeTree(fileRepo ports.FileRepository, authorizer ports.Authorizer, user string, action models.Action, dir string) error {
+	if err := authorize(authorizer, user, action, dir); err != nil {
+		return err
+	}
+	entries, err := fileRepo.ListDirectory(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryPath := path.Join("/", dir, entry.Name)
+		if entry.IsDir {
+			if err := authorizeTree(fileRepo, authorizer, user, action, entryPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := authorize(authorizer, user, action, entryPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeSize returns the bytes held by the file at p, or by the files below
+// the directory at p that include admits; a nil include admits everything.
+// Symlinks hold nothing.
+func treeSize(fileRepo ports.FileRepository, p string, include func(path string, isDir bool) bool) (int64, error) {
+	info, err := fileRepo.Stat(p)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	err = walkFiles(fileRepo, p, info, include, func(_ string, file *models.FileInfo) {
+		size += file.Size
+	})
+	return size, err
+}
+
+// walkFiles calls fn for the regular file at p, described by info, or for
+// each one below the directory at p that include admits.
+func walkFiles(fileRepo ports.FileRepository, p string, info *models.FileInfo, include func(path string, isDir bool) bool, fn func(path string, file *models.FileInfo)) error {
+	if info.SymlinkTarget != "" {
+		return nil
+	}
+	if !info.IsDir {
+		fn(p, info)
+		return nil
+	}
+	entries, err := fileRepo.ListDirectory(p)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		entryPath := path.Join("/", p, entry.Name)
+		if include != nil && !include(entryPath, entry.IsDir) {
+			continue
+		}
+		if err := walkFiles(fileRepo, entryPath, entry, include, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// QuotaService enforces storage and user quotas on uploads. Storage quotas,
+// of the root directory or of each share, are kept by the repository, which
+// also fails writes that cross them mid-stream. User quotas count the bytes
+// a user's uploads take, as recorded in the ledger, plus what they moved to
+// the trash and the uploads they are sending right now. Resumable uploads reserve their whole length
+// against both while their chunks are staged.
+type QuotaService struct {
+	storage    ports.UsageRepository
+	ledger     ports.UsageLedger
+	trash      ports.TrashRepository // Optional
+	authorizer ports.Authorizer
+	logger     ports.Logger
+
+	mu        sync.Mutex
+	userLimit int64                  // Default per user; zero means no limit
+	limits    map[string]int64       // Per-user limits replacing userLimit
+	inFlight  map[string]int64       // Bytes of unfinished uploads per user
+	reserved  map[string]reservation // Space held for staged uploads, by ID
+}
+
+// reservation is the space held for one staged upload.
+type reservation struct {
+	user    string
+	area    string // Name of the storage area, as in models.Usage
+	storage int64  // Bytes held in the area, less what the upload replaces
+	size    int64  // Bytes held against the user
+}
+
+func NewQuotaService(storage ports.UsageRepository, ledger ports.UsageLedger, authorizer ports.Authorizer) *QuotaService {
+	return &QuotaService{
+		storage:    storage,
+		ledger:     ledger,
+		authorizer: authorizer,
+		logger:     nopLogger{},
+		inFlight:   make(map[string]int64),
+		reserved:   make(map[string]reservation),
+	}
+}
+
+// WithUserLimits limits every user to limit bytes, or to their entry in
+// users; zero means no limit. It may be called again while uploads are
+// running, e.g. on config reload.
+func (s *QuotaService) WithUserLimits(limit int64, users map[string]int64) *QuotaService {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userLimit = limit
+	s.limits = users
+	return s
+}
+
+// WithLogger reports ledger updates that fail. The files involved are
+// already written, moved or deleted, so the failure is not returned.
+func (s *QuotaService) WithLogger(logger ports.Logger) *QuotaService {
+	s.logger = logger
+	return s
+}
+
+// WithTrash charges each trashed entry to whoever trashed it until it is
+// purged or restored, so deleting does not free a user's quota for good
+// while the bytes are still kept.
+func (s *QuotaService) WithTrash(trash ports.TrashRepository) *QuotaService {
+	s.trash = trash
+	return s
+}
+
+// Check fails before anything is written if size more bytes at p would not
+// fit: with a TooLargeError if they never could, and a QuotaExceededError
+// if they do not fit in what is left. A negative size is unknown, so only
+// a full quota is refused. replaced is the size of a file the write would
+// replace, which frees its storage; the user keeps being charged for it
+// until the upload is recorded.
+func (s *QuotaService) Check(user, p string, size, replaced int64) error {
+	return s.check("", user, p, size, replaced, 0)
+}
+
+// CheckRestore is Check for restoring item to p. A user restoring what they
+// trashed themselves is charged for it already.
+func (s *QuotaService) CheckRestore(user, p string, item *models.TrashItem, replaced int64) error {
+	var charged int64
+	if s.trash != nil && item.DeletedBy == user {
+		charged = item.Size
+	}
+	return s.check("", user, p, item.Size, replaced, charged)
+}
+
+// Reserve is Check for the staged upload id, which then holds its size
+// until Release(id), so concurrent uploads cannot all count on the same
+// free space. Reserving id again replaces what it held.
+func (s *QuotaService) Reserve(id, user, p string, size, replaced int64) error {
+	return s.check(id, user, p, size, replaced, 0)
+}
+
+// Release gives up the space held for the staged upload id.
+func (s *QuotaService) Release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reserved, id)
+}
+
+// check is Check, reserving the space for id unless id is "". charged is
+// what user is already charged for out of size.
+func (s *QuotaService) check(id, user, p string, size, replaced, charged int64) error {
+	needed := max(size, 1)
+	storage, err := s.storage.UsageAt(p)
+	if _, outside := err.(*errors.ValidationError); outside {
+		// Nothing can be stored outside every area, e.g. beside the shares;
+		// the write itself reports that
+		storage = models.Usage{}
+	} else if err != nil {
+		return err
+	}
+	stored, err := s.storedBy(user)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, held := range s.reserved {
+		if key != id && held.area == storage.Name {
+			storage.Used += held.storage
+		}
+	}
+	if err := checkUsage(storage, p, storage.Name, size, needed-replaced); err != nil {
+		return err
+	}
+	usage := models.Usage{Name: user, Used: stored + s.inFlightLocked(user, id), Limit: s.limitLocked(user)}
+	if err := checkUsage(usage, p, "user "+user, size, needed-charged); err != nil {
+		return err
+	}
+	if id != "" {
+		s.reserved[id] = reservation{user: user, area: storage.Name, storage: max(size-replaced, 0), size: size}
+	}
+	return nil
+}
+
+// checkUsage is Check against one quota.
+func checkUsage(usage models.Usage, p, quota string, size, needed int64) error {
+	if usage.Limit > 0 && size > usage.Limit {
+		return &errors.TooLargeError{Path: p, Limit: usage.Limit}
+	}
+	if !usage.Fits(needed) {
+		return &errors.QuotaExceededError{Path: p, Quota: quota, Limit: usage.Limit}
+	}
+	return nil
+}
+
+// Limit counts the bytes read from reader against user's quota as they
+// arrive and fails the read once the quota would be crossed, so the
+// repository discards the partial file. Call release once the upload is
+// recorded or has failed.
+func (s *QuotaService) Limit(user, p string, reader models.ReadCloser) (limited models.ReadCloser, release func()) {
+	return s.LimitReserved("", user, p, reader)
+}
+
+// LimitReserved is Limit for the staged upload id, whose reservation is
+// not counted again while its own bytes are read.
+func (s *QuotaService) LimitReserved(id, user, p string, reader models.ReadCloser) (limited models.ReadCloser, release func()) {
+	r := &userQuotaReader{ReadCloser: reader, quotas: s, id: id, user: user, path: p}
+	return r, func() { s.reserve(id, user, p, 0, -r.reserved) }
+}
+
+// Record notes that user's upload was stored at p with size bytes. If the
+// ledger cannot be updated the user is undercounted until they upload again.
+func (s *QuotaService) Record(user, p string, size int64) {
+	if err := s.ledger.Record(p, user, size); err != nil {
+		s.logger.Error("Failed to record upload in usage ledger", "user", user, "path", p, "error", err)
+	}
+}
+
+// Forget stops counting the uploads at or below p, e.g. once deleted.
+func (s *QuotaService) Forget(p string) {
+	if err := s.ledger.Forget(p); err != nil {
+		s.logger.Error("Failed to forget uploads in usage ledger", "path", p, "error", err)
+	}
+}
+
+// Rename counts the uploads at or below p at newPath instead.
+func (s *QuotaService) Rename(p, newPath string) {
+	if err := s.ledger.Rename(p, newPath); err != nil {
+		s.logger.Error("Failed to rename uploads in usage ledger", "path", p, "newPath", newPath, "error", err)
+	}
+}
+
+// RecordTree counts the files at or below p against user instead of
+// whoever was charged for that path before, e.g. once they are restored
+// or copied there.
+func (s *QuotaService) RecordTree(fileRepo ports.FileRepository, user, p string) {
+	s.Forget(p)
+	info, err := fileRepo.Stat(p)
+	if err == nil {
+		err = walkFiles(fileRepo, p, info, nil, func(filePath string, file *models.FileInfo) {
+			s.Record(user, filePath, file.Size)
+		})
+	}
+	if err != nil {
+		s.logger.Error("Failed to record files in usage ledger", "user", user, "path", p, "error", err)
+	}
+}
+
+// Usage returns user's own usage and that of the storage areas whose root
+// user may list.
+func (s *QuotaService) Usage(user string) (models.Usage, []models.Usage, error) {
+	own, err := s.userUsage(user)
+	if err != nil {
+		return models.Usage{}, nil, err
+	}
+	areas, err := s.storage.Usages()
+	if err != nil {
+		return models.Usage{}, nil, err
+	}
+	visible := areas[:0]
+	for _, area := range areas {
+		if s.authorizer.Allowed(user, models.ActionList, area.Name) {
+			visible = append(visible, area)
+		}
+	}
+	return own, visible, nil
+}
+
+// userUsage returns what user's stored and unfinished uploads take.
+func (s *QuotaService) userUsage(user string) (models.Usage, error) {
+	stored, err := s.storedBy(user)
+	if err != nil {
+		return models.Usage{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return models.Usage{Name: user, Used: stored + s.inFlightLocked(user, ""), Limit: s.limitLocked(user)}, nil
+}
+
+// storedBy returns the bytes of user's stored uploads and of the entries
+// they trashed.
+func (s *QuotaService) storedBy(user string) (int64, error) {
+	stored, err := s.ledger.UserUsage(user)
+	if err != nil || s.trash == nil {
+		return stored, err
+	}
+	items, err := s.trash.ListTrash()
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range items {
+		if item.DeletedBy == user {
+			stored += item.Size
+		}
+	}
+	return stored, nil
+}
+
+// inFlightLocked returns the bytes user is sending or holds for staged
+// uploads, leaving out what is held for except.
+func (s *QuotaService) inFlightLocked(user, except string) int64 {
+	total := s.inFlight[user]
+	for key, held := range s.reserved {
+		if key != except && held.user == user {
+			total += held.size
+		}
+	}
+	return total
+}
+
+func (s *QuotaService) limitLocked(user string) int64 {
+	if limit, ok := s.limits[user]; ok {
+		return limit
+	}
+	return s.userLimit
+}
+
+// reserve counts n more unfinished bytes of p for user, failing if that
+// takes them and their stored bytes over their limit; what is held for the
+// staged upload id is left out. A negative n releases bytes.
+func (s *QuotaService) reserve(id, user, p string, stored, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit := s.limitLocked(user); n > 0 && limit > 0 {
+		if stored+s.inFlightLocked(user, id)+n > limit {
+			return &errors.QuotaExceededError{Path: p, Quota: "user " + user, Limit: limit}
+		}
+	}
+	s.inFlight[user] += n
+	if s.inFlight[user] == 0 {
+		delete(s.inFlight, user)
+	}
+	return nil
+}
+
+// userQuotaReader reserves the bytes of an upload against its user's quota
+// as they are read. What the user has stored is looked up once, with the
+// first bytes, rather than for every chunk.
+type userQuotaReader struct {
+	models.ReadCloser
+	quotas   *QuotaService
+	id       string // Of the staged upload being stored, if any
+	user     string
+	path     string
+	reserved int64
+	stored   int64 // Of the user, once loaded
+	loaded   bool
+}
+
+func (r *userQuotaReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if !r.loaded {
+			stored, err := r.quotas.storedBy(r.user)
+			if err != nil {
+				return n, err
+			}
+			r.stored, r.loaded = stored, true
+		}
+		if err := r.quotas.reserve(r.id, r.user, r.path, r.stored, int64(n)); err != nil {
+			return n, err
+		}
+		r.reserved += int64(n)
+	}
+	return n, err
+}
+
+// nopLogger is used until a service is given a real logger.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any)       {}
+func (nopLogger) Info(string, ...any)        {}
+func (nopLogger) Warn(string, ...any)        {}
+func (nopLogger) Error(string, ...any)       {}
+func (nopLogger) Fatal(string, ...any)       {}
+func (l nopLogger) With(...any) ports.Logger { return l }
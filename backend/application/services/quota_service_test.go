@@ -0,0 +1,281 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// newQuotaTestService limits the root to storage bytes and alice to 10.
+func newQuotaTestService(t *testing.T, storage int64) (*QuotaService, *fs.LocalFileRepository, string) {
+	t.Helper()
+	root := t.TempDir()
+	repo := fs.NewLocalFileRepository(root).WithQuota(storage)
+	ledger, err := fs.NewLocalUsageLedger(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatalf("Failed to create usage ledger: %v", err)
+	}
+	quotas := NewQuotaService(repo, ledger, acl.AllowAllAuthorizer{}).WithUserLimits(0, map[string]int64{"alice": 10})
+	return quotas, repo, root
+}
+
+func TestUploadServiceUserQuota(t *testing.T) {
+	quotas, repo, root := newQuotaTestService(t, 100)
+	uploads := NewUploadService(repo, acl.AllowAllAuthorizer{}, models.ConflictOverwrite).WithQuotas(quotas)
+
+	if _, err := uploads.Execute("alice", []models.UploadPart{testPart{"a.txt", "123456"}}); err != nil {
+		t.Fatalf("Failed to upload within the quota: %v", err)
+	}
+	// The second file crosses alice's quota part way through
+	_, err := uploads.Execute("alice", []models.UploadPart{testPart{"b.txt", "123456"}})
+	if quota, ok := err.(*errors.QuotaExceededError); !ok || quota.Quota != "user alice" {
+		t.Fatalf("Expected alice's quota to be exceeded, got %T %v", err, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected the rejected file to be discarded, got %v", err)
+	}
+	if _, err := uploads.Execute("bob", []models.UploadPart{testPart{"c.txt", strings.Repeat("x", 50)}}); err != nil {
+		t.Errorf("Expected users without a quota to be unlimited, got %v", err)
+	}
+
+	deletes := NewDeleteService(repo, acl.AllowAllAuthorizer{}).WithQuotas(quotas)
+	if err := deletes.Execute("alice", "/a.txt", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	own, areas, err := quotas.Usage("alice")
+	if err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	if own != (models.Usage{Name: "alice", Used: 0, Limit: 10}) {
+		t.Errorf("Expected deleted uploads to stop counting, got %+v", own)
+	}
+	if len(areas) != 1 || areas[0] != (models.Usage{Name: "/", Used: 50, Limit: 100}) {
+		t.Errorf("Expected the root directory's usage, got %+v", areas)
+	}
+}
+
+func TestRestoresAndCopiesCountAgainstUserQuota(t *testing.T) {
+	quotas, repo, root := newQuotaTestService(t, 100)
+	uploads := NewUploadService(repo, acl.AllowAllAuthorizer{}, models.ConflictOverwrite).WithQuotas(quotas)
+	quotas.WithTrash(repo)
+	deletes := NewDeleteService(repo, acl.AllowAllAuthorizer{}).WithTrash(repo).WithQuotas(quotas)
+	trash := NewTrashService(repo, repo, acl.AllowAllAuthorizer{}).WithQuotas(quotas)
+	copies := NewCopyService(repo, acl.AllowAllAuthorizer{}).WithQuotas(quotas)
+	used := func() int64 {
+		own, _, err := quotas.Usage("alice")
+		if err != nil {
+			t.Fatalf("Failed to get usage: %v", err)
+		}
+		return own.Used
+	}
+
+	// Trashed entries keep counting against whoever trashed them
+	if _, err := uploads.Execute("alice", []models.UploadPart{testPart{"a.txt", "123456"}}); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+	if err := deletes.Execute("alice", "/a.txt", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if got := used(); got != 6 {
+		t.Errorf("Expected the trashed file to count, got %d", got)
+	}
+	_, err := uploads.Execute("alice", []models.UploadPart{testPart{"b.txt", "123456"}})
+	if _, ok := err.(*errors.QuotaExceededError); !ok {
+		t.Fatalf("Expected the trash to leave no room, got %T %v", err, err)
+	}
+
+	// Restoring her own entry costs nothing more
+	items, err := trash.List("alice")
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected one trashed entry, got %v %v", items, err)
+	}
+	if _, err := trash.Restore("alice", items[0].ID, "", models.ConflictReject); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if got := used(); got != 6 {
+		t.Errorf("Expected the restored file to count once, got %d", got)
+	}
+
+	// Only purging frees the space
+	if err := deletes.Execute("alice", "/a.txt", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := trash.Empty("alice"); err != nil {
+		t.Fatalf("Failed to empty the trash: %v", err)
+	}
+	if _, err := uploads.Execute("alice", []models.UploadPart{testPart{"b.txt", "123456"}}); err != nil {
+		t.Fatalf("Failed to upload after purging: %v", err)
+	}
+
+	// Someone else's trashed file is charged to whoever trashed it
+	if err := os.WriteFile(filepath.Join(root, "other.bin"), []byte("xyz"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := deletes.Execute("bob", "/other.bin", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if got := used(); got != 6 {
+		t.Errorf("Expected bob's trash not to count for alice, got %d", got)
+	}
+
+	// Copying someone else's files costs their size
+	if err := os.WriteFile(filepath.Join(root, "big.bin"), []byte(strings.Repeat("x", 8)), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	err = copies.Execute("alice", "/big.bin", "/mine.bin", false)
+	if _, ok := err.(*errors.QuotaExceededError); !ok {
+		t.Fatalf("Expected the copy to exceed alice's quota, got %T %v", err, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "small.bin"), []byte("xyz"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := copies.Execute("alice", "/small.bin", "/mine.bin", false); err != nil {
+		t.Fatalf("Failed to copy: %v", err)
+	}
+	if got := used(); got != 9 {
+		t.Errorf("Expected the copy to count, got %d", got)
+	}
+}
+
+func TestResumableUploadQuota(t *testing.T) {
+	quotas, repo, root := newQuotaTestService(t, 20)
+	if err := os.WriteFile(filepath.Join(root, "old.bin"), []byte(strings.Repeat("x", 15)), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	store, err := fs.NewLocalUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create upload store: %v", err)
+	}
+	service := NewResumableUploadService(store, repo, acl.AllowAllAuthorizer{}, models.ConflictOverwrite, time.Hour).WithQuotas(quotas)
+
+	tests := []struct {
+		name, target string
+		length       int64
+		want         string // failureReason of the error, or "" to accept
+	}{
+		{"larger than the storage quota", "/big.bin", 21, "too_large"},
+		{"more than is left", "/new.bin", 6, "quota"},
+		{"replacing a file", "/old.bin", 18, ""},
+	}
+	for _, tt := range tests {
+		_, err := service.Create("bob", tt.target, tt.length, nil)
+		if tt.want == "" && err != nil {
+			t.Errorf("%s: expected the upload to be accepted, got %v", tt.name, err)
+		} else if tt.want != "" && (err == nil || failureReason(err) != tt.want) {
+			t.Errorf("%s: expected a %s error, got %T %v", tt.name, tt.want, err, err)
+		}
+	}
+
+	// alice may only send 10 bytes however much storage is left
+	if _, err := service.Create("alice", "/mine.bin", 11, nil); err == nil {
+		t.Error("Expected an upload over alice's quota to be refused")
+	}
+}
+
+func TestResumableUploadReservesSpace(t *testing.T) {
+	quotas, repo, _ := newQuotaTestService(t, 20)
+	store, err := fs.NewLocalUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create upload store: %v", err)
+	}
+	service := NewResumableUploadService(store, repo, acl.AllowAllAuthorizer{}, models.ConflictOverwrite, time.Hour).WithQuotas(quotas)
+	create := func(user, target string, length int64) (*models.UploadSession, error) {
+		t.Helper()
+		return service.Create(user, target, length, nil)
+	}
+
+	first, err := create("bob", "/a.bin", 15)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	if _, err := create("bob", "/b.bin", 10); failureReason(err) != "quota" {
+		t.Fatalf("Expected the first upload's space to be held, got %v", err)
+	}
+	if err := service.Terminate("bob", first.ID); err != nil {
+		t.Fatalf("Failed to terminate: %v", err)
+	}
+	second, err := create("bob", "/b.bin", 10)
+	if err != nil {
+		t.Fatalf("Expected a terminated upload to free its space, got %v", err)
+	}
+
+	// Storing the file turns the held space into used space
+	if _, err := service.Append("bob", second.ID, 0, strings.NewReader(strings.Repeat("x", 10))); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	if _, err := create("bob", "/c.bin", 10); err != nil {
+		t.Errorf("Expected the rest of the space to be free, got %v", err)
+	}
+	if _, err := create("bob", "/d.bin", 1); failureReason(err) != "quota" {
+		t.Errorf("Expected the storage quota to be full, got %v", err)
+	}
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if purged, err := service.PurgeExpired(); err != nil || purged != 1 {
+		t.Fatalf("Failed to purge expired uploads: %d %v", purged, err)
+	}
+	third, err := create("bob", "/d.bin", 10)
+	if err != nil {
+		t.Fatalf("Expected an expired upload to free its space, got %v", err)
+	}
+
+	// The held space counts against the user's quota as well
+	if err := service.Terminate("bob", third.ID); err != nil {
+		t.Fatalf("Failed to terminate: %v", err)
+	}
+	if err := repo.Delete("/b.bin", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if _, err := create("alice", "/e.bin", 6); err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	_, err = create("alice", "/f.bin", 5)
+	if quota, ok := err.(*errors.QuotaExceededError); !ok || quota.Quota != "user alice" {
+		t.Errorf("Expected alice's quota to be exceeded, got %T %v", err, err)
+	}
+}
+
+// brokenLedger fails every update.
+type brokenLedger struct{}
+
+func (brokenLedger) Record(string, string, int64) error { return fmt.Errorf("disk full") }
+func (brokenLedger) Forget(string) error                { return fmt.Errorf("disk full") }
+func (brokenLedger) Rename(string, string) error        { return fmt.Errorf("disk full") }
+func (brokenLedger) UserUsage(string) (int64, error)    { return 0, nil }
+
+// errorLogger keeps the messages logged as errors.
+type errorLogger struct {
+	nopLogger
+	errors []string
+}
+
+func (l *errorLogger) Error(msg string, _ ...any) { l.errors = append(l.errors, msg) }
+
+func TestQuotaLedgerFailuresAreLogged(t *testing.T) {
+	repo := fs.NewLocalFileRepository(t.TempDir())
+	logger := &errorLogger{}
+	quotas := NewQuotaService(repo, brokenLedger{}, acl.AllowAllAuthorizer{}).WithLogger(logger)
+
+	uploads := NewUploadService(repo, acl.AllowAllAuthorizer{}, models.ConflictOverwrite).WithQuotas(quotas)
+	if _, err := uploads.Execute("alice", []models.UploadPart{testPart{"a.txt", "123"}}); err != nil {
+		t.Fatalf("Expected the upload to stand without the ledger, got %v", err)
+	}
+	moves := NewMoveService(repo, acl.AllowAllAuthorizer{}).WithQuotas(quotas)
+	if err := moves.Execute("alice", "/a.txt", "/b.txt", false); err != nil {
+		t.Fatalf("Expected the move to stand without the ledger, got %v", err)
+	}
+	deletes := NewDeleteService(repo, acl.AllowAllAuthorizer{}).WithQuotas(quotas)
+	if err := deletes.Execute("alice", "/b.txt", false); err != nil {
+		t.Fatalf("Expected the delete to stand without the ledger, got %v", err)
+	}
+	if len(logger.errors) != 3 {
+		t.Errorf("Expected each failed ledger update to be logged, got %q", logger.errors)
+	}
+}
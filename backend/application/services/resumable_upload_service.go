@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// DefaultUploadExpiry is how long an idle resumable upload is kept.
+const DefaultUploadExpiry = 24 * time.Hour
+
+// ResumableUploadService manages uploads that arrive in chunks over several
+// requests. Chunks are staged in an UploadStore and the finished file is
+// written through the FileRepository once the last byte arrives.
+type ResumableUploadService struct {
+	store      ports.UploadStore
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	policy     models.ConflictPolicy
+	expiry     time.Duration
+	metrics    ports.Metrics
+	maxSize    atomic.Int64 // Largest upload accepted; zero means no limit
+	modes      ports.ModeResolver
+	quotas     *QuotaService
+	now        func() time.Time
+}
+
+func NewResumableUploadService(store ports.UploadStore, fileRepo ports.FileRepository, authorizer ports.Authorizer, policy models.ConflictPolicy, expiry time.Duration) *ResumableUploadService {
+	return &ResumableUploadService{
+		store:      store,
+		fileRepo:   fileRepo,
+		authorizer: authorizer,
+		policy:     policy,
+		expiry:     expiry,
+		metrics:    nopMetrics{},
+		now:        time.Now,
+	}
+}
+
+// WithMetrics counts received chunk bytes and failed uploads
+func (s *ResumableUploadService) WithMetrics(metrics ports.Metrics) *ResumableUploadService {
+	s.metrics = metrics
+	return s
+}
+
+// WithMaxSize rejects uploads longer than maxSize bytes; zero means no limit.
+// It may be called again while uploads are running, e.g. on config reload.
+func (s *ResumableUploadService) WithMaxSize(maxSize int64) *ResumableUploadService {
+	s.maxSize.Store(maxSize)
+	return s
+}
+
+// WithModes applies the access mode at each destination to the conflict
+// policy, e.g. so write-once shares never replace a file
+func (s *ResumableUploadService) WithModes(modes ports.ModeResolver) *ResumableUploadService {
+	s.modes = modes
+	return s
+}
+
+// WithQuotas refuses uploads that do not fit in the storage or user quota,
+// holds the space of each upload until it is stored or abandoned, and
+// counts stored files against their uploader
+func (s *ResumableUploadService) WithQuotas(quotas *QuotaService) *ResumableUploadService {
+	s.quotas = quotas
+	return s
+}
+
+// Create starts an upload of length bytes to target. A zero-length upload
+// is committed immediately.
+func (s *ResumableUploadService) Create(user, target string, length int64, metadata map[string]string) (*models.UploadSession, error) {
+	target = path.Clean("/" + target)
+	if isRoot(target) {
+		return nil, errors.NewValidationError("path", target, "must name a file")
+	}
+	if length < 0 {
+		return nil, errors.NewValidationError("length", length, "must not be negative")
+	}
+	if maxSize := s.maxSize.Load(); maxSize > 0 && length > maxSize {
+		s.metrics.UploadFailed("too_large")
+		return nil, &errors.TooLargeError{Path: target, Limit: maxSize}
+	}
+	if err := authorize(s.authorizer, user, models.ActionWrite, target); err != nil {
+		return nil, err
+	}
+	if err := s.ensureNotDirectory(target); err != nil {
+		return nil, err
+	}
+	if conflictPolicyFor(s.modes, s.policy, target) == models.ConflictReject {
+		// Fail before the client sends any bytes
+		exists, err := s.fileRepo.FileExists(target)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, &errors.ConflictError{Path: target, Reason: "already exists"}
+		}
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reserveSpace(id, user, target, length); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	session := &models.UploadSession{
+		ID:        id,
+		Owner:     user,
+		Path:      target,
+		Length:    length,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.expiry),
+	}
+	if err := s.store.Create(session); err != nil {
+		s.releaseSpace(id)
+		return nil, err
+	}
+	if session.Complete() {
+		if err := s.commit(user, session); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// Status returns the session so clients can learn where to resume.
+func (s *ResumableUploadService) Status(user, id string) (*models.UploadSession, error) {
+	return s.session(user, id)
+}
+
+// Append stores a chunk that starts at offset. The bytes read are kept even
+// if the body is cut short, so the client can resume from the new offset.
+// The file is committed when the upload becomes complete.
+func (s *ResumableUploadService) Append(user, id string, offset int64, chunk io.Reader) (*models.UploadSession, error) {
+	session, err := s.session(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Complete() {
+		return nil, &errors.ConflictError{Path: session.Path, Reason: "upload is already complete"}
+	}
+	// Renews the reservation, which is lost when the server restarts; the
+	// space may have gone meanwhile
+	if err := s.reserveSpace(id, user, session.Path, session.Length); err != nil {
+		return nil, err
+	}
+
+	newOffset, appendErr := s.store.Append(id, offset, session.Length-offset, chunk)
+	if _, conflict := appendErr.(*errors.ConflictError); conflict {
+		s.metrics.UploadFailed("offset_mismatch")
+		return nil, appendErr
+	}
+	if newOffset > offset {
+		s.metrics.AddBytesUploaded(newOffset - offset)
+	}
+	session.Offset = newOffset
+	session.ExpiresAt = s.now().Add(s.expiry)
+	if err := s.store.Save(session); err != nil {
+		if _, gone := err.(*errors.NotFoundError); gone {
+			// Terminated or purged meanwhile; drop the renewed reservation
+			s.releaseSpace(id)
+		}
+		return nil, err
+	}
+	if appendErr != nil {
+		s.metrics.UploadFailed("interrupted")
+		return session, appendErr
+	}
+
+	if session.Complete() {
+		if err := s.commit(user, session); err != nil {
+			s.metrics.UploadFailed(failureReason(err))
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// Terminate abandons an upload and discards its staged bytes.
+func (s *ResumableUploadService) Terminate(user, id string) error {
+	if _, err := s.session(user, id); err != nil {
+		return err
+	}
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	s.releaseSpace(id)
+	return nil
+}
+
+// PurgeExpired discards sessions that have been idle past their expiry and
+// returns how many were removed.
+func (s *ResumableUploadService) PurgeExpired() (int, error) {
+	sessions, err := s.store.List()
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	purged := 0
+	for _, session := range sessions {
+		if now.Before(session.ExpiresAt) {
+			continue
+		}
+		if err := s.store.Delete(session.ID); err != nil {
+			return purged, err
+		}
+		s.releaseSpace(session.ID)
+		purged++
+	}
+	return purged, nil
+}
+
+// RunCleanup calls PurgeExpired every interval until ctx is done.
+func (s *ResumableUploadService) RunCleanup(ctx context.Context, interval time.Duration, logger ports.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				logger.Error("Failed to purge expired uploads", "error", err)
+			} else if purged > 0 {
+				logger.Info("Purged expired uploads", "count", purged)
+			}
+		}
+	}
+}
+
+// session loads id, hiding sessions that are expired or belong to someone else.
+func (s *ResumableUploadService) session(user, id string) (*models.UploadSession, error) {
+	session, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Owner != user || !s.now().Before(session.ExpiresAt) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	return session, nil
+}
+
+// commit writes the staged bytes to the destination and drops the session.
+// The session is dropped even if the write fails: nothing more can be
+// appended to it, so the client has to start over.
+func (s *ResumableUploadService) commit(user string, session *models.UploadSession) error {
+	err := s.writeStaged(user, session)
+	s.releaseSpace(session.ID)
+	if deleteErr := s.store.Delete(session.ID); err == nil {
+		err = deleteErr
+	}
+	return err
+}
+
+// writeStaged writes the staged bytes of session to its destination.
+// session.Path is updated if the conflict policy picked another name the
+// uploader may see.
+// Permissions are checked again because the policy may have changed.
+func (s *ResumableUploadService) writeStaged(user string, session *models.UploadSession) error {
+	if err := authorize(s.authorizer, user, models.ActionWrite, session.Path); err != nil {
+		return err
+	}
+	if err := s.ensureNotDirectory(session.Path); err != nil {
+		return err
+	}
+	if err := s.fileRepo.CreateDirectory(path.Dir(session.Path)); err != nil {
+		return err
+	}
+
+	content, err := s.store.Open(session.ID)
+	if err != nil {
+		return err
+	}
+	var release func()
+	if s.quotas != nil {
+		content, release = s.quotas.LimitReserved(session.ID, user, session.Path, content)
+		defer release()
+	}
+	stored, written, err := s.fileRepo.WriteFile(session.Path, content, conflictPolicyFor(s.modes, s.policy, session.Path))
+	if err != nil {
+		return err
+	}
+	if s.quotas != nil {
+		s.quotas.Record(user, stored, written)
+	}
+	session.Path = reportedPath(s.modes, session.Path, stored)
+	return nil
+}
+
+// reserveSpace holds length bytes at target for upload id, failing if they
+// cannot be stored there. A file the upload would replace frees its space.
+func (s *ResumableUploadService) reserveSpace(id, user, target string, length int64) error {
+	if s.quotas == nil {
+		return nil
+	}
+	var replaced int64
+	if conflictPolicyFor(s.modes, s.policy, target) == models.ConflictOverwrite {
+		if info, err := s.fileRepo.Stat(target); err == nil && !info.IsDir {
+			replaced = info.Size
+		}
+	}
+	err := s.quotas.Reserve(id, user, target, length, replaced)
+	if err != nil {
+		s.metrics.UploadFailed(failureReason(err))
+	}
+	return err
+}
+
+// releaseSpace gives up the space held for upload id.
+func (s *ResumableUploadService) releaseSpace(id string) {
+	if s.quotas != nil {
+		s.quotas.Release(id)
+	}
+}
+
+// ensureNotDirectory rejects a destination that is an existing directory.
+func (s *ResumableUploadService) ensureNotDirectory(target string) error {
+	exists, err := s.fileRepo.FileExists(target)
+	if err != nil || !exists {
+		return err
+	}
+	isDir, err := s.fileRepo.IsDirectory(target)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		return &errors.ConflictError{Path: target, Reason: "is a directory"}
+	}
+	return nil
+}
+
+// newUploadID returns a random session identifier.
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate upload ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func newResumableTestService(t *testing.T) (*ResumableUploadService, string) {
+	t.Helper()
+	root := t.TempDir()
+	store, err := fs.NewLocalUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create upload store: %v", err)
+	}
+	return NewResumableUploadService(store, fs.NewLocalFileRepository(root), acl.AllowAllAuthorizer{}, models.ConflictOverwrite, time.Hour), root
+}
+
+func TestResumableUploadCommitsWhenComplete(t *testing.T) {
+	service, root := newResumableTestService(t)
+
+	session, err := service.Create("alice", "/docs/report.txt", 11, nil)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	if _, err := service.Append("alice", session.ID, 0, strings.NewReader("hello ")); err != nil {
+		t.Fatalf("Failed to append first chunk: %v", err)
+	}
+	if _, err := service.Status("bob", session.ID); err == nil {
+		t.Error("Expected another user's session to be hidden")
+	}
+	if _, err := service.Append("alice", session.ID, 0, strings.NewReader("again")); err == nil {
+		t.Error("Expected a stale offset to be rejected")
+	} else if _, ok := err.(*errors.ConflictError); !ok {
+		t.Errorf("Expected ConflictError for stale offset, got %T", err)
+	}
+
+	session, err = service.Append("alice", session.ID, 6, strings.NewReader("world and more"))
+	if err != nil {
+		t.Fatalf("Failed to append last chunk: %v", err)
+	}
+	if !session.Complete() || session.Offset != 11 {
+		t.Errorf("Expected complete upload at offset 11, got %d", session.Offset)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "docs", "report.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read committed file: %v", err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("Expected %q, got %q", "hello world", data)
+	}
+	if _, err := service.Status("alice", session.ID); err == nil {
+		t.Error("Expected the session to be gone after commit")
+	}
+}
+
+func TestResumableUploadPurgesExpiredSessions(t *testing.T) {
+	service, _ := newResumableTestService(t)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	stale, err := service.Create("alice", "/stale.bin", 10, nil)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	now = now.Add(30 * time.Minute)
+	fresh, err := service.Create("alice", "/fresh.bin", 10, nil)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+
+	now = now.Add(45 * time.Minute)
+	if _, err := service.Status("alice", stale.ID); err == nil {
+		t.Error("Expected an expired session to be hidden before purging")
+	}
+	purged, err := service.PurgeExpired()
+	if err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged session, got %d", purged)
+	}
+	if _, err := service.Status("alice", fresh.ID); err != nil {
+		t.Errorf("Expected fresh session to survive: %v", err)
+	}
+}
+
+func TestResumableUploadMaxSize(t *testing.T) {
+	service, _ := newResumableTestService(t)
+	service.WithMaxSize(10)
+
+	if _, err := service.Create("alice", "/big.bin", 11, nil); err == nil {
+		t.Error("Expected an upload over the limit to be refused")
+	} else if _, ok := err.(*errors.TooLargeError); !ok {
+		t.Errorf("Expected TooLargeError, got %T", err)
+	}
+	if _, err := service.Create("alice", "/ok.bin", 10, nil); err != nil {
+		t.Errorf("Expected an upload at the limit to be accepted, got %v", err)
+	}
+}
+
+func TestResumableUploadDropsFailedCommit(t *testing.T) {
+	service, root := newResumableTestService(t)
+	service.policy = models.ConflictReject
+
+	session, err := service.Create("alice", "/taken.txt", 5, nil)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	// The name is taken while the bytes are on their way
+	if err := os.WriteFile(filepath.Join(root, "taken.txt"), []byte("first"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := service.Append("alice", session.ID, 0, strings.NewReader("hello")); err == nil {
+		t.Fatal("Expected the commit to fail")
+	} else if _, ok := err.(*errors.ConflictError); !ok {
+		t.Errorf("Expected ConflictError, got %T %v", err, err)
+	}
+
+	// The client cannot finish the upload, so it is told to start over
+	if _, err := service.Status("alice", session.ID); err == nil {
+		t.Error("Expected the failed session to be gone")
+	}
+	if _, err := service.Append("alice", session.ID, 5, strings.NewReader("")); err == nil {
+		t.Error("Expected a later PATCH to find no session")
+	} else if _, ok := err.(*errors.NotFoundError); !ok {
+		t.Errorf("Expected NotFoundError, got %T %v", err, err)
+	}
+}
+
+func TestResumableUploadStaysTerminated(t *testing.T) {
+	service, _ := newResumableTestService(t)
+
+	session, err := service.Create("alice", "/late.bin", 10, nil)
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	if err := service.Terminate("alice", session.ID); err != nil {
+		t.Fatalf("Failed to terminate upload: %v", err)
+	}
+
+	// A PATCH that read the session before the DELETE saves it last
+	if err := service.store.Save(session); err == nil {
+		t.Error("Expected saving a terminated session to fail")
+	} else if _, ok := err.(*errors.NotFoundError); !ok {
+		t.Errorf("Expected NotFoundError, got %T %v", err, err)
+	}
+	if _, err := service.Status("alice", session.ID); err == nil {
+		t.Error("Expected the terminated session to stay gone")
+	}
+}
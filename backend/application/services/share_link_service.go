@@ -0,0 +1,499 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// DefaultLinkExpiry is how long a share link lasts when no expiry is given.
+const DefaultLinkExpiry = 7 * 24 * time.Hour
+
+// CreateLinkRequest describes a share link to create.
+type CreateLinkRequest struct {
+	Path         string
+	Permission   models.LinkPermission
+	ExpiresIn    time.Duration // Zero means DefaultLinkExpiry
+	Password     string        // Empty means none
+	MaxDownloads int           // Zero means unlimited
+
+	// Upload-into links only
+	MaxFileSize       int64    // Zero means the server's limit
+	AllowedExtensions []string // e.g. "log" or ".log"; empty allows any
+}
+
+// maxFilenameBytes keeps names sent through links within common filesystem
+// limits of 255 bytes, leaving room for the " (n)" a taken name gets.
+const maxFilenameBytes = 255 - len(" (1000)")
+
+// maxUploaderBytes caps the name and email uploaders give.
+const maxUploaderBytes = 200
+
+// ShareLinkService creates and resolves share links. Every use of a link
+// goes through the regular services as the link's owner, so the owner's
+// ACLs and the share's access mode still apply. Give it an UploadService
+// that never overwrites, so one uploader cannot replace another's files.
+type ShareLinkService struct {
+	links      ports.LinkStore
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	hasher     ports.PasswordHasher
+	list       *ListFilesService
+	files      *DownloadFileService
+	zips       *DownloadZipService
+	uploads    *UploadService
+	now        func() time.Time
+}
+
+func NewShareLinkService(
+	links ports.LinkStore,
+	fileRepo ports.FileRepository,
+	authorizer ports.Authorizer,
+	hasher ports.PasswordHasher,
+	list *ListFilesService,
+	files *DownloadFileService,
+	zips *DownloadZipService,
+	uploads *UploadService,
+) *ShareLinkService {
+	return &ShareLinkService{
+		links:      links,
+		fileRepo:   fileRepo,
+		authorizer: authorizer,
+		hasher:     hasher,
+		list:       list,
+		files:      files,
+		zips:       zips,
+		uploads:    uploads,
+		now:        time.Now,
+	}
+}
+
+// Create stores a new link to req.Path owned by user, who must currently
+// be allowed to do what the link permits.
+func (s *ShareLinkService) Create(user string, req CreateLinkRequest) (*models.ShareLink, error) {
+	permission, err := models.ParseLinkPermission(string(req.Permission))
+	if err != nil {
+		return nil, errors.NewValidationError("permission", req.Permission, err.Error())
+	}
+	if req.ExpiresIn < 0 {
+		return nil, errors.NewValidationError("expiresIn", req.ExpiresIn, "must not be negative")
+	}
+	if req.MaxDownloads < 0 {
+		return nil, errors.NewValidationError("maxDownloads", req.MaxDownloads, "must not be negative")
+	}
+	if req.MaxFileSize < 0 {
+		return nil, errors.NewValidationError("maxFileSize", req.MaxFileSize, "must not be negative")
+	}
+	if permission != models.LinkUpload && (req.MaxFileSize > 0 || len(req.AllowedExtensions) > 0) {
+		return nil, errors.NewValidationError("permission", permission, "file size and extension limits need an upload-into link")
+	}
+	extensions, err := normalizeExtensions(req.AllowedExtensions)
+	if err != nil {
+		return nil, err
+	}
+
+	target := path.Clean("/" + req.Path)
+	if permission == models.LinkUpload {
+		err = authorize(s.authorizer, user, models.ActionWrite, target)
+	} else {
+		err = authorizeAny(s.authorizer, user, target, models.ActionRead, models.ActionList)
+	}
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.fileRepo.FileExists(target)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &errors.NotFoundError{Path: target}
+	}
+	isDir, err := s.fileRepo.IsDirectory(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case permission == models.LinkUpload && !isDir:
+		return nil, errors.NewValidationError("path", target, "upload links must point at a directory")
+	case permission == models.LinkUpload:
+		err = authorize(s.authorizer, user, models.ActionWrite, target)
+	case isDir:
+		err = authorize(s.authorizer, user, models.ActionList, target)
+	default:
+		err = authorize(s.authorizer, user, models.ActionRead, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newLinkToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = DefaultLinkExpiry
+	}
+	now := s.now()
+	link := &models.ShareLink{
+		Token:             token,
+		Owner:             user,
+		Path:              target,
+		IsDir:             isDir,
+		Permission:        permission,
+		MaxDownloads:      req.MaxDownloads,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(expiresIn),
+		MaxFileSize:       req.MaxFileSize,
+		AllowedExtensions: extensions,
+	}
+	if req.Password != "" {
+		if link.PasswordHash, err = s.hasher.Hash(req.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.links.Save(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// List returns user's links that have not expired yet, oldest first.
+func (s *ShareLinkService) List(user string) ([]*models.ShareLink, error) {
+	links, err := s.links.List()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	owned := []*models.ShareLink{}
+	for _, link := range links {
+		if link.Owner == user && !link.Expired(now) {
+			owned = append(owned, link)
+		}
+	}
+	return owned, nil
+}
+
+// Get returns one of user's links, including who uploaded what through it.
+func (s *ShareLinkService) Get(user, token string) (*models.ShareLink, error) {
+	link, err := s.links.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	if link.Owner != user {
+		return nil, &errors.NotFoundError{Path: token}
+	}
+	return link, nil
+}
+
+// Revoke deletes one of user's links. Links of other users are reported as
+// not found rather than forbidden, so tokens cannot be probed.
+func (s *ShareLinkService) Revoke(user, token string) error {
+	link, err := s.links.Get(token)
+	if err != nil {
+		return err
+	}
+	if link.Owner != user {
+		return &errors.NotFoundError{Path: token}
+	}
+	return s.links.Delete(token)
+}
+
+// Open looks up token for a public request, checking its expiry and password.
+func (s *ShareLinkService) Open(token, password string) (*models.ShareLink, error) {
+	link, err := s.links.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	if link.Expired(s.now()) {
+		return nil, &errors.GoneError{Path: token, Reason: "link expired"}
+	}
+	if link.PasswordHash != "" && !s.hasher.Verify(link.PasswordHash, password) {
+		return nil, &errors.UnauthorizedError{Reason: "link password required"}
+	}
+	if link.Exhausted() {
+		return nil, &errors.GoneError{Path: token, Reason: "download limit reached"}
+	}
+	return link, nil
+}
+
+// Browse lists sub, a path inside the link's directory. It returns nil when
+// sub is a file. Entry URLs are relative to the link, hiding where it points.
+func (s *ShareLinkService) Browse(link *models.ShareLink, sub string) (*models.PageData, error) {
+	if link.Permission == models.LinkUpload {
+		return nil, &errors.ForbiddenError{Path: sub, Action: string(models.ActionList)}
+	}
+	target, err := linkTarget(link, sub)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.list.Execute(link.Owner, target)
+	if err != nil || page == nil {
+		return page, err
+	}
+	page.Root = path.Clean("/" + sub)
+	for _, f := range page.Files {
+		f.URL = linkRelative(link, f.URL)
+		f.ZipURL = f.URL + "?zip=1"
+	}
+	return page, nil
+}
+
+// IsDirectory reports whether sub is a directory the link's owner may
+// archive. Errors count as not a directory; using the path reports them.
+func (s *ShareLinkService) IsDirectory(link *models.ShareLink, sub string) bool {
+	if link.Permission == models.LinkUpload {
+		return false
+	}
+	target, err := linkTarget(link, sub)
+	if err != nil || authorize(s.authorizer, link.Owner, models.ActionZip, target) != nil {
+		return false
+	}
+	isDir, err := s.fileRepo.IsDirectory(target)
+	return err == nil && isDir
+}
+
+// Download opens sub, a file inside the link or the linked file itself. It
+// returns nil for directories. The caller counts the download with
+// CountDownload before sending any of the file, so HEAD and Not Modified
+// responses do not use a download up.
+func (s *ShareLinkService) Download(link *models.ShareLink, sub string) (*models.FileContent, error) {
+	if link.Permission == models.LinkUpload {
+		return nil, &errors.ForbiddenError{Path: sub, Action: string(models.ActionRead)}
+	}
+	if link.Exhausted() {
+		return nil, &errors.GoneError{Path: link.Token, Reason: "download limit reached"}
+	}
+	target, err := linkTarget(link, sub)
+	if err != nil {
+		return nil, err
+	}
+	file, err := s.files.Execute(link.Owner, target)
+	if err != nil || file == nil {
+		return file, err
+	}
+	file.Name = path.Base(target)
+	return file, nil
+}
+
+// Zip archives sub, a directory inside the link. It returns a nil stream
+// when sub is not a directory. As with Download, the caller counts the
+// archive with CountDownload before sending it.
+func (s *ShareLinkService) Zip(link *models.ShareLink, sub string) (models.ReadCloser, string, error) {
+	if link.Permission != models.LinkDownload {
+		return nil, "", &errors.ForbiddenError{Path: sub, Action: string(models.ActionZip)}
+	}
+	if link.Exhausted() {
+		return nil, "", &errors.GoneError{Path: link.Token, Reason: "download limit reached"}
+	}
+	target, err := linkTarget(link, sub)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.zips.Execute(link.Owner, target)
+}
+
+// Upload stores parts directly in an upload-into link's directory and
+// records them, with uploader, on the link. Names are sanitized and any
+// directories in them dropped; files the link does not accept are refused
+// before anything is written. Stored names are reported relative to the link.
+func (s *ShareLinkService) Upload(link *models.ShareLink, uploader models.Uploader, parts []models.UploadPart) ([]models.FileUpload, error) {
+	if link.Permission != models.LinkUpload {
+		return nil, &errors.ForbiddenError{Path: "/", Action: string(models.ActionWrite)}
+	}
+	uploader, err := cleanUploader(uploader)
+	if err != nil {
+		for _, part := range parts {
+			part.Content().Close()
+		}
+		return nil, err
+	}
+
+	var accepted []models.UploadPart
+	var refused error
+	for _, part := range parts {
+		name := sanitizeFilename(part.Filename())
+		if name == "" {
+			part.Content().Close()
+			continue
+		}
+		if !link.AllowsExtension(name) {
+			part.Content().Close()
+			if refused == nil {
+				refused = errors.NewValidationError("filename", name, "extension not accepted; allowed: "+strings.Join(link.AllowedExtensions, ", "))
+			}
+			continue
+		}
+		filename := path.Join(link.Path, name)
+		var content models.ReadCloser = part.Content()
+		if link.MaxFileSize > 0 {
+			content = &sizeLimitedReader{ReadCloser: content, path: filename, remaining: link.MaxFileSize, limit: link.MaxFileSize}
+		}
+		accepted = append(accepted, &linkUploadPart{name: filename, content: content})
+	}
+
+	var uploads []models.FileUpload
+	if len(accepted) > 0 {
+		uploads, err = s.uploads.Execute(link.Owner, accepted)
+	}
+	if len(uploads) == 0 {
+		if err == nil {
+			err = refused
+		}
+		return nil, err
+	}
+
+	now := s.now()
+	received := make([]models.ReceivedFile, len(uploads))
+	for i := range uploads {
+		uploads[i].Filename = strings.TrimPrefix(linkRelative(link, uploads[i].Filename), "/")
+		received[i] = models.ReceivedFile{Name: uploads[i].Filename, Size: uploads[i].Size, Uploader: uploader, UploadedAt: now}
+	}
+	if _, err := s.links.Update(link.Token, func(l *models.ShareLink) error {
+		l.Uploads = append(l.Uploads, received...)
+		return nil
+	}); err != nil {
+		return uploads, err
+	}
+	return uploads, nil
+}
+
+// CountDownload records one download of link, failing once its limit is
+// reached. The check and the count are one update of the link store, so
+// concurrent requests cannot both take the last download. Every response
+// that carries file content counts, including a single range.
+func (s *ShareLinkService) CountDownload(link *models.ShareLink) error {
+	updated, err := s.links.Update(link.Token, func(l *models.ShareLink) error {
+		if l.Exhausted() {
+			return &errors.GoneError{Path: l.Token, Reason: "download limit reached"}
+		}
+		l.Downloads++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*link = *updated
+	return nil
+}
+
+// linkTarget resolves sub against the link. A file link only reaches itself.
+func linkTarget(link *models.ShareLink, sub string) (string, error) {
+	sub = path.Clean("/" + sub)
+	if !link.IsDir && sub != "/" {
+		return "", &errors.NotFoundError{Path: sub}
+	}
+	return path.Join(link.Path, sub), nil
+}
+
+// linkRelative turns a repository path below the link into one relative to it.
+func linkRelative(link *models.ShareLink, p string) string {
+	p = path.Clean("/" + p)
+	if link.Path == "/" {
+		return p
+	}
+	return path.Clean("/" + strings.TrimPrefix(p, link.Path))
+}
+
+// linkUploadPart is an upload renamed into a link's directory.
+type linkUploadPart struct {
+	name    string
+	content models.ReadCloser
+}
+
+func (p *linkUploadPart) Filename() string           { return p.name }
+func (p *linkUploadPart) Content() models.ReadCloser { return p.content }
+
+// sanitizeFilename reduces an uploaded name to a plain, visible file name:
+// directories, control characters and leading dots are dropped, characters
+// Windows refuses are replaced, and overlong names are shortened. It returns
+// "" when nothing usable is left.
+func sanitizeFilename(name string) string {
+	name = path.Base("/" + strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, ". /")
+
+	if len(name) > maxFilenameBytes {
+		ext := path.Ext(name)
+		if len(ext) > maxFilenameBytes/4 {
+			ext = ""
+		}
+		cut := maxFilenameBytes - len(ext)
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut] + ext
+	}
+	return name
+}
+
+// normalizeExtensions lower-cases extensions and gives each a leading dot.
+func normalizeExtensions(extensions []string) ([]string, error) {
+	var normalized []string
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if ext == "." || strings.ContainsAny(ext[1:], "./\\") {
+			return nil, errors.NewValidationError("allowedExtensions", ext, "must be a single extension such as .log")
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized, nil
+}
+
+// cleanUploader trims what an uploader says about themselves and checks
+// that the email, if any, is an address.
+func cleanUploader(uploader models.Uploader) (models.Uploader, error) {
+	clean := func(value string) string {
+		value = strings.TrimSpace(strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, value))
+		if len(value) > maxUploaderBytes {
+			value = strings.ToValidUTF8(value[:maxUploaderBytes], "")
+		}
+		return value
+	}
+	uploader.Name, uploader.Email = clean(uploader.Name), clean(uploader.Email)
+	if uploader.Email != "" {
+		address, err := mail.ParseAddress(uploader.Email)
+		if err != nil {
+			return uploader, errors.NewValidationError("email", uploader.Email, "not an email address")
+		}
+		uploader.Email = address.Address
+	}
+	return uploader, nil
+}
+
+// newLinkToken returns a random, unguessable link token.
+func newLinkToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate link token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// newLinkTestService shares a root holding docs/a.txt, secret.txt and an
+// empty inbox directory.
+func newLinkTestService(t *testing.T, authorizer ports.Authorizer) (*ShareLinkService, string) {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"docs", "inbox"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for name, content := range map[string]string{"docs/a.txt": "alpha", "secret.txt": "secret"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	store, err := fs.NewLocalLinkStore(filepath.Join(t.TempDir(), "links.json"))
+	if err != nil {
+		t.Fatalf("Failed to create link store: %v", err)
+	}
+	repo := fs.NewLocalFileRepository(root)
+	service := NewShareLinkService(store, repo, authorizer, auth.PasswordHasher{Algorithm: auth.HashBcrypt},
+		NewListFilesService(repo, authorizer),
+		NewDownloadFileService(repo, authorizer),
+		NewDownloadZipService(repo, authorizer),
+		NewUploadService(repo, authorizer, models.ConflictRename))
+	return service, root
+}
+
+func readFile(t *testing.T, file *models.FileContent) string {
+	t.Helper()
+	defer file.Content.Close()
+	data, err := io.ReadAll(file.Content)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	return string(data)
+}
+
+func TestShareLinkDownloads(t *testing.T) {
+	service, _ := newLinkTestService(t, acl.AllowAllAuthorizer{})
+
+	link, err := service.Create("alice", CreateLinkRequest{Path: "docs", Permission: models.LinkDownload, Password: "pw", MaxDownloads: 1})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	if !link.IsDir || link.Path != "/docs" || link.ExpiresAt.Sub(link.CreatedAt) != DefaultLinkExpiry {
+		t.Errorf("Unexpected link %+v", link)
+	}
+
+	if _, err := service.Open(link.Token, "wrong"); err == nil {
+		t.Fatal("Expected a wrong password to be refused")
+	} else if _, ok := err.(*errors.UnauthorizedError); !ok {
+		t.Errorf("Expected UnauthorizedError, got %T %v", err, err)
+	}
+	opened, err := service.Open(link.Token, "pw")
+	if err != nil {
+		t.Fatalf("Failed to open link: %v", err)
+	}
+
+	page, err := service.Browse(opened, "/")
+	if err != nil {
+		t.Fatalf("Failed to browse link: %v", err)
+	}
+	if len(page.Files) != 1 || page.Files[0].URL != "/a.txt" {
+		t.Errorf("Expected a.txt relative to the link, got %+v", page.Files)
+	}
+	// ".." stays inside the linked directory
+	if _, err := service.Download(opened, "../secret.txt"); err == nil {
+		t.Error("Expected ../secret.txt to stay out of reach")
+	}
+
+	file, err := service.Download(opened, "a.txt")
+	if err != nil {
+		t.Fatalf("Failed to download through link: %v", err)
+	}
+	if got := readFile(t, file); got != "alpha" {
+		t.Errorf("Expected alpha, got %q", got)
+	}
+	if err := service.CountDownload(opened); err != nil {
+		t.Fatalf("Failed to count the download: %v", err)
+	}
+	if err := service.CountDownload(opened); err == nil {
+		t.Error("Expected the last download to be taken only once")
+	}
+	if _, err := service.Download(opened, "a.txt"); err == nil {
+		t.Error("Expected the download limit to be enforced")
+	} else if _, ok := err.(*errors.GoneError); !ok {
+		t.Errorf("Expected GoneError, got %T %v", err, err)
+	}
+	if _, err := service.Open(link.Token, "pw"); err == nil {
+		t.Error("Expected an exhausted link to be gone")
+	}
+}
+
+func TestShareLinkExpiryAndRevocation(t *testing.T) {
+	service, _ := newLinkTestService(t, acl.AllowAllAuthorizer{})
+
+	link, err := service.Create("alice", CreateLinkRequest{Path: "secret.txt", Permission: models.LinkView, ExpiresIn: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	opened, err := service.Open(link.Token, "")
+	if err != nil {
+		t.Fatalf("Failed to open link: %v", err)
+	}
+	// A file link reaches nothing but the file itself
+	if _, err := service.Download(opened, "other.txt"); err == nil {
+		t.Error("Expected a sub-path of a file link to be refused")
+	}
+
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := service.Open(link.Token, ""); err == nil {
+		t.Error("Expected an expired link to be gone")
+	} else if _, ok := err.(*errors.GoneError); !ok {
+		t.Errorf("Expected GoneError, got %T %v", err, err)
+	}
+	service.now = time.Now
+
+	if err := service.Revoke("bob", link.Token); err == nil {
+		t.Error("Expected another user's revoke to fail")
+	}
+	if links, _ := service.List("bob"); len(links) != 0 {
+		t.Errorf("Expected bob to see no links, got %d", len(links))
+	}
+	if err := service.Revoke("alice", link.Token); err != nil {
+		t.Fatalf("Failed to revoke link: %v", err)
+	}
+	if _, err := service.Open(link.Token, ""); err == nil {
+		t.Error("Expected a revoked link to be gone")
+	}
+}
+
+func TestShareLinkUploads(t *testing.T) {
+	service, root := newLinkTestService(t, acl.AllowAllAuthorizer{})
+
+	if _, err := service.Create("alice", CreateLinkRequest{Path: "secret.txt", Permission: models.LinkUpload}); err == nil {
+		t.Error("Expected an upload link to a file to be refused")
+	}
+	if _, err := service.Create("alice", CreateLinkRequest{Path: "docs", Permission: models.LinkView, MaxFileSize: 10}); err == nil {
+		t.Error("Expected upload limits on a view link to be refused")
+	}
+	link, err := service.Create("alice", CreateLinkRequest{Path: "inbox", Permission: models.LinkUpload, MaxFileSize: 5, AllowedExtensions: []string{"LOG", ".txt"}})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	if strings.Join(link.AllowedExtensions, " ") != ".log .txt" {
+		t.Errorf("Expected normalized extensions, got %v", link.AllowedExtensions)
+	}
+
+	vendor := models.Uploader{Name: " Vendor ", Email: "Ops <ops@example.com>"}
+	uploads, err := service.Upload(link, vendor, []models.UploadPart{
+		testPart{"../../.escape.txt", "x"},
+		testPart{`app:1.LOG`, "log"},
+		testPart{"tool.exe", "MZ"},
+		testPart{"big.log", "123456"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to upload through link: %v", err)
+	}
+	var names []string
+	for _, upload := range uploads {
+		names = append(names, upload.Filename)
+	}
+	if strings.Join(names, " ") != "escape.txt app_1.LOG" {
+		t.Errorf("Expected sanitized names relative to the link, got %v", names)
+	}
+	for _, name := range []string{"escape.txt", "app_1.LOG"} {
+		if _, err := os.Stat(filepath.Join(root, "inbox", name)); err != nil {
+			t.Errorf("Expected %s inside the linked directory: %v", name, err)
+		}
+	}
+	for _, name := range []string{"tool.exe", "big.log"} {
+		if _, err := os.Stat(filepath.Join(root, "inbox", name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be refused, got %v", name, err)
+		}
+	}
+
+	// A second file with a taken name is renamed, not overwritten
+	if uploads, err := service.Upload(link, models.Uploader{}, []models.UploadPart{testPart{"escape.txt", "y"}}); err != nil || uploads[0].Filename != "escape (1).txt" {
+		t.Errorf("Expected the upload to be renamed, got %+v %v", uploads, err)
+	}
+	if _, err := service.Upload(link, models.Uploader{}, []models.UploadPart{testPart{"tool.exe", "MZ"}}); err == nil {
+		t.Error("Expected a refused extension to fail the upload")
+	}
+	if _, err := service.Upload(link, models.Uploader{Email: "not an address"}, []models.UploadPart{testPart{"a.txt", "x"}}); err == nil {
+		t.Error("Expected an invalid email to be refused")
+	}
+
+	owned, err := service.Get("alice", link.Token)
+	if err != nil {
+		t.Fatalf("Failed to get link: %v", err)
+	}
+	if len(owned.Uploads) != 3 {
+		t.Fatalf("Expected 3 recorded uploads, got %+v", owned.Uploads)
+	}
+	if got := owned.Uploads[0].Uploader; got.Name != "Vendor" || got.Email != "ops@example.com" {
+		t.Errorf("Expected the cleaned uploader, got %+v", got)
+	}
+	if _, err := service.Get("bob", link.Token); err == nil {
+		t.Error("Expected another user's link to be hidden")
+	}
+
+	// Upload links reveal nothing
+	if _, err := service.Browse(link, "/"); err == nil {
+		t.Error("Expected browsing an upload link to be forbidden")
+	}
+	if _, err := service.Download(link, "escape.txt"); err == nil {
+		t.Error("Expected downloading from an upload link to be forbidden")
+	}
+}
+
+func TestShareLinkRespectsOwnerPermissions(t *testing.T) {
+	service, _ := newLinkTestService(t, denyPrefixAuthorizer{denied: "/docs"})
+
+	_, err := service.Create("alice", CreateLinkRequest{Path: "docs", Permission: models.LinkView})
+	if _, ok := err.(*errors.ForbiddenError); !ok {
+		t.Errorf("Expected ForbiddenError, got %T %v", err, err)
+	}
+	if _, err := service.Create("alice", CreateLinkRequest{Path: "missing", Permission: models.LinkView}); err == nil {
+		t.Error("Expected a link to a missing path to be refused")
+	}
+	if _, err := service.Create("alice", CreateLinkRequest{Path: "secret.txt", Permission: "edit"}); err == nil {
+		t.Error("Expected an unknown permission to be refused")
+	}
+}
+
+func TestShareLinkUploadsRenameLongNames(t *testing.T) {
+	service, _ := newLinkTestService(t, acl.AllowAllAuthorizer{})
+	link, err := service.Create("alice", CreateLinkRequest{Path: "inbox", Permission: models.LinkUpload})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+
+	// Two-byte runes, so a cut at an odd byte would split one
+	long := strings.Repeat("é", 200) + ".txt"
+	for i := range 2 {
+		uploads, err := service.Upload(link, models.Uploader{}, []models.UploadPart{testPart{long, "x"}})
+		if err != nil || len(uploads) != 1 {
+			t.Fatalf("Upload %d: expected the long name to be stored, got %+v %v", i, uploads, err)
+		}
+		name := uploads[0].Filename
+		if len(name) > 255 || !utf8.ValidString(name) || !strings.HasSuffix(name, ".txt") {
+			t.Errorf("Upload %d: expected a valid name of at most 255 bytes, got %q (%d bytes)", i, name, len(name))
+		}
+		if i == 1 && !strings.HasSuffix(name, " (1).txt") {
+			t.Errorf("Expected the second upload to be renamed, got %q", name)
+		}
+	}
+}
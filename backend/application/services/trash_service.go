@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// TrashService lists, restores and purges deleted entries. Users see the
+// entries whose original path they could read, restore those they may
+// write back, and purge those they could have deleted. Restoring over an
+// existing entry also needs the right to delete it.
+type TrashService struct {
+	trash      ports.TrashRepository
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	modes      ports.ModeResolver
+	quotas     *QuotaService
+	now        func() time.Time
+}
+
+func NewTrashService(trash ports.TrashRepository, fileRepo ports.FileRepository, authorizer ports.Authorizer) *TrashService {
+	return &TrashService{trash: trash, fileRepo: fileRepo, authorizer: authorizer, now: time.Now}
+}
+
+// WithModes lets the mode of the share restored into decide whether a
+// restore may replace what it finds there.
+func (s *TrashService) WithModes(modes ports.ModeResolver) *TrashService {
+	s.modes = modes
+	return s
+}
+
+// WithQuotas checks restored entries against the quotas and charges them to the restoring user.
+func (s *TrashService) WithQuotas(quotas *QuotaService) *TrashService {
+	s.quotas = quotas
+	return s
+}
+
+// List returns the trashed entries user may see, oldest first.
+func (s *TrashService) List(user string) ([]*models.TrashItem, error) {
+	items, err := s.trash.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	visible := items[:0]
+	for _, item := range items {
+		if s.visible(user, item) {
+			visible = append(visible, item)
+		}
+	}
+	return visible, nil
+}
+
+// Restore moves entry id back to dst, or where it came from when dst is "".
+// It returns the path restored to.
+func (s *TrashService) Restore(user, id, dst string, policy models.ConflictPolicy) (string, error) {
+	item, err := s.item(user, id)
+	if err != nil {
+		return "", err
+	}
+	if dst == "" {
+		dst = item.OriginalPath
+	}
+	policy = conflictPolicyFor(s.modes, policy, dst)
+	if policy == models.ConflictOverwrite {
+		// The restore trashes whatever is at dst, which is a delete
+		err = authorizeDestination(s.fileRepo, s.authorizer, user, dst, true)
+	} else {
+		err = authorize(s.authorizer, user, models.ActionWrite, dst)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := s.checkSpace(user, item, dst, policy); err != nil {
+		return "", err
+	}
+	restored, err := s.trash.RestoreFromTrash(item.ID, dst, user, policy)
+	if err != nil {
+		return "", err
+	}
+	if s.quotas != nil {
+		s.quotas.RecordTree(s.fileRepo, user, restored)
+	}
+	return restored, nil
+}
+
+// checkSpace fails if item does not fit at dst, after what it would
+// replace there is gone.
+func (s *TrashService) checkSpace(user string, item *models.TrashItem, dst string, policy models.ConflictPolicy) error {
+	if s.quotas == nil {
+		return nil
+	}
+	var replaced int64
+	if policy == models.ConflictOverwrite {
+		if size, err := treeSize(s.fileRepo, dst, nil); err == nil {
+			replaced = size
+		}
+	}
+	return s.quotas.CheckRestore(user, dst, item, replaced)
+}
+
+// Purge deletes entry id for good.
+func (s *TrashService) Purge(user, id string) error {
+	item, err := s.item(user, id)
+	if err != nil {
+		return err
+	}
+	if err := authorize(s.authorizer, user, models.ActionDelete, item.OriginalPath); err != nil {
+		return err
+	}
+	return s.trash.PurgeTrash(item.ID)
+}
+
+// Empty purges every entry user may see and delete, returning how many.
+func (s *TrashService) Empty(user string) (int, error) {
+	items, err := s.List(user)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, item := range items {
+		if !s.authorizer.Allowed(user, models.ActionDelete, item.OriginalPath) {
+			continue
+		}
+		if err := s.trash.PurgeTrash(item.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeExpired empties entries trashed longer ago than retention.
+func (s *TrashService) PurgeExpired(retention time.Duration) (int, error) {
+	return s.trash.PurgeTrashBefore(s.now().Add(-retention))
+}
+
+// RunRetention calls PurgeExpired every interval until ctx is done.
+func (s *TrashService) RunRetention(ctx context.Context, interval, retention time.Duration, logger ports.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired(retention)
+			if err != nil {
+				logger.Error("Failed to empty trash", "error", err)
+			} else if purged > 0 {
+				logger.Info("Emptied expired trash", "count", purged)
+			}
+		}
+	}
+}
+
+// item finds entry id, hiding entries user may not see.
+func (s *TrashService) item(user, id string) (*models.TrashItem, error) {
+	items, err := s.trash.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == id && s.visible(user, item) {
+			return item, nil
+		}
+	}
+	return nil, &errors.NotFoundError{Path: id}
+}
+
+func (s *TrashService) visible(user string, item *models.TrashItem) bool {
+	action := models.ActionRead
+	if item.IsDir {
+		action = models.ActionList
+	}
+	return s.authorizer.Allowed(user, action, item.OriginalPath)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func TestDeleteMovesToTrash(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"private/a.txt", "b.txt"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	repo := fs.NewLocalFileRepository(root)
+	deleter := NewDeleteService(repo, acl.AllowAllAuthorizer{}).WithTrash(repo)
+	for _, p := range []string{"/private/a.txt", "/b.txt"} {
+		if err := deleter.Execute("alice", p, false); err != nil {
+			t.Fatalf("Failed to delete %s: %v", p, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(p))); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be gone, got %v", p, err)
+		}
+	}
+
+	service := NewTrashService(repo, repo, denyPrefixAuthorizer{denied: "/private"})
+	items, err := service.List("bob")
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != "/b.txt" || items[0].DeletedBy != "alice" {
+		t.Fatalf("Expected only /b.txt to be visible, got %+v", items)
+	}
+	b := items[0]
+
+	all, err := repo.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	for _, item := range all {
+		if item.OriginalPath == "/private/a.txt" {
+			if err := service.Purge("bob", item.ID); err == nil {
+				t.Error("Expected an entry from a denied path to stay hidden")
+			} else if _, ok := err.(*errors.NotFoundError); !ok {
+				t.Errorf("Expected NotFoundError, got %T %v", err, err)
+			}
+		}
+	}
+	if _, err := service.Restore("bob", b.ID, "/private/b.txt", models.ConflictReject); err == nil {
+		t.Error("Expected a restore into a denied path to be refused")
+	}
+
+	restored, err := service.Restore("bob", b.ID, "", models.ConflictReject)
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "b.txt")); restored != "/b.txt" || err != nil || string(data) != "b.txt" {
+		t.Errorf("Expected /b.txt to be back, got %q %q %v", restored, data, err)
+	}
+
+	if purged, err := service.Empty("bob"); err != nil || purged != 0 {
+		t.Errorf("Expected bob to purge nothing, got %d %v", purged, err)
+	}
+	if purged, err := service.PurgeExpired(time.Hour); err != nil || purged != 0 {
+		t.Errorf("Expected nothing to have expired, got %d %v", purged, err)
+	}
+	service.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if purged, err := service.PurgeExpired(time.Hour); err != nil || purged != 1 {
+		t.Errorf("Expected the private entry to expire, got %d %v", purged, err)
+	}
+}
+
+// denyActionAuthorizer allows everything except one action.
+type denyActionAuthorizer struct{ denied models.Action }
+
+func (a denyActionAuthorizer) Allowed(_ string, action models.Action, _ string) bool {
+	return action != a.denied
+}
+
+func TestTrashRestoreOverwriteNeedsDelete(t *testing.T) {
+	root := t.TempDir()
+	repo := fs.NewLocalFileRepository(root)
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	item, err := repo.MoveToTrash("/a.txt", "alice")
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("current"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	writer := NewTrashService(repo, repo, denyActionAuthorizer{denied: models.ActionDelete})
+	_, err = writer.Restore("bob", item.ID, "", models.ConflictOverwrite)
+	if _, ok := err.(*errors.ForbiddenError); !ok {
+		t.Fatalf("Expected ForbiddenError, got %T %v", err, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "current" {
+		t.Errorf("Expected a.txt to be untouched, got %q %v", data, err)
+	}
+	if restored, err := writer.Restore("bob", item.ID, "", models.ConflictRename); err != nil || restored != "/a (1).txt" {
+		t.Errorf("Expected a renamed restore to need only write access, got %q %v", restored, err)
+	}
+}
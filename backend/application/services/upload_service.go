@@ -2,22 +2,76 @@ package services
 
 import (
 	"path/filepath"
+	"sync/atomic"
 
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
 	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
 type UploadService struct {
-	fileRepo ports.FileRepository
+	fileRepo   ports.FileRepository
+	authorizer ports.Authorizer
+	policy     models.ConflictPolicy
+	metrics    ports.Metrics
+	maxSize    atomic.Int64 // Largest file accepted; zero means no limit
+	modes      ports.ModeResolver
+	quotas     *QuotaService
 }
 
-func NewUploadService(fileRepo ports.FileRepository) *UploadService {
-	return &UploadService{fileRepo: fileRepo}
+// NewUploadService creates an UploadService; policy decides what happens
+// when an uploaded name already exists.
+func NewUploadService(fileRepo ports.FileRepository, authorizer ports.Authorizer, policy models.ConflictPolicy) *UploadService {
+	return &UploadService{fileRepo: fileRepo, authorizer: authorizer, policy: policy, metrics: nopMetrics{}}
 }
 
-func (s *UploadService) Execute(parts []models.UploadPart) ([]models.FileUpload, error) {
+// WithMetrics counts uploaded bytes and failed uploads
+func (s *UploadService) WithMetrics(metrics ports.Metrics) *UploadService {
+	s.metrics = metrics
+	return s
+}
+
+// WithMaxSize rejects files larger than maxSize bytes; zero means no limit.
+// It may be called again while uploads are running, e.g. on config reload.
+func (s *UploadService) WithMaxSize(maxSize int64) *UploadService {
+	s.maxSize.Store(maxSize)
+	return s
+}
+
+// WithModes applies the access mode at each destination to the conflict
+// policy, e.g. so write-once shares never replace a file
+func (s *UploadService) WithModes(modes ports.ModeResolver) *UploadService {
+	s.modes = modes
+	return s
+}
+
+// WithQuotas refuses uploads that do not fit in the storage or user quota
+// and counts stored files against their uploader
+func (s *UploadService) WithQuotas(quotas *QuotaService) *UploadService {
+	s.quotas = quotas
+	return s
+}
+
+// CheckSpace fails if an upload of size bytes into dir cannot fit, so a
+// request can be refused before its body is read; size is -1 if unknown.
+func (s *UploadService) CheckSpace(user, dir string, size int64) error {
+	if s.quotas == nil {
+		return nil
+	}
+	err := s.quotas.Check(user, dir, size, 0)
+	if err != nil {
+		s.metrics.UploadFailed(failureReason(err))
+	}
+	return err
+}
+
+func (s *UploadService) Execute(user string, parts []models.UploadPart) ([]models.FileUpload, error) {
 	var uploads []models.FileUpload
 	var errors []error
+	fail := func(err error) {
+		errors = append(errors, err)
+		s.metrics.UploadFailed(failureReason(err))
+	}
 
 	for _, part := range parts {
 		filename := part.Filename()
@@ -30,20 +84,34 @@ func (s *UploadService) Execute(parts []models.UploadPart) ([]models.FileUpload,
 		content := part.Content()
 		defer content.Close()
 
+		if err := authorize(s.authorizer, user, models.ActionWrite, filename); err != nil {
+			fail(err)
+			continue
+		}
+		if err := s.CheckSpace(user, filename, -1); err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
 		dir := filepath.Dir(filename)
 		if err := s.fileRepo.CreateDirectory(dir); err != nil {
-			errors = append(errors, err)
+			fail(err)
 			continue
 		}
 
-		written, err := s.fileRepo.WriteFile(filename, content)
+		var body models.ReadCloser = content
+		if maxSize := s.maxSize.Load(); maxSize > 0 {
+			body = &sizeLimitedReader{ReadCloser: content, path: filename, remaining: maxSize, limit: maxSize}
+		}
+		stored, written, err := s.writeFile(user, filename, body)
 		if err != nil {
-			errors = append(errors, err)
+			fail(err)
 			continue
 		}
 
+		s.metrics.AddBytesUploaded(written)
 		uploads = append(uploads, models.FileUpload{
-			Filename: filename,
+			Filename: reportedPath(s.modes, filename, stored),
 			Size:     written,
 		})
 	}
@@ -55,3 +123,43 @@ func (s *UploadService) Execute(parts []models.UploadPart) ([]models.FileUpload,
 
 	return uploads, nil
 }
+
+// writeFile stores body at filename, counting it against user's quota.
+func (s *UploadService) writeFile(user, filename string, body models.ReadCloser) (string, int64, error) {
+	policy := conflictPolicyFor(s.modes, s.policy, filename)
+	if s.quotas == nil {
+		return s.fileRepo.WriteFile(filename, body, policy)
+	}
+	body, release := s.quotas.Limit(user, filename, body)
+	defer release()
+	stored, written, err := s.fileRepo.WriteFile(filename, body, policy)
+	if err == nil {
+		s.quotas.Record(user, stored, written)
+	}
+	return stored, written, err
+}
+
+// sizeLimitedReader fails with a TooLargeError once more than limit bytes
+// are read, so the repository discards the partial file.
+type sizeLimitedReader struct {
+	models.ReadCloser
+	path      string
+	remaining int64
+	limit     int64
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, &errors.TooLargeError{Path: r.path, Limit: r.limit}
+	}
+	// Read one byte past the limit to tell "exactly limit" from "too large"
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, &errors.TooLargeError{Path: r.path, Limit: r.limit}
+	}
+	return n, err
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// testPart is an in-memory upload part.
+type testPart struct {
+	name, content string
+}
+
+func (p testPart) Filename() string { return p.name }
+func (p testPart) Content() models.ReadCloser {
+	return io.NopCloser(strings.NewReader(p.content))
+}
+
+func TestUploadServiceMaxSize(t *testing.T) {
+	root := t.TempDir()
+	service := NewUploadService(fs.NewLocalFileRepository(root), acl.AllowAllAuthorizer{}, models.ConflictOverwrite).WithMaxSize(5)
+
+	uploads, err := service.Execute("", []models.UploadPart{testPart{"exact.txt", "12345"}, testPart{"big.txt", "123456"}})
+	if err != nil {
+		t.Fatalf("Expected partial success, got %v", err)
+	}
+	if len(uploads) != 1 || uploads[0].Filename != "exact.txt" || uploads[0].Size != 5 {
+		t.Errorf("Expected only exact.txt to be stored, got %+v", uploads)
+	}
+	if _, err := os.Stat(filepath.Join(root, "big.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected the oversized file to be discarded, got %v", err)
+	}
+
+	_, err = service.Execute("", []models.UploadPart{testPart{"big.txt", strings.Repeat("x", 100)}})
+	if _, ok := err.(*errors.TooLargeError); !ok {
+		t.Errorf("Expected TooLargeError, got %T %v", err, err)
+	}
+}
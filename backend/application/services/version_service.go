@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// VersionService lists, downloads and restores the earlier contents of
+// overwritten files. Versions are as readable as the file they belong to;
+// restoring one is a write to it.
+type VersionService struct {
+	versions   ports.VersionRepository
+	authorizer ports.Authorizer
+	modes      ports.ModeResolver
+	quotas     *QuotaService
+}
+
+func NewVersionService(versions ports.VersionRepository, authorizer ports.Authorizer) *VersionService {
+	return &VersionService{versions: versions, authorizer: authorizer}
+}
+
+// WithModes lets the mode of the file's share decide whether a restored
+// version may become the current content.
+func (s *VersionService) WithModes(modes ports.ModeResolver) *VersionService {
+	s.modes = modes
+	return s
+}
+
+// WithQuotas checks a restored version against the quotas and charges it to the restoring user.
+func (s *VersionService) WithQuotas(quotas *QuotaService) *VersionService {
+	s.quotas = quotas
+	return s
+}
+
+// List returns the versions of path, newest first.
+func (s *VersionService) List(user, path string) ([]*models.FileVersion, error) {
+	if err := authorize(s.authorizer, user, models.ActionRead, path); err != nil {
+		return nil, err
+	}
+	return s.versions.ListVersions(path)
+}
+
+func (s *VersionService) Download(user, path, id string) (*models.FileContent, error) {
+	if err := authorize(s.authorizer, user, models.ActionRead, path); err != nil {
+		return nil, err
+	}
+	return s.versions.OpenVersion(path, id)
+}
+
+// Restore makes version id of path current again, or under ConflictRename
+// stores it beside the current file. It returns the path written.
+func (s *VersionService) Restore(user, path, id string, policy models.ConflictPolicy) (string, error) {
+	if err := authorize(s.authorizer, user, models.ActionRead, path); err != nil {
+		return "", err
+	}
+	if err := authorize(s.authorizer, user, models.ActionWrite, path); err != nil {
+		return "", err
+	}
+	if s.quotas == nil {
+		return s.versions.RestoreVersion(path, id, conflictPolicyFor(s.modes, policy, path))
+	}
+
+	version, err := s.version(path, id)
+	if err != nil {
+		return "", err
+	}
+	// The current content is kept as a version, so nothing is freed
+	if err := s.quotas.Check(user, path, version.Size, 0); err != nil {
+		return "", err
+	}
+	restored, err := s.versions.RestoreVersion(path, id, conflictPolicyFor(s.modes, policy, path))
+	if err != nil {
+		return "", err
+	}
+	s.quotas.Record(user, restored, version.Size)
+	return restored, nil
+}
+
+// version finds version id of path.
+func (s *VersionService) version(path, id string) (*models.FileVersion, error) {
+	versions, err := s.versions.ListVersions(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if version.ID == id {
+			return version, nil
+		}
+	}
+	return nil, &errors.NotFoundError{Path: id}
+}
+
+// RunPruning prunes versions every interval until ctx is done, so versions
+// past their maximum age go even if their file is never written again.
+func (s *VersionService) RunPruning(ctx context.Context, interval time.Duration, logger ports.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := s.versions.PruneVersions()
+			if err != nil {
+				logger.Error("Failed to prune versions", "error", err)
+			} else if pruned > 0 {
+				logger.Info("Pruned expired versions", "count", pruned)
+			}
+		}
+	}
+}
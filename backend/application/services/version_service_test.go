@@ -0,0 +1,101 @@
+package services
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// newVersionTestRepo returns a repository where /a.txt and /private/b.txt
+// each have one version.
+func newVersionTestRepo(t *testing.T) *fs.LocalFileRepository {
+	t.Helper()
+	repo := fs.NewLocalFileRepository(t.TempDir()).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, p := range []string{"/a.txt", "/private/b.txt"} {
+		for _, content := range []string{"one", "two"} {
+			if _, _, err := repo.WriteFile(p, io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+				t.Fatalf("Failed to write %s: %v", p, err)
+			}
+		}
+	}
+	return repo
+}
+
+func TestVersionServiceAuthorization(t *testing.T) {
+	repo := newVersionTestRepo(t)
+	service := NewVersionService(repo, denyPrefixAuthorizer{denied: "/private"})
+
+	versions, err := service.List("alice", "/private/b.txt")
+	if _, ok := err.(*errors.ForbiddenError); !ok {
+		t.Errorf("Expected listing a denied file to be forbidden, got %+v %T %v", versions, err, err)
+	}
+	private, err := repo.ListVersions("/private/b.txt")
+	if err != nil || len(private) != 1 {
+		t.Fatalf("Expected one version, got %+v %v", private, err)
+	}
+	if _, err := service.Download("alice", "/private/b.txt", private[0].ID); err == nil {
+		t.Error("Expected downloading a version of a denied file to be refused")
+	}
+	if _, err := service.Restore("alice", "/private/b.txt", private[0].ID, models.ConflictOverwrite); err == nil {
+		t.Error("Expected restoring a version of a denied file to be refused")
+	}
+
+	versions, err = service.List("alice", "/a.txt")
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("Expected one version of /a.txt, got %+v %v", versions, err)
+	}
+	file, err := service.Download("alice", "/a.txt", versions[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to download version: %v", err)
+	}
+	data, _ := io.ReadAll(file.Content)
+	file.Content.Close()
+	if string(data) != "one" {
+		t.Errorf("Expected the replaced content, got %q", data)
+	}
+}
+
+func TestVersionServiceModes(t *testing.T) {
+	tests := []struct {
+		mode       models.AccessMode
+		listErr    string // failureReason of List's error, or "" for none
+		restoreErr string // Same for an overwriting restore
+		restoredTo string
+	}{
+		{models.AccessReadWrite, "", "", "/a.txt"},
+		{models.AccessReadOnly, "", "forbidden", ""},
+		{models.AccessWriteOnce, "", "conflict", ""},
+		// Versions would show what a drop box holds
+		{models.AccessDropBox, "forbidden", "forbidden", ""},
+	}
+	for _, tt := range tests {
+		repo := newVersionTestRepo(t)
+		modes := fixedMode(tt.mode)
+		service := NewVersionService(repo, NewModeAuthorizer(acl.AllowAllAuthorizer{}, modes)).WithModes(modes)
+		versions, err := repo.ListVersions("/a.txt")
+		if err != nil || len(versions) != 1 {
+			t.Fatalf("Expected one version, got %+v %v", versions, err)
+		}
+
+		if _, err := service.List("alice", "/a.txt"); errorReason(err) != tt.listErr {
+			t.Errorf("%s: expected List to fail with %q, got %v", tt.mode, tt.listErr, err)
+		}
+		restored, err := service.Restore("alice", "/a.txt", versions[0].ID, models.ConflictOverwrite)
+		if errorReason(err) != tt.restoreErr || restored != tt.restoredTo {
+			t.Errorf("%s: expected Restore to give %q %q, got %q %v", tt.mode, tt.restoredTo, tt.restoreErr, restored, err)
+		}
+	}
+}
+
+// errorReason is failureReason, or "" without an error.
+func errorReason(err error) string {
+	if err == nil {
+		return ""
+	}
+	return failureReason(err)
+}
@@ -1,56 +1,170 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"os"
+	"time"
 
 	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 	xhttp "github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/primary/http"
 	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/primary/http/handlers"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
 	config "github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/config"
-	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
 	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/logging"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/metrics"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/shares"
 	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/tls"
 )
 
+// uploadCleanupInterval is how often expired resumable uploads are purged.
+const uploadCleanupInterval = time.Hour
+
+// trashRetentionInterval is how often trashed entries past retention are purged.
+const trashRetentionInterval = time.Hour
+
+// versionPruneInterval is how often versions past their maximum age are pruned.
+const versionPruneInterval = time.Hour
+
 func main() {
-	// === CONFIG ===
-	var cfg ports.ConfigProvider
-	var err error
+	// Credential file management runs instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		os.Exit(runUserCommand(os.Args[2:]))
+	}
 
-	if os.Getenv("APP_ENV") == "production" {
-		cfg, err = config.NewEnvConfigProvider()
-		if err != nil {
-			log.Fatal("Failed to load config: ", err)
-		}
-	} else {
-		// Use development configuration
-		cfg, err = config.NewDevConfigProvider()
-		if err != nil {
-			log.Fatal("Failed to load development config: ", err)
+	// === CONFIG ===
+	cfg, err := config.NewLayeredConfigProvider(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal("Failed to load config: ", err)
+	}
+	if cfg.PrintRequested() {
+		if err := cfg.PrintConfig(os.Stdout); err != nil {
+			log.Fatal("Failed to print config: ", err)
 		}
-		log.Println("⚠️  Running in DEVELOPMENT mode with TLS disabled")
+		return
 	}
 
 	// === LOGGING ===
-	logger := logging.NewStdLogger()
+	logger, err := logging.NewSlogLogger(os.Stderr, cfg.GetLogFormat(), cfg.GetLogLevel())
+	if err != nil {
+		log.Fatal("Invalid logging configuration: ", err)
+	}
+	if !cfg.EnableTLS() {
+		logger.Warn("TLS is disabled; traffic including credentials is sent in plain text")
+	}
 
 	// === SECONDARY ADAPTERS ===
-	fileRepo := fs.NewLocalFileRepository(cfg.GetRootDir())
-	// TODO: Implement authentication provider selection based on configuration
-	// authProvider := auth.NewStaticAuthProvider(cfg.GetUsername(), cfg.GetPassword())
-	tlsGenerator := &tls.InMemoryTLSCertGenerator{}
+	for _, dir := range storageDirs(cfg) {
+		if removed, err := fs.NewLocalFileRepository(dir).CleanupTempFiles(); err != nil {
+			logger.Warn("Failed to clean up interrupted writes", "directory", dir, "error", err)
+		} else if removed > 0 {
+			logger.Info("Removed files left by interrupted writes", "directory", dir, "count", removed)
+		}
+	}
+	initialAuthProvider, err := newAuthProvider(cfg, logger)
+	if err != nil {
+		logger.Fatal("Failed to load credential file", "path", cfg.GetUsersFile(), "error", err)
+	}
+	authProvider := auth.NewSwappableAuthProvider(initialAuthProvider)
+	initialAuthorizer, err := newAuthorizer(cfg)
+	if err != nil {
+		logger.Fatal("Failed to load access control policy", "path", cfg.GetACLFile(), "error", err)
+	}
+	aclAuthorizer := acl.NewSwappableAuthorizer(initialAuthorizer)
+	var fileRepo ports.FileRepository = fs.NewLocalFileRepository(cfg.GetRootDir()).
+		WithSymlinkPolicy(cfg.GetSymlinkPolicy()).
+		WithVersions(cfg.GetVersionPolicy()).
+		WithQuota(cfg.GetRootQuota())
+	var authorizer ports.Authorizer = aclAuthorizer
+	var shareRegistry *shares.Registry
+	if len(cfg.GetShares()) > 0 {
+		shareRegistry, err = shares.NewRegistry(cfg.GetShares(), openShare(cfg.GetSymlinkPolicy(), cfg.GetVersionPolicy()))
+		if err != nil {
+			logger.Fatal("Failed to mount shares", "error", err)
+		}
+		fileRepo = shareRegistry
+		authorizer = shares.NewAuthorizer(shareRegistry, aclAuthorizer)
+		logger.Info("Serving shares", "names", shareNames(shareRegistry.Shares()))
+	}
+	modes := shares.NewModeResolver(shareRegistry, cfg.GetAccessMode())
+	authorizer = services.NewModeAuthorizer(authorizer, modes)
+	uploadStore, err := fs.NewLocalUploadStore(cfg.GetUploadDir())
+	if err != nil {
+		logger.Fatal("Failed to prepare upload staging", "path", cfg.GetUploadDir(), "error", err)
+	}
+	linkStore, err := fs.NewLocalLinkStore(cfg.GetLinksFile())
+	if err != nil {
+		logger.Fatal("Failed to load share links", "path", cfg.GetLinksFile(), "error", err)
+	}
+	usageLedger, err := fs.NewLocalUsageLedger(cfg.GetUsageFile())
+	if err != nil {
+		logger.Fatal("Failed to load usage ledger", "path", cfg.GetUsageFile(), "error", err)
+	}
+	promMetrics := metrics.NewPrometheusMetrics(cfg.GetRootDir())
+	jwtProvider := auth.NewJWTProvider(cfg.GetJWTSecret(), xhttp.DefaultTokenExpiry)
+	tlsGenerator := newCertGenerator(cfg, logger)
 
 	// === APPLICATION SERVICES ===
-	listService := services.NewListFilesService(fileRepo)
-	downloadService := services.NewDownloadFileService(fileRepo)
-	zipService := services.NewDownloadZipService(fileRepo)
-	uploadService := services.NewUploadService(fileRepo)
+	listService := services.NewListFilesService(fileRepo, authorizer)
+	downloadService := services.NewDownloadFileService(fileRepo, authorizer).WithMetrics(promMetrics)
+	zipService := services.NewDownloadZipService(fileRepo, authorizer).WithMetrics(promMetrics)
+	infoService := services.NewFileInfoService(fileRepo, authorizer)
+	// Both the root repository and the share registry keep usage
+	quotaService := services.NewQuotaService(fileRepo.(ports.UsageRepository), usageLedger, authorizer).
+		WithUserLimits(cfg.GetUserQuota(), cfg.GetUserQuotas()).
+		WithLogger(logger)
+	uploadService := services.NewUploadService(fileRepo, authorizer, cfg.GetConflictPolicy()).
+		WithMetrics(promMetrics).
+		WithModes(modes).
+		WithMaxSize(cfg.GetMaxUploadBytes()).
+		WithQuotas(quotaService)
+	resumableService := services.NewResumableUploadService(uploadStore, fileRepo, authorizer, cfg.GetConflictPolicy(), services.DefaultUploadExpiry).
+		WithMetrics(promMetrics).
+		WithModes(modes).
+		WithMaxSize(cfg.GetMaxUploadBytes()).
+		WithQuotas(quotaService)
+	// Both the root repository and the share registry keep a trash
+	trashRepo := fileRepo.(ports.TrashRepository)
+	deleteService := services.NewDeleteService(fileRepo, authorizer).WithQuotas(quotaService)
+	if cfg.TrashEnabled() {
+		deleteService.WithTrash(trashRepo)
+		quotaService.WithTrash(trashRepo)
+	}
+	trashService := services.NewTrashService(trashRepo, fileRepo, authorizer).WithModes(modes).WithQuotas(quotaService)
+	versionService := services.NewVersionService(fileRepo.(ports.VersionRepository), authorizer).WithModes(modes).WithQuotas(quotaService)
+	moveService := services.NewMoveService(fileRepo, authorizer).WithQuotas(quotaService)
+	copyService := services.NewCopyService(fileRepo, authorizer).WithQuotas(quotaService)
+	mkdirService := services.NewCreateDirectoryService(fileRepo, authorizer).WithModes(modes)
+	authService := services.NewAuthService(authProvider, jwtProvider)
+	// Files sent through links are renamed rather than replacing what is
+	// there, and count against the link's owner
+	linkUploadService := services.NewUploadService(fileRepo, authorizer, models.ConflictRename).
+		WithMetrics(promMetrics).
+		WithModes(modes).
+		WithMaxSize(cfg.GetMaxUploadBytes()).
+		WithQuotas(quotaService)
+	linkService := services.NewShareLinkService(linkStore, fileRepo, authorizer, auth.PasswordHasher{Algorithm: auth.HashBcrypt},
+		listService, downloadService, zipService, linkUploadService)
 
 	// === PRIMARY ADAPTERS (HTTP HANDLERS) ===
-	rootHandler := handlers.NewRootHandler(listService, downloadService, zipService, cfg.GetPort())
+	rootHandler := handlers.NewRootHandler(listService, downloadService, zipService, infoService, cfg.GetPort())
 	uploadHandler := handlers.NewUploadHandler(uploadService)
+	tusHandler := handlers.NewTusHandler(resumableService, "/api/uploads")
+	fileOpsHandler := handlers.NewFileOpsHandler(deleteService, moveService, copyService, mkdirService)
+	authHandler := handlers.NewAuthHandler(authService)
+	linkHandler := handlers.NewLinkHandler(linkService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	versionHandler := handlers.NewVersionHandler(versionService)
+	usageHandler := handlers.NewUsageHandler(quotaService)
 
 	// === HTTP SERVER ===
 	server := xhttp.NewServer(
@@ -60,20 +174,146 @@ func main() {
 		rootHandler,
 		uploadHandler,
 	)
+	if err := server.ConfigureAuth(cfg.GetAuthMode(), authProvider, jwtProvider); err != nil {
+		logger.Fatal("Invalid auth configuration", "error", err)
+	}
+	server.SetListenAddr(cfg.GetListenAddr())
+	server.SetTimeouts(cfg.GetReadTimeout(), cfg.GetWriteTimeout(), cfg.GetShutdownTimeout())
+	server.SetMaxHeaderBytes(cfg.GetMaxHeaderBytes())
+	server.SetPublicAccess(cfg.PublicHealth(), cfg.PublicStatic())
+	server.ConfigureTLS(cfg.EnableTLS())
+	server.SetHTTPRedirect(cfg.GetHTTPRedirectAddr())
+	server.SetHSTS(cfg.GetHSTSMaxAge())
+	if len(cfg.GetACMEDomains()) > 0 {
+		acmeSource, err := tls.NewACMECertSource(tls.ACMEOptions{
+			Domains:      cfg.GetACMEDomains(),
+			Email:        cfg.GetACMEEmail(),
+			DirectoryURL: cfg.GetACMEDirectoryURL(),
+			CacheDir:     cfg.GetACMECacheDir(),
+			CAFile:       cfg.GetACMECAFile(),
+		})
+		if err != nil {
+			logger.Fatal("Invalid ACME configuration", "error", err)
+		}
+		server.SetCertSource(acmeSource)
+		logger.Info("ACME certificates enabled", "domains", cfg.GetACMEDomains())
+	}
+	healthService := services.NewHealthService(buildInfo(), append(storageChecks(cfg), server.TLSCheck())...)
+	healthHandler := handlers.NewHealthHandler(healthService)
+	server.Handle("GET /health", healthHandler)
+	server.Handle("GET /ready", healthHandler)
+	server.Handle("GET /version", healthHandler)
+	server.SetMetrics(promMetrics)
+	if cfg.GetMetricsAddr() != "" {
+		server.ServeMetrics(cfg.GetMetricsAddr(), promMetrics)
+	} else {
+		server.Handle("GET /metrics", promMetrics)
+	}
+	server.Handle("DELETE /api/files", fileOpsHandler)
+	server.Handle("POST /api/files/move", fileOpsHandler)
+	server.Handle("POST /api/files/copy", fileOpsHandler)
+	server.Handle("POST /api/directories", fileOpsHandler)
+	server.Handle("/api/uploads", tusHandler)
+	server.Handle("/api/uploads/", tusHandler)
+	server.Handle("/api/trash", trashHandler)
+	server.Handle("/api/trash/", trashHandler)
+	server.Handle("/api/versions", versionHandler)
+	server.Handle("/api/versions/", versionHandler)
+	server.Handle("GET /api/usage", usageHandler)
+	server.Handle("/api/links", linkHandler)
+	server.Handle("/api/links/", linkHandler)
+	// Share links carry their own token and optional password
+	server.HandlePublic("/s/", handlers.NewPublicLinkHandler(linkService))
+	if cfg.GetAuthMode().UsesJWT() {
+		// Token endpoints must be reachable before the client holds a token
+		server.HandlePublic("/api/auth/", authHandler)
+	}
+	logger.Info("Authentication configured", "mode", cfg.GetAuthMode())
 
-	// In development, we'll serve the frontend files directly
-	if os.Getenv("APP_ENV") != "production" {
-		// For development, serve the built frontend files if present.
-		// The binary is executed from the project root (via `make run`),
-		// so the correct relative path is "frontend/dist".
-		frontendDir := "frontend/dist"
-		if _, err := os.Stat(frontendDir); !os.IsNotExist(err) {
-			server.SetStaticFileServer(frontendDir)
+	// Serve the built frontend files if present
+	if staticDir := cfg.GetStaticDir(); staticDir != "" {
+		if _, err := os.Stat(staticDir); !os.IsNotExist(err) {
+			server.SetStaticFileServer(staticDir)
 		}
 	}
 
+	// SIGHUP re-reads the configuration
+	reloads := &reloader{
+		current:      cfg,
+		logger:       logger,
+		server:       server,
+		authProvider: authProvider,
+		authorizer:   aclAuthorizer,
+		shares:       shareRegistry,
+		uploads:      uploadService,
+		linkUploads:  linkUploadService,
+		resumable:    resumableService,
+		quotas:       quotaService,
+	}
+	server.OnReload(reloads.reload)
+
+	// Drop resumable uploads that clients abandoned
+	go resumableService.RunCleanup(context.Background(), uploadCleanupInterval, logger)
+	if cfg.GetTrashRetention() > 0 {
+		go trashService.RunRetention(context.Background(), trashRetentionInterval, cfg.GetTrashRetention(), logger)
+	}
+	go versionService.RunPruning(context.Background(), versionPruneInterval, logger)
+
 	// === START ===
 	if err := server.Start(); err != nil {
 		logger.Fatal("Server failed", "error", err)
 	}
 }
+
+// openShare serves shares from their directories on the local filesystem,
+// each limited to its quota. Shares without a version policy of their own
+// use versions.
+func openShare(symlinks models.SymlinkPolicy, versions models.VersionPolicy) func(share models.Share) ports.FileRepository {
+	return func(share models.Share) ports.FileRepository {
+		policy := versions
+		if share.Versions != nil {
+			policy = *share.Versions
+		}
+		return fs.NewLocalFileRepository(share.Path).WithSymlinkPolicy(symlinks).
+			WithVersions(policy).
+			WithQuota(share.Quota)
+	}
+}
+
+// storageDirs returns the directories holding shared files: one per share,
+// or the root directory when no shares are configured.
+func storageDirs(cfg ports.ConfigProvider) []string {
+	if len(cfg.GetShares()) == 0 {
+		return []string{cfg.GetRootDir()}
+	}
+	var dirs []string
+	for _, share := range cfg.GetShares() {
+		dirs = append(dirs, share.Path)
+	}
+	return dirs
+}
+
+// storageChecks returns a readiness check for every directory in storageDirs.
+func storageChecks(cfg ports.ConfigProvider) []ports.ReadinessCheck {
+	if len(cfg.GetShares()) == 0 {
+		return []ports.ReadinessCheck{fs.NewStorageCheck(cfg.GetRootDir())}
+	}
+	var checks []ports.ReadinessCheck
+	for _, share := range cfg.GetShares() {
+		mode := share.Mode
+		if mode == "" {
+			mode = cfg.GetAccessMode()
+		}
+		checks = append(checks, fs.NewShareStorageCheck(share, mode))
+	}
+	return checks
+}
+
+// shareNames lists the names of shares for logging.
+func shareNames(list []models.Share) []string {
+	names := make([]string, 0, len(list))
+	for _, share := range list {
+		names = append(names, share.Name)
+	}
+	return names
+}
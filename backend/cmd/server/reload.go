@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+	xhttp "github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/primary/http"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+	config "github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/config"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/logging"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/shares"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/tls"
+)
+
+// reloadable lists the config keys applied by a reload; changing any other
+// key only takes effect after a restart.
+var reloadable = map[string]bool{
+	"auth.username":           true,
+	"auth.password":           true,
+	"auth.users_file":         true,
+	"auth.acl_file":           true,
+	"tls.cert_file":           true,
+	"tls.key_file":            true,
+	"tls.cert_dir":            true,
+	"tls.hosts":               true,
+	"limits.max_upload_bytes": true,
+	"quotas.user":             true,
+	"log.level":               true,
+	"shares":                  true,
+}
+
+// reloader re-reads the configuration on SIGHUP and swaps in the parts that
+// can change while requests are running.
+type reloader struct {
+	current *config.LayeredConfigProvider // Configuration of the last successful reload or the start
+	logger  *logging.SlogLogger
+	server  *xhttp.Server
+
+	authProvider *auth.SwappableAuthProvider
+	authorizer   *acl.SwappableAuthorizer
+	shares       *shares.Registry // Nil when serving the root directory
+	uploads      *services.UploadService
+	linkUploads  *services.UploadService
+	resumable    *services.ResumableUploadService
+	quotas       *services.QuotaService
+}
+
+// reload applies the current config file and environment. Everything that
+// can fail is prepared before anything is swapped, so a failed reload leaves
+// the previous configuration running.
+func (r *reloader) reload() error {
+	next, err := config.NewLayeredConfigProvider(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	authProvider, err := newAuthProvider(next, r.logger)
+	if err != nil {
+		return err
+	}
+	authorizer, err := newAuthorizer(next)
+	if err != nil {
+		return err
+	}
+	mountShares := func() {}
+	if r.shares != nil && len(next.GetShares()) > 0 {
+		if mountShares, err = r.shares.Prepare(next.GetShares()); err != nil {
+			return err
+		}
+	}
+	// Report only what changed since the last reload, not since the start
+	changes, err := r.current.Changes(next)
+	if err != nil {
+		return err
+	}
+	// Replacing the certificate is the last step that can fail
+	if next.EnableTLS() && len(next.GetACMEDomains()) == 0 {
+		if err := r.server.ReplaceCertGenerator(newCertGenerator(next, r.logger)); err != nil {
+			return err
+		}
+	}
+
+	// The level was validated along with the rest of the configuration
+	_ = r.logger.SetLevel(next.GetLogLevel())
+	r.authProvider.Swap(authProvider)
+	r.authorizer.Swap(authorizer)
+	mountShares()
+	r.uploads.WithMaxSize(next.GetMaxUploadBytes())
+	r.linkUploads.WithMaxSize(next.GetMaxUploadBytes())
+	r.resumable.WithMaxSize(next.GetMaxUploadBytes())
+	r.quotas.WithUserLimits(next.GetUserQuota(), next.GetUserQuotas())
+
+	for _, key := range changes {
+		// Per-user limits are keyed "quotas.users.<name>"
+		if strings.HasPrefix(key, "quotas.users.") {
+			continue
+		}
+		// Switching between the root directory and shares needs a restart
+		if !reloadable[key] || key == "shares" && (r.shares == nil || len(next.GetShares()) == 0) {
+			r.logger.Warn("Setting changed but only takes effect after a restart", "key", key)
+		}
+	}
+	r.current = next
+	return nil
+}
+
+// newAuthProvider checks Basic credentials against the users file if one is
+// configured, or the single configured username and password.
+func newAuthProvider(cfg ports.ConfigProvider, logger ports.Logger) (ports.AuthProvider, error) {
+	if cfg.GetUsersFile() == "" {
+		return auth.NewStaticAuthProvider(cfg.GetUsername(), cfg.GetPassword()), nil
+	}
+	return auth.NewFileAuthProvider(cfg.GetUsersFile(), logger)
+}
+
+// newAuthorizer loads the access control policy, allowing everything when
+// none is configured.
+func newAuthorizer(cfg ports.ConfigProvider) (ports.Authorizer, error) {
+	if cfg.GetACLFile() == "" {
+		return acl.AllowAllAuthorizer{}, nil
+	}
+	return acl.LoadRuleAuthorizer(cfg.GetACLFile())
+}
+
+// newCertGenerator serves the configured certificate files, or certificates
+// issued by a persisted local CA.
+func newCertGenerator(cfg ports.ConfigProvider, logger ports.Logger) ports.TLSCertGenerator {
+	if cfg.GetTLSCertFile() != "" {
+		return tls.NewFileTLSCertLoader(cfg.GetTLSCertFile(), cfg.GetTLSKeyFile())
+	}
+	persistent := tls.NewPersistentTLSCertGenerator(cfg.GetTLSCertDir(), cfg.GetTLSHosts())
+	logger.Debug("Using generated TLS certificates; trust the local CA to avoid browser warnings", "ca", persistent.CAFile())
+	return persistent
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+)
+
+// defaultUsersFile is used when neither -file nor USERS_FILE is given.
+const defaultUsersFile = "users.htpasswd"
+
+const userUsage = `Usage: file-share user <command> [flags] [username]
+
+Commands:
+  add <username>      Add a user (fails if the user exists)
+  passwd <username>   Change a user's password
+  remove <username>   Remove a user
+  list                List users
+
+Flags:
+  -file string   credential file (default $USERS_FILE or users.htpasswd)
+  -hash string   password hash for add/passwd: bcrypt or argon2id (default bcrypt)
+
+The password is read from the terminal, or from the first line of stdin when piped.
+`
+
+// runUserCommand manages the credential file and returns the process exit code.
+func runUserCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, userUsage)
+		return 2
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, userUsage) }
+	defaultFile := os.Getenv("USERS_FILE")
+	if defaultFile == "" {
+		defaultFile = defaultUsersFile
+	}
+	path := flags.String("file", defaultFile, "credential file")
+	hash := flags.String("hash", string(auth.HashBcrypt), "password hash algorithm")
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if err := userCommand(command, *path, auth.HashAlgorithm(*hash), flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// userCommand performs a single user subcommand against the file at path.
+func userCommand(command, path string, algo auth.HashAlgorithm, args []string) error {
+	file, err := auth.LoadHtpasswdFile(path)
+	if err != nil {
+		return err
+	}
+
+	if command == "list" {
+		for _, name := range file.Usernames() {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("%s expects exactly one username", command)
+	}
+	username := args[0]
+
+	switch command {
+	case "add", "passwd":
+		if command == "add" && file.Has(username) {
+			return fmt.Errorf("user %q already exists; use passwd to change the password", username)
+		}
+		if command == "passwd" && !file.Has(username) {
+			return fmt.Errorf("user %q does not exist", username)
+		}
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		if err := file.SetPassword(username, password, algo); err != nil {
+			return err
+		}
+	case "remove":
+		if !file.Remove(username) {
+			return fmt.Errorf("user %q does not exist", username)
+		}
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	if err := file.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s %s\n", path, command, username)
+	return nil
+}
+
+// readPassword prompts twice on a terminal, or reads one line from piped stdin.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Confirm password: ")
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(first) != string(second) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(first), nil
+}
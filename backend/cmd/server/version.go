@@ -0,0 +1,38 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// Build metadata, injected at build time with
+// -ldflags "-X main.Version=... -X main.Commit=... -X main.BuildDate=...".
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = "unknown"
+)
+
+// buildInfo collects the build metadata, falling back to the VCS revision
+// the Go toolchain embeds when Commit was not injected.
+func buildInfo() models.BuildInfo {
+	commit := Commit
+	if commit == "" {
+		commit = "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					commit = setting.Value
+				}
+			}
+		}
+	}
+	return models.BuildInfo{
+		Version:   Version,
+		Commit:    commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
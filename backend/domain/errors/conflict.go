@@ -0,0 +1,12 @@
+package errors
+
+// ConflictError reports that an operation clashes with the current state of a path,
+// e.g. the destination already exists or a directory is not empty.
+type ConflictError struct {
+	Path   string
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return "conflict at " + e.Path + ": " + e.Reason
+}
@@ -0,0 +1,11 @@
+package errors
+
+// ForbiddenError reports that the caller may not perform an action on a path.
+type ForbiddenError struct {
+	Path   string
+	Action string
+}
+
+func (e *ForbiddenError) Error() string {
+	return "forbidden: " + e.Action + " on " + e.Path
+}
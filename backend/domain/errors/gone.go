@@ -0,0 +1,12 @@
+package errors
+
+// GoneError reports something that existed but can no longer be used, such
+// as an expired share link.
+type GoneError struct {
+	Path   string
+	Reason string
+}
+
+func (e *GoneError) Error() string {
+	return e.Path + ": " + e.Reason
+}
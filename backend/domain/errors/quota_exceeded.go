@@ -0,0 +1,15 @@
+package errors
+
+import "fmt"
+
+// QuotaExceededError reports a write that does not fit in what is left of
+// a storage quota, such as a share's or a user's.
+type QuotaExceededError struct {
+	Path  string
+	Quota string // Whose quota, e.g. "/projects" or "user alice"
+	Limit int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s does not fit in the %d byte quota of %s", e.Path, e.Limit, e.Quota)
+}
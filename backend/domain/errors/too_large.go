@@ -0,0 +1,13 @@
+package errors
+
+import "fmt"
+
+// TooLargeError reports content that exceeds a configured size limit.
+type TooLargeError struct {
+	Path  string
+	Limit int64
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("%s exceeds the size limit of %d bytes", e.Path, e.Limit)
+}
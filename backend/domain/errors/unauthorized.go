@@ -0,0 +1,10 @@
+package errors
+
+// UnauthorizedError reports missing, wrong or expired credentials.
+type UnauthorizedError struct {
+	Reason string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return "unauthorized: " + e.Reason
+}
@@ -0,0 +1,61 @@
+package models
+
+import "fmt"
+
+// AccessMode restricts what may be done in a share, or on the whole server,
+// regardless of who is asking.
+type AccessMode string
+
+const (
+	// AccessReadWrite allows every action.
+	AccessReadWrite AccessMode = "read-write"
+	// AccessReadOnly publishes files: nothing can be written, moved or deleted.
+	AccessReadOnly AccessMode = "read-only"
+	// AccessDropBox accepts uploads but hides what is already there.
+	AccessDropBox AccessMode = "drop-box"
+	// AccessWriteOnce accepts new files but never replaces or removes one.
+	AccessWriteOnce AccessMode = "write-once"
+)
+
+// ParseAccessMode converts a configuration value into an AccessMode.
+func ParseAccessMode(value string) (AccessMode, error) {
+	switch mode := AccessMode(value); mode {
+	case AccessReadWrite, AccessReadOnly, AccessDropBox, AccessWriteOnce:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown access mode %q (want read-write, read-only, drop-box or write-once)", value)
+}
+
+// Permits reports whether the mode allows action at all.
+func (m AccessMode) Permits(action Action) bool {
+	switch m {
+	case AccessReadOnly:
+		return action == ActionList || action == ActionRead || action == ActionZip
+	case AccessDropBox:
+		return action == ActionWrite
+	case AccessWriteOnce:
+		return action != ActionDelete
+	}
+	return true
+}
+
+// HidesContents reports whether the mode keeps what is stored from those who
+// write to it, so uploaders must not be told the name a file was stored as.
+func (m AccessMode) HidesContents() bool {
+	return m == AccessDropBox
+}
+
+// ConflictPolicy returns the policy a write should use in this mode instead
+// of policy. Write-once turns overwrites into conflicts; drop-box stores
+// clashing names under a new name, so neither replacing nor refusing a file
+// gives away what the box already holds. As that new name would, the
+// uploader is only ever told the name they sent (see HidesContents).
+func (m AccessMode) ConflictPolicy(policy ConflictPolicy) ConflictPolicy {
+	switch {
+	case m == AccessWriteOnce && policy == ConflictOverwrite:
+		return ConflictReject
+	case m == AccessDropBox:
+		return ConflictRename
+	}
+	return policy
+}
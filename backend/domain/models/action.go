@@ -0,0 +1,15 @@
+package models
+
+// Action is an operation a user may be allowed to perform on a path.
+type Action string
+
+const (
+	ActionList   Action = "list"
+	ActionRead   Action = "read"
+	ActionWrite  Action = "write"
+	ActionDelete Action = "delete"
+	ActionZip    Action = "zip"
+)
+
+// AllActions lists every action, e.g. for "*" grants.
+var AllActions = []Action{ActionList, ActionRead, ActionWrite, ActionDelete, ActionZip}
@@ -0,0 +1,9 @@
+package models
+
+// BuildInfo identifies the running binary.
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	GoVersion string
+}
@@ -0,0 +1,24 @@
+package models
+
+import "fmt"
+
+// ConflictPolicy decides what a write does when the destination file exists.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite atomically replaces the existing file.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename keeps the existing file and stores the new one as "name (1).ext".
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictReject fails the write with a ConflictError.
+	ConflictReject ConflictPolicy = "reject"
+)
+
+// ParseConflictPolicy converts a configuration value into a ConflictPolicy.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(value); policy {
+	case ConflictOverwrite, ConflictRename, ConflictReject:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q (want overwrite, rename or reject)", value)
+}
@@ -1,9 +1,18 @@
 package models
 
+import (
+	"io/fs"
+	"time"
+)
+
 type FileInfo struct {
-	Name   string
-	URL    string
-	ZipURL string
-	Size   string
-	IsDir  bool
+	Name          string
+	URL           string
+	ZipURL        string
+	Size          int64 // Size in bytes; 0 for directories
+	ModTime       time.Time
+	Mode          fs.FileMode
+	MimeType      string
+	SymlinkTarget string // Link destination when the entry is a symlink
+	IsDir         bool
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ReadSeekCloser is a ReadCloser that can also seek, which ranged reads need.
+type ReadSeekCloser interface {
+	ReadCloser
+	Seek(offset int64, whence int) (int64, error)
+}
+
+// FileContent is an open file together with the metadata needed to serve it.
+type FileContent struct {
+	Content ReadSeekCloser
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
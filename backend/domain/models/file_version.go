@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FileVersion is an earlier content of a file, kept when an upload
+// replaced it.
+type FileVersion struct {
+	ID         string
+	Path       string
+	Size       int64
+	Modified   time.Time // When this content was written
+	ReplacedAt time.Time // When a newer content took its place
+}
+
+// VersionPolicy decides how many earlier contents of each file are kept.
+type VersionPolicy struct {
+	Keep   int           // Versions kept per file; zero turns versioning off
+	MaxAge time.Duration // Versions replaced longer ago are pruned; zero keeps them regardless of age
+}
+
+// Enabled reports whether overwritten files keep their earlier contents.
+func (p VersionPolicy) Enabled() bool {
+	return p.Keep > 0
+}
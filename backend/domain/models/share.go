@@ -0,0 +1,13 @@
+package models
+
+// Share is a directory exposed under its own top-level name, so that
+// "/projects/a.txt" is "a.txt" inside the projects share.
+type Share struct {
+	Name    string     // First segment of every path inside the share
+	Path    string     // Directory holding the share's files
+	Mode    AccessMode // What may be done in the share; "" inherits the server's mode
+	Quota   int64      // Bytes the share may hold; zero means no limit
+	ACLFile string     // Policy checked against paths relative to the share, or "" for none
+	// Versions keeps overwritten contents; nil inherits the server's policy
+	Versions *VersionPolicy
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// LinkPermission is what a share link lets its holder do.
+type LinkPermission string
+
+const (
+	// LinkView lists directories and shows files in the browser.
+	LinkView LinkPermission = "view"
+	// LinkDownload additionally offers files as attachments and directories as ZIP archives.
+	LinkDownload LinkPermission = "download"
+	// LinkUpload accepts files into a directory without revealing what it holds.
+	LinkUpload LinkPermission = "upload-into"
+)
+
+// ParseLinkPermission converts a request value into a LinkPermission.
+func ParseLinkPermission(value string) (LinkPermission, error) {
+	switch permission := LinkPermission(value); permission {
+	case LinkView, LinkDownload, LinkUpload:
+		return permission, nil
+	}
+	return "", fmt.Errorf("unknown link permission %q (want view, download or upload-into)", value)
+}
+
+// ShareLink lets whoever holds Token reach Path without an account. Every
+// use is checked against the owner's own permissions, so a link never
+// grants more than its owner has.
+type ShareLink struct {
+	Token        string
+	Owner        string // User who created the link; "" when anonymous
+	Path         string
+	IsDir        bool
+	Permission   LinkPermission
+	PasswordHash string // Empty when no password is required
+	MaxDownloads int    // Zero means unlimited
+	Downloads    int
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+
+	// Upload-into links only: limits on what may be sent, and what was
+	MaxFileSize       int64    // Largest file accepted; zero means the server's limit
+	AllowedExtensions []string // Lower-case with the dot, e.g. ".log"; empty allows any
+	Uploads           []ReceivedFile
+}
+
+// Uploader is who sent files through a link, as they described themselves.
+type Uploader struct {
+	Name  string
+	Email string
+}
+
+// ReceivedFile records one file received through an upload-into link.
+type ReceivedFile struct {
+	Name       string // Stored name, relative to the link
+	Size       int64
+	Uploader   Uploader
+	UploadedAt time.Time
+}
+
+// AllowsExtension reports whether a file called name may be uploaded.
+func (l *ShareLink) AllowsExtension(name string) bool {
+	if len(l.AllowedExtensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, allowed := range l.AllowedExtensions {
+		if ext == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired reports whether the link can no longer be used at now.
+func (l *ShareLink) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Exhausted reports whether the link has served its last download.
+func (l *ShareLink) Exhausted() bool {
+	return l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads
+}
@@ -0,0 +1,26 @@
+package models
+
+import "fmt"
+
+// SymlinkPolicy decides which symbolic links inside shared directories are followed.
+type SymlinkPolicy string
+
+const (
+	// SymlinkDeny refuses any path that passes through a symlink. Links are
+	// still listed and can be deleted or renamed.
+	SymlinkDeny SymlinkPolicy = "deny"
+	// SymlinkFollowWithinRoot follows relative links that stay inside the
+	// shared directory and refuses the rest.
+	SymlinkFollowWithinRoot SymlinkPolicy = "follow-within-root"
+	// SymlinkFollowAll follows every link, wherever it points.
+	SymlinkFollowAll SymlinkPolicy = "follow-all"
+)
+
+// ParseSymlinkPolicy converts a configuration value into a SymlinkPolicy.
+func ParseSymlinkPolicy(value string) (SymlinkPolicy, error) {
+	switch policy := SymlinkPolicy(value); policy {
+	case SymlinkDeny, SymlinkFollowWithinRoot, SymlinkFollowAll:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown symlink policy %q (want deny, follow-within-root or follow-all)", value)
+}
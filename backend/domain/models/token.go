@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TokenPair is the result of a successful login or refresh.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TrashItem is a deleted file or directory kept in the trash until it is
+// restored or purged.
+type TrashItem struct {
+	ID           string
+	OriginalPath string
+	DeletedBy    string // "" when the deleter was anonymous
+	DeletedAt    time.Time
+	IsDir        bool
+	Size         int64 // Total bytes of the files it holds
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// UploadSession tracks a resumable upload whose bytes are staged until
+// Offset reaches Length and the file is committed to Path.
+type UploadSession struct {
+	ID        string
+	Owner     string // Username that created the session; "" when anonymous
+	Path      string // Destination in the file repository
+	Length    int64
+	Offset    int64
+	Metadata  map[string]string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Complete reports whether every byte of the upload has been received.
+func (s *UploadSession) Complete() bool {
+	return s.Offset >= s.Length
+}
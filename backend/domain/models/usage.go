@@ -0,0 +1,14 @@
+package models
+
+// Usage is how many bytes an area of storage, or a user's uploads, take
+// against their quota.
+type Usage struct {
+	Name  string // The area's path, e.g. "/" or "/projects", or the user
+	Used  int64
+	Limit int64 // Zero means no limit
+}
+
+// Fits reports whether size more bytes stay within the limit.
+func (u Usage) Fits(size int64) bool {
+	return u.Limit == 0 || u.Used+size <= u.Limit
+}
@@ -1,5 +1,37 @@
 package ports
 
+import "fmt"
+
 type AuthProvider interface {
 	Authenticate(username, password string) bool
+	// UserExists reports whether username is still a known user
+	UserExists(username string) bool
+}
+
+// AuthMode selects which authentication scheme protects the API routes.
+type AuthMode string
+
+const (
+	// AuthModeNone leaves every route open.
+	AuthModeNone AuthMode = "none"
+	// AuthModeBasic requires HTTP Basic credentials checked by an AuthProvider.
+	AuthModeBasic AuthMode = "basic"
+	// AuthModeJWT requires a Bearer token checked by a JWTProvider.
+	AuthModeJWT AuthMode = "jwt"
+	// AuthModeBoth accepts either a Bearer token or Basic credentials.
+	AuthModeBoth AuthMode = "both"
+)
+
+// ParseAuthMode converts a configuration value into an AuthMode.
+func ParseAuthMode(value string) (AuthMode, error) {
+	switch mode := AuthMode(value); mode {
+	case AuthModeNone, AuthModeBasic, AuthModeJWT, AuthModeBoth:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown auth mode %q (want none, basic, jwt or both)", value)
+}
+
+// UsesJWT reports whether the mode accepts Bearer tokens.
+func (m AuthMode) UsesJWT() bool {
+	return m == AuthModeJWT || m == AuthModeBoth
 }
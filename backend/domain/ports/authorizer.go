@@ -0,0 +1,9 @@
+package ports
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/models"
+
+// Authorizer decides whether a user may perform an action on a path.
+// An empty user is an anonymous caller.
+type Authorizer interface {
+	Allowed(user string, action models.Action, path string) bool
+}
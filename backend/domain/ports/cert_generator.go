@@ -1,5 +1,27 @@
 package ports
 
+import (
+	"crypto/tls"
+	"net/http"
+)
+
 type TLSCertGenerator interface {
 	GenerateCert() ([]byte, []byte, error)
 }
+
+// TLSCertWatcher is implemented by generators whose certificate lives in
+// files that may be replaced while the server runs.
+type TLSCertWatcher interface {
+	// CertVersion returns a value that changes whenever the files do
+	CertVersion() (string, error)
+}
+
+// TLSCertSource obtains certificates during the handshake instead of
+// serving one fixed key pair, as ACME clients do.
+type TLSCertSource interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// NextProtos lists ALPN protocols the source must be offered, such as acme-tls/1
+	NextProtos() []string
+	// HTTPHandler answers challenges on plain HTTP and passes other requests to fallback
+	HTTPHandler(fallback http.Handler) http.Handler
+}
@@ -1,11 +1,97 @@
 package ports
 
+import (
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
 // ConfigProvider defines the interface for configuration providers
 type ConfigProvider interface {
+	// GetListenAddr returns the host:port the server listens on; GetPort is its port
+	GetListenAddr() string
 	GetPort() string
 	GetUsername() string
 	GetPassword() string
 	GetRootDir() string
+	// GetShares returns the named directories served instead of the root directory, or nil to serve the root
+	GetShares() []models.Share
+	// GetAccessMode returns the server's access mode, which shares without a mode of their own inherit
+	GetAccessMode() models.AccessMode
+	// GetSymlinkPolicy returns which symlinks inside shared directories are followed
+	GetSymlinkPolicy() models.SymlinkPolicy
+	// GetStaticDir returns the frontend build served at /, or "" for none
+	GetStaticDir() string
+	// GetReadTimeout, GetWriteTimeout and GetShutdownTimeout bound requests and graceful shutdown
+	GetReadTimeout() time.Duration
+	GetWriteTimeout() time.Duration
+	GetShutdownTimeout() time.Duration
+	// GetMaxHeaderBytes returns the largest request header block accepted
+	GetMaxHeaderBytes() int
+	// GetMaxUploadBytes returns the largest file an upload may create; zero means no limit
+	GetMaxUploadBytes() int64
 	// EnableTLS returns whether TLS should be enabled
 	EnableTLS() bool
+	// GetAuthMode returns the authentication scheme protecting the API
+	GetAuthMode() AuthMode
+	// GetJWTSecret returns the key used to sign and verify JWT tokens
+	GetJWTSecret() string
+	// PublicHealth returns whether the health endpoint bypasses authentication
+	PublicHealth() bool
+	// PublicStatic returns whether static assets and API docs bypass authentication
+	PublicStatic() bool
+	// GetUsersFile returns the htpasswd-style credential file, or "" to use the single USERNAME/PASSWORD account
+	GetUsersFile() string
+	// GetACLFile returns the JSON access control policy, or "" to allow every action
+	GetACLFile() string
+	// TrashEnabled reports whether deletes move entries to the trash
+	TrashEnabled() bool
+	// GetTrashRetention returns how long trashed entries are kept; zero keeps them until purged
+	GetTrashRetention() time.Duration
+	// GetVersionPolicy returns how overwritten files keep their earlier
+	// contents, for the root directory and shares without a policy of their own
+	GetVersionPolicy() models.VersionPolicy
+	// GetRootQuota returns the bytes the root directory may hold when no
+	// shares are configured; zero means no limit
+	GetRootQuota() int64
+	// GetUserQuota returns the bytes each user's uploads may take; zero means no limit
+	GetUserQuota() int64
+	// GetUserQuotas returns per-user limits that replace GetUserQuota
+	GetUserQuotas() map[string]int64
+	// GetUsageFile returns the JSON file recording who uploaded each file
+	GetUsageFile() string
+	// GetLinksFile returns the JSON file where share links are kept
+	GetLinksFile() string
+	// GetUploadDir returns the directory where resumable uploads are staged
+	GetUploadDir() string
+	// GetConflictPolicy returns what uploads do when the destination file exists
+	GetConflictPolicy() models.ConflictPolicy
+	// GetLogFormat returns the log output format: json or text
+	GetLogFormat() string
+	// GetLogLevel returns the minimum level logged: debug, info, warn or error
+	GetLogLevel() string
+	// GetMetricsAddr returns a separate listen address for /metrics, or "" to serve it on the main port
+	GetMetricsAddr() string
+	// GetTLSCertFile and GetTLSKeyFile name a PEM certificate and key to serve, or "" to generate one
+	GetTLSCertFile() string
+	GetTLSKeyFile() string
+	// GetTLSCertDir returns where the generated local CA and server certificate are kept
+	GetTLSCertDir() string
+	// GetTLSHosts returns the DNS names and IP addresses a generated certificate covers, or nil for the defaults
+	GetTLSHosts() []string
+	// GetACMEDomains returns the names to obtain ACME certificates for, or nil to disable ACME
+	GetACMEDomains() []string
+	// GetACMEEmail returns the contact address registered with the ACME CA
+	GetACMEEmail() string
+	// GetACMEDirectoryURL returns the ACME directory, or "" for Let's Encrypt
+	GetACMEDirectoryURL() string
+	// GetACMECacheDir returns where ACME account keys and certificates are cached
+	GetACMECacheDir() string
+	// GetACMECAFile returns extra PEM roots trusted when talking to the ACME directory
+	GetACMECAFile() string
+	// GetHTTPRedirectAddr returns a plain HTTP listener that redirects to HTTPS (and answers
+	// ACME HTTP-01 challenges), or "" for none
+	GetHTTPRedirectAddr() string
+	// GetHSTSMaxAge returns the Strict-Transport-Security max-age sent over HTTPS; zero disables it
+	GetHSTSMaxAge() time.Duration
 }
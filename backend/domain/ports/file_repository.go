@@ -6,10 +6,26 @@ import (
 
 type FileRepository interface {
 	ListDirectory(path string) ([]*models.FileInfo, error)
+	// Stat returns metadata for a single entry, sniffing the MIME type if needed.
+	Stat(path string) (*models.FileInfo, error)
 	IsDirectory(path string) (bool, error)
 	FileExists(path string) (bool, error)
-	ServeFile(path string) (models.ReadCloser, string, error)
+	// ServeFile opens a regular file for reading; the caller closes Content.
+	ServeFile(path string) (*models.FileContent, error)
 	CreateDirectory(path string) error
-	WriteFile(path string, reader models.ReadCloser) (int64, error)
-	ZipDirectory(root string) (models.ReadCloser, error)
+	// WriteFile stores reader's content at path atomically: readers see either
+	// the old file or the complete new one. If path exists, policy decides
+	// whether to replace it, pick a free "name (n).ext" or fail with a
+	// ConflictError. It returns the path actually written and its size.
+	WriteFile(path string, reader models.ReadCloser, policy models.ConflictPolicy) (string, int64, error)
+	// ZipDirectory streams a ZIP of root. include, if non-nil, receives each
+	// entry's repository path and returns false to leave it (or its subtree) out.
+	ZipDirectory(root string, include func(path string, isDir bool) bool) (models.ReadCloser, error)
+	// Delete removes path; directories with contents need recursive.
+	Delete(path string, recursive bool) error
+	// Move renames src to dst, replacing dst first when overwrite is set.
+	Move(src, dst string, overwrite bool) error
+	// Copy duplicates a file or directory tree, replacing dst first when overwrite
+	// is set. include filters entries below a directory as in ZipDirectory.
+	Copy(src, dst string, overwrite bool, include func(path string, isDir bool) bool) error
 }
@@ -1,18 +1,48 @@
 package ports
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Token types distinguish short-lived access tokens from refresh tokens
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
 
 // JWTClaims represents the claims stored in a JWT token
 type JWTClaims struct {
+	ID        string    `json:"jti"`
 	Username  string    `json:"username"`
+	TokenType string    `json:"token_use"`
 	ExpiresAt time.Time `json:"exp"`
 	IssuedAt  time.Time `json:"iat"`
 }
 
 type JWTProvider interface {
-	// GenerateToken creates a new JWT token for the given username
+	// GenerateToken creates a new access token for the given username
 	GenerateToken(username string) (string, error)
 
-	// ValidateToken validates a JWT token and returns the claims
+	// GenerateRefreshToken creates a longer-lived refresh token for the given username
+	GenerateRefreshToken(username string) (string, error)
+
+	// ValidateToken validates a JWT token and returns the claims.
+	// Revoked tokens are rejected.
 	ValidateToken(tokenString string) (*JWTClaims, error)
+
+	// RevokeToken stops the token identified by claims from validating again.
+	// It fails with ErrTokenRevoked if the token was already revoked.
+	RevokeToken(claims *JWTClaims) error
+}
+
+// ErrTokenRevoked reports a token that had been revoked before
+var ErrTokenRevoked = errors.New("token already revoked")
+
+// TokenRevocationList remembers revoked token IDs until they would have expired anyway
+type TokenRevocationList interface {
+	// Revoke records tokenID, failing with ErrTokenRevoked if it already is,
+	// so that only one of several concurrent revocations succeeds
+	Revoke(tokenID string, expiresAt time.Time) error
+	IsRevoked(tokenID string) bool
 }
@@ -0,0 +1,18 @@
+package ports
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/models"
+
+// LinkStore keeps share links across restarts.
+type LinkStore interface {
+	// Save creates or replaces the link with link.Token.
+	Save(link *models.ShareLink) error
+	// Get returns the link, or a NotFoundError.
+	Get(token string) (*models.ShareLink, error)
+	// List returns every stored link.
+	List() ([]*models.ShareLink, error)
+	// Update applies fn to the stored link and saves the result, with no
+	// other update in between. Nothing is saved if fn fails.
+	Update(token string, fn func(link *models.ShareLink) error) (*models.ShareLink, error)
+	// Delete removes the link. Missing links are not an error.
+	Delete(token string) error
+}
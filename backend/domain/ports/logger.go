@@ -1,7 +1,10 @@
 package ports
 
-// Logger defines the interface for logging messages at different severity levels
+// Logger defines the interface for logging messages at different severity levels.
+// keysAndValues are alternating attribute names and values.
 type Logger interface {
+	// Debug logs diagnostic messages that are usually filtered out
+	Debug(msg string, keysAndValues ...any)
 	// Info logs informational messages
 	Info(msg string, keysAndValues ...any)
 	// Warn logs warning messages
@@ -10,4 +13,6 @@ type Logger interface {
 	Error(msg string, keysAndValues ...any)
 	// Fatal logs critical messages and terminates the program
 	Fatal(msg string, keysAndValues ...any)
+	// With returns a child logger that adds keysAndValues to every message
+	With(keysAndValues ...any) Logger
 }
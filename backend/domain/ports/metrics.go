@@ -0,0 +1,18 @@
+package ports
+
+import "time"
+
+// Metrics records request and transfer statistics for monitoring.
+type Metrics interface {
+	// ObserveRequest records one HTTP request by method, route pattern and status
+	ObserveRequest(method, route string, status int, duration time.Duration)
+	// AddBytesUploaded counts file content received from clients
+	AddBytesUploaded(n int64)
+	// AddBytesDownloaded counts file and archive content sent to clients
+	AddBytesDownloaded(n int64)
+	// ZipStreamStarted and ZipStreamFinished bracket one streamed archive
+	ZipStreamStarted()
+	ZipStreamFinished()
+	// UploadFailed counts a failed upload by a short reason such as "forbidden"
+	UploadFailed(reason string)
+}
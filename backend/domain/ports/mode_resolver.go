@@ -0,0 +1,9 @@
+package ports
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/models"
+
+// ModeResolver reports the access mode in effect at a path, e.g. the mode
+// of the share the path belongs to.
+type ModeResolver interface {
+	ModeFor(path string) models.AccessMode
+}
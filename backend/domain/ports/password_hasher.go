@@ -0,0 +1,8 @@
+package ports
+
+// PasswordHasher hashes secrets that must be checked later but never read back.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash.
+	Verify(hash, password string) bool
+}
@@ -0,0 +1,9 @@
+package ports
+
+// ReadinessCheck reports whether one dependency is able to serve requests.
+type ReadinessCheck interface {
+	// Name identifies the check in readiness reports
+	Name() string
+	// Check returns nil when the dependency is ready
+	Check() error
+}
@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// TrashRepository keeps deleted entries in a hidden area of the repository
+// so they can be restored. Trashed entries never appear in listings or
+// archives and cannot be reached through their paths.
+type TrashRepository interface {
+	// MoveToTrash moves path into the trash, recording who deleted it.
+	MoveToTrash(path, user string) (*models.TrashItem, error)
+	// ListTrash returns the trashed entries, oldest first.
+	ListTrash() ([]*models.TrashItem, error)
+	// RestoreFromTrash moves entry id back to dst, or to where it came from
+	// when dst is "". policy decides what happens if dst is taken; an entry
+	// it overwrites is trashed as deleted by user. It returns the path
+	// restored to.
+	RestoreFromTrash(id, dst, user string, policy models.ConflictPolicy) (string, error)
+	// PurgeTrash deletes entry id for good.
+	PurgeTrash(id string) error
+	// PurgeTrashBefore deletes every entry trashed before cutoff and
+	// returns how many there were.
+	PurgeTrashBefore(cutoff time.Time) (int, error)
+}
@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"io"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// UploadStore stages the bytes of resumable uploads outside the file
+// repository until they are complete.
+type UploadStore interface {
+	// Create stores a new session with no staged bytes yet.
+	Create(session *models.UploadSession) error
+	// Save updates the record of an existing session. A session deleted
+	// meanwhile is a NotFoundError; Save never brings it back.
+	Save(session *models.UploadSession) error
+	// Get returns the session with its current offset, or a NotFoundError.
+	Get(id string) (*models.UploadSession, error)
+	// List returns every staged session.
+	List() ([]*models.UploadSession, error)
+	// Append writes up to limit bytes from r at offset and returns the new
+	// offset. Bytes read before an error are kept. An offset that does not
+	// match the staged size is a ConflictError.
+	Append(id string, offset, limit int64, r io.Reader) (int64, error)
+	// Open reads back the staged bytes; the caller closes it.
+	Open(id string) (models.ReadCloser, error)
+	// Delete discards the session and its staged bytes.
+	Delete(id string) error
+}
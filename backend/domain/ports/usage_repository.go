@@ -0,0 +1,30 @@
+package ports
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/models"
+
+// UsageRepository reports how many bytes each area of storage holds: the
+// root directory, or each share. Usage is kept up to date as files are
+// written and removed rather than measured per request. Usage counts the
+// files; trashed entries and versions take whatever room they leave, and
+// the oldest are evicted when a write needs it.
+type UsageRepository interface {
+	// UsageAt returns the usage of the area holding path.
+	UsageAt(path string) (models.Usage, error)
+	// Usages returns the usage of every area.
+	Usages() ([]models.Usage, error)
+}
+
+// UsageLedger remembers who uploaded each file and how large it was, so
+// user quotas count the bytes a user's uploads still take.
+type UsageLedger interface {
+	// Record notes that the file at path holds size bytes uploaded by user,
+	// replacing whatever was recorded for path.
+	Record(path, user string, size int64) error
+	// Forget drops the records of path and everything below it.
+	Forget(path string) error
+	// Rename moves the records of path and everything below it to newPath,
+	// dropping those already at newPath.
+	Rename(path, newPath string) error
+	// UserUsage returns the bytes recorded for user.
+	UserUsage(user string) (int64, error)
+}
@@ -0,0 +1,20 @@
+package ports
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/models"
+
+// VersionRepository keeps the earlier contents of files replaced by
+// overwriting writes in a hidden area of the repository. Versions never
+// appear in listings or archives.
+type VersionRepository interface {
+	// ListVersions returns the kept versions of path, newest first.
+	ListVersions(path string) ([]*models.FileVersion, error)
+	// OpenVersion opens version id of path for reading.
+	OpenVersion(path, id string) (*models.FileContent, error)
+	// RestoreVersion writes version id back to path, keeping the content it
+	// replaces as a version in turn. policy decides what happens if path is
+	// taken. It returns the path written.
+	RestoreVersion(path, id string, policy models.ConflictPolicy) (string, error)
+	// PruneVersions drops the versions the policy no longer keeps and
+	// returns how many there were.
+	PruneVersions() (int, error)
+}
@@ -1,3 +1,19 @@
 module github.com/EslamYasser-Dev/simple-file-share
 
 go 1.25.0
+
+require (
+	golang.org/x/crypto v0.50.0
+	golang.org/x/term v0.42.0
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/sys v0.43.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/net v0.52.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+)
@@ -0,0 +1,114 @@
+package xhttp
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// certReloader hands the current certificate to TLS handshakes. When the
+// generator implements ports.TLSCertWatcher, it is asked at most once per
+// interval whether its files changed, and the certificate is reloaded if so.
+// A failed reload keeps serving the previous certificate.
+type certReloader struct {
+	generator ports.TLSCertGenerator
+	logger    ports.Logger
+	interval  time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	version string
+	checked time.Time
+}
+
+func newCertReloader(generator ports.TLSCertGenerator, logger ports.Logger, interval time.Duration) *certReloader {
+	return &certReloader{generator: generator, logger: logger, interval: interval, now: time.Now}
+}
+
+// load fetches and parses the certificate, replacing the current one on success.
+func (c *certReloader) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loadLocked(c.currentVersion())
+}
+
+func (c *certReloader) loadLocked(version string) error {
+	certPEM, keyPEM, err := c.generator.GenerateCert()
+	if err != nil {
+		return fmt.Errorf("failed to generate TLS certificate: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse TLS key pair: %w", err)
+	}
+	c.cert = &cert
+	// Generating may have rewritten the files, so read the version afterwards
+	if after := c.currentVersion(); after != "" {
+		version = after
+	}
+	c.version = version
+	c.checked = c.now()
+	return nil
+}
+
+// currentVersion returns the watched files' version, or "" if they cannot be read.
+func (c *certReloader) currentVersion() string {
+	watcher, ok := c.generator.(ports.TLSCertWatcher)
+	if !ok {
+		return ""
+	}
+	version, err := watcher.CertVersion()
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// replace switches to generator if it produces a usable certificate;
+// otherwise the current generator and certificate stay in place.
+func (c *certReloader) replace(generator ports.TLSCertGenerator) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.generator
+	c.generator = generator
+	if err := c.loadLocked(c.currentVersion()); err != nil {
+		c.generator = previous
+		return err
+	}
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, watched := c.generator.(ports.TLSCertWatcher); watched && c.now().Sub(c.checked) >= c.interval {
+		c.checked = c.now()
+		if version := c.currentVersion(); version != c.version {
+			if err := c.loadLocked(version); err != nil {
+				// Remember the version so a broken file is not retried on every handshake
+				c.version = version
+				c.logger.Warn("Keeping previous TLS certificate", "error", err)
+			} else {
+				c.logger.Info("Reloaded TLS certificate")
+			}
+		}
+	}
+	if c.cert == nil {
+		return nil, fmt.Errorf("no TLS certificate loaded")
+	}
+	return c.cert, nil
+}
+
+// loaded reports whether a certificate is available.
+func (c *certReloader) loaded() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cert != nil
+}
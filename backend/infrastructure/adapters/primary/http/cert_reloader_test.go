@@ -0,0 +1,63 @@
+package xhttp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/tls"
+)
+
+// watchedGenerator hands out fresh certificates and reports version.
+type watchedGenerator struct {
+	version string
+	fail    bool
+	calls   int
+}
+
+func (g *watchedGenerator) GenerateCert() ([]byte, []byte, error) {
+	g.calls++
+	if g.fail {
+		return nil, nil, errors.New("broken certificate")
+	}
+	return (&tls.InMemoryTLSCertGenerator{}).GenerateCert()
+}
+
+func (g *watchedGenerator) CertVersion() (string, error) { return g.version, nil }
+
+func TestCertReloader(t *testing.T) {
+	gen := &watchedGenerator{version: "1"}
+	now := time.Now()
+	certs := newCertReloader(gen, nopLogger{}, time.Minute)
+	certs.now = func() time.Time { return now }
+	if err := certs.load(); err != nil {
+		t.Fatalf("Failed to load certificate: %v", err)
+	}
+	first, err := certs.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+
+	// Changes are not noticed until the check interval passes
+	gen.version = "2"
+	if cert, _ := certs.GetCertificate(nil); cert != first || gen.calls != 1 {
+		t.Fatalf("Expected no reload within the interval, got %d loads", gen.calls)
+	}
+	now = now.Add(time.Minute)
+	second, err := certs.GetCertificate(nil)
+	if err != nil || second == first {
+		t.Fatalf("Expected a reloaded certificate, got error %v", err)
+	}
+
+	// A broken replacement keeps the previous certificate and is tried once
+	gen.version, gen.fail = "3", true
+	now = now.Add(time.Minute)
+	if cert, err := certs.GetCertificate(nil); err != nil || cert != second {
+		t.Fatalf("Expected the previous certificate after a failed reload, got error %v", err)
+	}
+	now = now.Add(time.Minute)
+	certs.GetCertificate(nil)
+	if gen.calls != 3 {
+		t.Errorf("Expected the broken version to be tried once, got %d loads", gen.calls)
+	}
+}
@@ -4,13 +4,24 @@ import "time"
 
 const (
 	// Server timeouts
-	DefaultReadTimeout  = 30 * time.Second
-	DefaultWriteTimeout = 30 * time.Second
+	DefaultReadTimeout     = 30 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
 	DefaultShutdownTimeout = 30 * time.Second
-	
+
 	// HTTP limits
 	DefaultMaxHeaderBytes = 1 << 20 // 1MB
-	
+
+	// Request tracing
+	RequestIDHeader    = "X-Request-ID"
+	MaxRequestIDLength = 128
+
+	// Authentication
+	DefaultTokenExpiry = 1 * time.Hour
+
 	// TLS configuration
 	DefaultTLSMinVersion = 1.3
+	// CertCheckInterval is how often handshakes check the certificate files for changes
+	CertCheckInterval = 10 * time.Second
+	// DefaultHSTSMaxAge is how long browsers keep using HTTPS after a visit
+	DefaultHSTSMaxAge = 365 * 24 * time.Hour
 )
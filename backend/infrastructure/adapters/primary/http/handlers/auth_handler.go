@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// AuthHandler serves the login, refresh and logout endpoints.
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler creates a new AuthHandler.
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+// tokenResponse is the JSON body returned by login and refresh.
+type tokenResponse struct {
+	AccessToken      string `json:"accessToken"`
+	RefreshToken     string `json:"refreshToken"`
+	TokenType        string `json:"tokenType"`
+	ExpiresIn        int64  `json:"expiresIn"`
+	RefreshExpiresIn int64  `json:"refreshExpiresIn"`
+}
+
+// ServeHTTP dispatches on the final path segment.
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/api/auth/") {
+	case "login":
+		h.login(w, r)
+	case "refresh":
+		h.refresh(w, r)
+	case "logout":
+		h.logout(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := decodeJSON(w, r, &body); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	pair, err := h.authService.Login(body.Username, body.Password)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeTokenPair(w, pair)
+}
+
+func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := decodeJSON(w, r, &body); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	pair, err := h.authService.Refresh(body.RefreshToken)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeTokenPair(w, pair)
+}
+
+// logout revokes the Bearer token and the refresh token from the body, if present.
+// A missing body is fine; the client may only hold an access token.
+func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &body); err != nil {
+			respondWithError(w, err)
+			return
+		}
+	}
+
+	accessToken, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		accessToken = ""
+	}
+	if err := h.authService.Logout(accessToken, body.RefreshToken); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokenPair renders a token pair with lifetimes relative to now.
+func writeTokenPair(w http.ResponseWriter, pair *models.TokenPair) {
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:      pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		RefreshExpiresIn: int64(time.Until(pair.RefreshExpiresAt).Seconds()),
+	})
+}
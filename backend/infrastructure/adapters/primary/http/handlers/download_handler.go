@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	stderrors "errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	xhttp "github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/primary/http"
 
 	"github.com/EslamYasser-Dev/simple-file-share/application/services"
 )
@@ -37,7 +42,7 @@ func (h *DownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	isZipRequest := strings.HasSuffix(path, ".zip")
 	if isZipRequest {
 		path = strings.TrimSuffix(path, ".zip")
-		stream, filename, err := h.zipService.Execute(path)
+		stream, filename, err := h.zipService.Execute(currentUser(r), path)
 		if err != nil {
 			respondWithError(w, err)
 			return
@@ -46,39 +51,86 @@ func (h *DownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Not a directory", http.StatusBadRequest)
 			return
 		}
-		serveDownload(w, stream, filename, "application/zip")
+		serveDownload(w, r, stream, filename, "application/zip")
 		return
 	}
 
-	stream, filename, err := h.fileService.Execute(path)
+	file, err := h.fileService.Execute(currentUser(r), path)
 	if err != nil {
 		respondWithError(w, err)
 		return
 	}
-	if stream == nil {
+	if file == nil {
 		http.Error(w, "Is a directory", http.StatusConflict)
 		return
 	}
-	serveDownload(w, stream, filename, "application/octet-stream")
+	serveFile(w, r, file)
 }
 
-// serveDownload writes stream to HTTP response with headers.
-func serveDownload(w http.ResponseWriter, stream io.ReadCloser, filename, contentType string) {
+// serveFile sends a file with Content-Length, ETag and Last-Modified, and
+// answers Range, If-Range, If-None-Match and If-Modified-Since requests.
+func serveFile(w http.ResponseWriter, r *http.Request, file *models.FileContent) {
+	defer file.Content.Close()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Header().Set("ETag", fileETag(file))
+	http.ServeContent(w, r, file.Name, file.ModTime, file.Content)
+}
+
+// fileETag derives a strong validator from the file's size and modification time.
+func fileETag(file *models.FileContent) string {
+	return fmt.Sprintf(`"%x-%x"`, file.ModTime.UnixNano(), file.Size)
+}
+
+// serveDownload writes stream to HTTP response with headers. A HEAD request
+// gets the headers alone, without the stream being read.
+func serveDownload(w http.ResponseWriter, r *http.Request, stream io.ReadCloser, filename, contentType string) {
 	defer stream.Close()
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if r.Method == http.MethodHead {
+		return
+	}
 	if _, err := io.Copy(w, stream); err != nil {
 		http.Error(w, "Stream copy failed", http.StatusInternalServerError)
 	}
 }
 
-// respondWithError maps domain errors to HTTP status codes.
+// respondWithError maps domain errors to HTTP status codes. A path the
+// filesystem reports missing is Not Found too, whichever route reached it,
+// so the server's hidden directories look like any other missing path.
 func respondWithError(w http.ResponseWriter, err error) {
-	if _, ok := err.(*errors.NotFoundError); ok {
+	if stderrors.Is(err, fs.ErrNotExist) {
+		err = &errors.NotFoundError{}
+	}
+	switch err.(type) {
+	case *errors.NotFoundError:
 		http.Error(w, "Not Found", http.StatusNotFound)
-		return
+	case *errors.UnauthorizedError:
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case *errors.ForbiddenError:
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case *errors.ConflictError:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case *errors.ValidationError:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case *errors.TooLargeError:
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case *errors.QuotaExceededError:
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	case *errors.GoneError:
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// currentUser returns the authenticated username, or "" for anonymous requests.
+func currentUser(r *http.Request) string {
+	if claims, ok := xhttp.UserFromContext(r.Context()); ok {
+		return claims.Username
 	}
-	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	return ""
 }
 
 // cleanPath normalizes path for security and consistency.
@@ -89,6 +141,12 @@ func cleanPath(p string) string {
 	return filepath.ToSlash(p)
 }
 
+// normalizePath turns a client-supplied path into a clean absolute slash
+// path that cannot climb above the root.
+func normalizePath(p string) string {
+	return path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+}
+
 // containsPathTraversal checks for dangerous path patterns.
 func containsPathTraversal(p string) bool {
 	return strings.Contains(p, "..")
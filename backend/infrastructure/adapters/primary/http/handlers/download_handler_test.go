@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+const downloadContent = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+func newDownloadTestHandler(t *testing.T) (*DownloadHandler, time.Time) {
+	t.Helper()
+	root := t.TempDir()
+	full := filepath.Join(root, "data.txt")
+	if err := os.WriteFile(full, []byte(downloadContent), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(full, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	return NewDownloadHandler(
+		services.NewDownloadFileService(repo, authorizer),
+		services.NewDownloadZipService(repo, authorizer),
+	), modTime
+}
+
+func download(handler http.Handler, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/data.txt", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDownloadFullFile(t *testing.T) {
+	handler, modTime := newDownloadTestHandler(t)
+
+	rec := download(handler, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != downloadContent {
+		t.Errorf("Unexpected body %q", rec.Body.String())
+	}
+	want := map[string]string{
+		"Content-Length":      "36",
+		"Accept-Ranges":       "bytes",
+		"Last-Modified":       modTime.Format(http.TimeFormat),
+		"Content-Type":        "application/octet-stream",
+		"Content-Disposition": `attachment; filename="data.txt"`,
+	}
+	for name, value := range want {
+		if got := rec.Header().Get(name); got != value {
+			t.Errorf("Expected %s %q, got %q", name, value, got)
+		}
+	}
+	if etag := rec.Header().Get("ETag"); !strings.HasPrefix(etag, `"`) {
+		t.Errorf("Expected a strong ETag, got %q", etag)
+	}
+}
+
+func TestDownloadRangeAndConditionalRequests(t *testing.T) {
+	handler, modTime := newDownloadTestHandler(t)
+	etag := download(handler, nil).Header().Get("ETag")
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		want         int
+		body         string
+		contentRange string
+	}{
+		{"single range", map[string]string{"Range": "bytes=10-19"}, http.StatusPartialContent, "abcdefghij", "bytes 10-19/36"},
+		{"open-ended range", map[string]string{"Range": "bytes=30-"}, http.StatusPartialContent, "uvwxyz", "bytes 30-35/36"},
+		{"suffix range", map[string]string{"Range": "bytes=-4"}, http.StatusPartialContent, "wxyz", "bytes 32-35/36"},
+		{"unsatisfiable range", map[string]string{"Range": "bytes=100-200"}, http.StatusRequestedRangeNotSatisfiable, "", "bytes */36"},
+		{"if-none-match hit", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "", ""},
+		{"if-none-match miss", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK, downloadContent, ""},
+		{"if-modified-since unchanged", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, http.StatusNotModified, "", ""},
+		{"if-modified-since older", map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, downloadContent, ""},
+		{"if-range current", map[string]string{"Range": "bytes=0-3", "If-Range": etag}, http.StatusPartialContent, "0123", "bytes 0-3/36"},
+		{"if-range stale", map[string]string{"Range": "bytes=0-3", "If-Range": `"stale"`}, http.StatusOK, downloadContent, ""},
+		{"if-range date", map[string]string{"Range": "bytes=0-3", "If-Range": modTime.Format(http.TimeFormat)}, http.StatusPartialContent, "0123", "bytes 0-3/36"},
+	}
+
+	for _, tt := range tests {
+		rec := download(handler, tt.headers)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, rec.Code)
+			continue
+		}
+		if tt.want != http.StatusRequestedRangeNotSatisfiable && rec.Body.String() != tt.body {
+			t.Errorf("%s: expected body %q, got %q", tt.name, tt.body, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Range"); got != tt.contentRange {
+			t.Errorf("%s: expected Content-Range %q, got %q", tt.name, tt.contentRange, got)
+		}
+	}
+}
+
+func TestDownloadMultipleRanges(t *testing.T) {
+	handler, _ := newDownloadTestHandler(t)
+
+	rec := download(handler, map[string]string{"Range": "bytes=0-1,10-12"})
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", rec.Code)
+	}
+	mediaType, params, err := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected multipart/byteranges, got %q", rec.Header().Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(rec.Body, params["boundary"])
+	want := []struct{ contentRange, body string }{
+		{"bytes 0-1/36", "01"},
+		{"bytes 10-12/36", "abc"},
+	}
+	for i, w := range want {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("Failed to read part %d: %v", i, err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Failed to read part %d body: %v", i, err)
+		}
+		if got := part.Header.Get("Content-Range"); got != w.contentRange {
+			t.Errorf("Part %d: expected Content-Range %q, got %q", i, w.contentRange, got)
+		}
+		if string(body) != w.body {
+			t.Errorf("Part %d: expected body %q, got %q", i, w.body, body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("Expected exactly two parts, got extra part or error: %v", err)
+	}
+}
+
+func TestRootHandlerRejectsTraversal(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "share")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "data.txt"), []byte(downloadContent), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	handler := NewRootHandler(
+		services.NewListFilesService(repo, authorizer),
+		services.NewDownloadFileService(repo, authorizer),
+		services.NewDownloadZipService(repo, authorizer),
+		services.NewFileInfoService(repo, authorizer),
+		"22010",
+	)
+
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"/api/files/download?path=../secret.txt", http.StatusForbidden},
+		{"/api/files/download?path=/a/../../secret.txt", http.StatusForbidden},
+		{"/api/files?path=..", http.StatusForbidden},
+		{"/api/files/download?path=/data.txt", http.StatusOK},
+		{"/api/files/download?path=data.txt", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.want, rec.Code)
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("GET %s: served a file outside the root", tt.target)
+		}
+	}
+}
+
+func TestMissingPathsAreNotFound(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".trash", ".versions"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0700); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	list := services.NewListFilesService(repo, authorizer)
+	handler := NewRootHandler(
+		list,
+		services.NewDownloadFileService(repo, authorizer),
+		services.NewDownloadZipService(repo, authorizer),
+		services.NewFileInfoService(repo, authorizer),
+		"22010",
+	)
+
+	// The hidden directories answer like paths that were never there
+	for _, target := range []string{
+		"/.trash", "/.versions", "/missing", "/missing.zip", "/.trash.zip",
+		"/api/files?path=/.trash", "/api/files?path=/missing",
+		"/api/files/info?path=/.versions", "/api/files/download?path=/missing",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected status 404, got %d", target, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	NewListHandler(list, "22010").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.trash", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("List /.trash: expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestFileInfo(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "share")
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "report.txt"), []byte(downloadContent), 0640); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Chmod(filepath.Join(root, "docs", "report.txt"), 0640); err != nil {
+		t.Fatalf("Failed to chmod file: %v", err)
+	}
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(root, "docs", "report.txt"), modTime, modTime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	handler := NewRootHandler(
+		services.NewListFilesService(repo, authorizer),
+		services.NewDownloadFileService(repo, authorizer),
+		services.NewDownloadZipService(repo, authorizer),
+		services.NewFileInfoService(repo, authorizer),
+		"22010",
+	)
+	get := func(target string) (*httptest.ResponseRecorder, fileDetails) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var details fileDetails
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &details); err != nil {
+				t.Fatalf("GET %s: failed to decode response: %v", target, err)
+			}
+		}
+		return rec, details
+	}
+
+	rec, file := get("/api/files/info?path=/docs/report.txt")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a file, got %d", rec.Code)
+	}
+	want := fileDetails{
+		fileItem: fileItem{
+			Name:     "report.txt",
+			Path:     "docs/report.txt",
+			Size:     int64(len(downloadContent)),
+			Modified: "2024-03-01T12:00:00Z",
+			MimeType: "text/plain; charset=utf-8",
+		},
+		Mode:          "-rw-r-----",
+		SizeFormatted: "36 B",
+	}
+	if file != want {
+		t.Errorf("Expected file details %+v, got %+v", want, file)
+	}
+
+	rec, dir := get("/api/files/info?path=docs")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a directory, got %d", rec.Code)
+	}
+	if !dir.IsDir || dir.Name != "docs" || !strings.HasPrefix(dir.Mode, "d") {
+		t.Errorf("Expected directory details, got %+v", dir)
+	}
+
+	for _, tt := range []struct {
+		target string
+		want   int
+	}{
+		{"/api/files/info?path=/missing.txt", http.StatusNotFound},
+		{"/api/files/info?path=../secret.txt", http.StatusForbidden},
+		{"/api/files/info?path=/docs/../../secret.txt", http.StatusForbidden},
+		{"/api/files/info", http.StatusBadRequest},
+	} {
+		if rec, _ := get(tt.target); rec.Code != tt.want {
+			t.Errorf("GET %s: expected status %d, got %d", tt.target, tt.want, rec.Code)
+		} else if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("GET %s: described a file outside the root", tt.target)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+)
+
+// FileOpsHandler serves the JSON endpoints that change the file tree:
+// delete, move, copy and mkdir.
+type FileOpsHandler struct {
+	deleteService *services.DeleteService
+	moveService   *services.MoveService
+	copyService   *services.CopyService
+	mkdirService  *services.CreateDirectoryService
+}
+
+// NewFileOpsHandler creates a new FileOpsHandler.
+func NewFileOpsHandler(
+	deleteService *services.DeleteService,
+	moveService *services.MoveService,
+	copyService *services.CopyService,
+	mkdirService *services.CreateDirectoryService,
+) *FileOpsHandler {
+	return &FileOpsHandler{
+		deleteService: deleteService,
+		moveService:   moveService,
+		copyService:   copyService,
+		mkdirService:  mkdirService,
+	}
+}
+
+// pathResponse is returned by every operation with the affected path.
+type pathResponse struct {
+	Path string `json:"path"`
+}
+
+// transferRequest is the body of move and copy requests.
+type transferRequest struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// ServeHTTP dispatches on method and path.
+func (h *FileOpsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodDelete && r.URL.Path == "/api/files":
+		h.delete(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/files/move":
+		h.transfer(w, r, h.moveService.Execute)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/files/copy":
+		h.transfer(w, r, h.copyService.Execute)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/directories":
+		h.mkdir(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// delete accepts {"path", "recursive"} as JSON or as query parameters.
+func (h *FileOpsHandler) delete(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Path      string `json:"path"`
+		Recursive bool   `json:"recursive"`
+	}{Path: r.URL.Query().Get("path")}
+	body.Recursive, _ = strconv.ParseBool(r.URL.Query().Get("recursive"))
+
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &body); err != nil {
+			respondWithError(w, err)
+			return
+		}
+	}
+	if body.Path == "" {
+		http.Error(w, "Missing path", http.StatusBadRequest)
+		return
+	}
+
+	target := normalizePath(body.Path)
+	if err := h.deleteService.Execute(currentUser(r), target, body.Recursive); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pathResponse{Path: target})
+}
+
+// transfer handles move and copy, which share a request shape.
+func (h *FileOpsHandler) transfer(w http.ResponseWriter, r *http.Request, execute func(user, src, dst string, overwrite bool) error) {
+	var body transferRequest
+	if err := decodeJSON(w, r, &body); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	if body.From == "" || body.To == "" {
+		http.Error(w, "Both from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	src, dst := normalizePath(body.From), normalizePath(body.To)
+	if err := execute(currentUser(r), src, dst, body.Overwrite); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pathResponse{Path: dst})
+}
+
+func (h *FileOpsHandler) mkdir(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Path string `json:"path"`
+	}
+	if err := decodeJSON(w, r, &body); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	if body.Path == "" {
+		http.Error(w, "Missing path", http.StatusBadRequest)
+		return
+	}
+
+	dir := normalizePath(body.Path)
+	if err := h.mkdirService.Execute(currentUser(r), dir); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, pathResponse{Path: dir})
+}
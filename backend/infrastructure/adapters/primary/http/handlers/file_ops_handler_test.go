@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func TestFileOpsHandler(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, content string) {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("docs/a.txt", "a")
+	writeFile("docs/b.txt", "b")
+	writeFile("other/c.txt", "c")
+
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	handler := NewFileOpsHandler(
+		services.NewDeleteService(repo, authorizer),
+		services.NewMoveService(repo, authorizer),
+		services.NewCopyService(repo, authorizer),
+		services.NewCreateDirectoryService(repo, authorizer),
+	)
+
+	steps := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/api/directories", `{"path": "new/nested"}`, http.StatusCreated},
+		{http.MethodPost, "/api/directories", `{"path": "new/nested"}`, http.StatusConflict},
+		{http.MethodPost, "/api/files/copy", `{"from": "docs/a.txt", "to": "new/a.txt"}`, http.StatusOK},
+		{http.MethodPost, "/api/files/copy", `{"from": "docs/a.txt", "to": "new/a.txt"}`, http.StatusConflict},
+		{http.MethodPost, "/api/files/copy", `{"from": "docs/b.txt", "to": "new/a.txt", "overwrite": true}`, http.StatusOK},
+		{http.MethodPost, "/api/files/copy", `{"from": "docs", "to": "docs/inside"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/files/copy", `{"from": "docs", "to": "docs-copy"}`, http.StatusOK},
+		{http.MethodPost, "/api/files/move", `{"from": "missing.txt", "to": "x.txt"}`, http.StatusNotFound},
+		{http.MethodPost, "/api/files/move", `{"from": "other/c.txt", "to": "docs/a.txt"}`, http.StatusConflict},
+		{http.MethodPost, "/api/files/move", `{"from": "other/c.txt", "to": "moved/c.txt"}`, http.StatusOK},
+		{http.MethodDelete, "/api/files", `{"path": "docs"}`, http.StatusConflict},
+		{http.MethodDelete, "/api/files", `{"path": "docs", "recursive": true}`, http.StatusOK},
+		{http.MethodDelete, "/api/files?path=other", ``, http.StatusOK},
+		{http.MethodDelete, "/api/files", `{"path": "docs"}`, http.StatusNotFound},
+		{http.MethodDelete, "/api/files", `{"path": "../.."}`, http.StatusBadRequest},
+	}
+	for i, step := range steps {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(step.method, step.path, strings.NewReader(step.body)))
+		if rec.Code != step.want {
+			t.Fatalf("step %d %s %s %s: expected %d, got %d (%s)", i, step.method, step.path, step.body, step.want, rec.Code, rec.Body.String())
+		}
+	}
+
+	expect := map[string]string{
+		"new/a.txt":       "b",
+		"docs-copy/a.txt": "a",
+		"moved/c.txt":     "c",
+	}
+	for name, content := range expect {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q (err %v)", name, content, data, err)
+		}
+	}
+	for _, gone := range []string{"docs", "other"} {
+		if _, err := os.Stat(filepath.Join(root, gone)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be deleted", gone)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import "fmt"
+
+// formatFileSize returns human-readable size string.
+func formatFileSize(size int64, isDir bool) string {
+	if isDir {
+		return "[Directory]"
+	}
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+)
+
+// HealthHandler serves the probe endpoints:
+//
+//	GET /health   liveness and uptime
+//	GET /ready    readiness checks; 503 while any check fails
+//	GET /version  build metadata
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler.
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+type healthResponse struct {
+	Status        string `json:"status"`
+	Uptime        string `json:"uptime"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+}
+
+type readyResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type versionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Probe results must never be served from a cache
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch r.URL.Path {
+	case "/health":
+		uptime := h.healthService.Uptime()
+		writeJSON(w, http.StatusOK, healthResponse{
+			Status:        "ok",
+			Uptime:        uptime.Truncate(time.Second).String(),
+			UptimeSeconds: int64(uptime.Seconds()),
+		})
+	case "/ready":
+		checks, ready := h.healthService.Ready()
+		if !ready {
+			writeJSON(w, http.StatusServiceUnavailable, readyResponse{Status: "unavailable", Checks: checks})
+			return
+		}
+		writeJSON(w, http.StatusOK, readyResponse{Status: "ready", Checks: checks})
+	case "/version":
+		build := h.healthService.Version()
+		writeJSON(w, http.StatusOK, versionResponse{
+			Version:   build.Version,
+			Commit:    build.Commit,
+			BuildDate: build.BuildDate,
+			GoVersion: build.GoVersion,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// stubCheck is a readiness check with a fixed outcome.
+type stubCheck struct {
+	name string
+	err  error
+}
+
+func (c *stubCheck) Name() string { return c.name }
+func (c *stubCheck) Check() error { return c.err }
+
+func TestHealthHandler(t *testing.T) {
+	tls := &stubCheck{name: "tls", err: errors.New("certificate not loaded")}
+	build := models.BuildInfo{Version: "v1.2.3", Commit: "abc123", BuildDate: "2024-01-02T03:04:05Z", GoVersion: "go1.25"}
+	handler := NewHealthHandler(services.NewHealthService(build, fs.NewStorageCheck(t.TempDir()), tls))
+
+	get := func(path string) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode %s response: %v", path, err)
+		}
+		return rec.Code, body
+	}
+
+	if code, body := get("/health"); code != http.StatusOK || body["status"] != "ok" || body["uptime"] == nil {
+		t.Errorf("Unexpected /health response %d %v", code, body)
+	}
+
+	code, body := get("/ready")
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while TLS is not loaded, got %d", code)
+	}
+	checks, _ := body["checks"].(map[string]any)
+	if checks["storage"] != "ok" || checks["tls"] != "certificate not loaded" {
+		t.Errorf("Unexpected readiness checks %v", checks)
+	}
+
+	tls.err = nil
+	if code, body := get("/ready"); code != http.StatusOK || body["status"] != "ready" {
+		t.Errorf("Expected ready once TLS is loaded, got %d %v", code, body)
+	}
+
+	code, body = get("/version")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200 from /version, got %d", code)
+	}
+	want := map[string]string{"version": "v1.2.3", "commit": "abc123", "buildDate": "2024-01-02T03:04:05Z", "goVersion": "go1.25"}
+	for key, value := range want {
+		if body[key] != value {
+			t.Errorf("Expected %s %q, got %v", key, value, body[key])
+		}
+	}
+}
+
+func TestStorageCheckFailsForMissingRoot(t *testing.T) {
+	check := fs.NewStorageCheck(t.TempDir() + "/missing")
+	if err := check.Check(); err == nil {
+		t.Error("Expected a missing root directory to fail the storage check")
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+)
+
+// maxJSONBodyBytes caps request bodies decoded by decodeJSON.
+const maxJSONBodyBytes = 1 << 20 // 1MB
+
+// writeJSON encodes v as the response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// decodeJSON reads a JSON request body into v.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.NewValidationError("body", nil, "invalid JSON: "+err.Error())
+	}
+	return nil
+}
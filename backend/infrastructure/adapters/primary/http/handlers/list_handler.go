@@ -28,9 +28,9 @@ func (h *ListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pageData, err := h.listService.Execute(path)
+	pageData, err := h.listService.Execute(currentUser(r), path)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		respondWithError(w, err)
 		return
 	}
 
@@ -1,88 +1,133 @@
 package handlers
 
 import (
-    "encoding/json"
-    "net/http"
-    "path/filepath"
-    "strings"
-    "time"
+	"net/http"
+	"strings"
+	"time"
 
-    "github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
 )
 
 // RootHandler decides between directory listing and file/zip download for GET "/".
 type RootHandler struct {
-	listService     *services.ListFilesService
-	fileService     *services.DownloadFileService
-	zipService      *services.DownloadZipService
-	port            string
+	listService *services.ListFilesService
+	fileService *services.DownloadFileService
+	zipService  *services.DownloadZipService
+	infoService *services.FileInfoService
+	port        string
 }
 
-func NewRootHandler(list *services.ListFilesService, file *services.DownloadFileService, zip *services.DownloadZipService, port string) *RootHandler {
-	return &RootHandler{listService: list, fileService: file, zipService: zip, port: port}
+func NewRootHandler(list *services.ListFilesService, file *services.DownloadFileService, zip *services.DownloadZipService, info *services.FileInfoService, port string) *RootHandler {
+	return &RootHandler{listService: list, fileService: file, zipService: zip, infoService: info, port: port}
+}
+
+// fileItem is the JSON shape of a directory entry used by the frontend.
+type fileItem struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	IsDir    bool   `json:"isDir"`
+	Modified string `json:"modified"`
+	MimeType string `json:"mimeType"`
+}
+
+// fileDetails extends fileItem with the fields only /api/files/info returns.
+type fileDetails struct {
+	fileItem
+	Mode          string `json:"mode"`
+	SymlinkTarget string `json:"symlinkTarget,omitempty"`
+	SizeFormatted string `json:"sizeFormatted"`
+}
+
+// toFileItem maps repository metadata to the frontend shape.
+func toFileItem(f *models.FileInfo) fileItem {
+	return fileItem{
+		Name:     f.Name,
+		Path:     strings.TrimPrefix(f.URL, "/"),
+		Size:     f.Size,
+		IsDir:    f.IsDir,
+		Modified: f.ModTime.UTC().Format(time.RFC3339),
+		MimeType: f.MimeType,
+	}
 }
 
 func (h *RootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-    // API endpoints
-    if r.Method == http.MethodGet && r.URL.Path == "/api/files" {
-        // JSON directory listing using ?path=
-        reqPath := r.URL.Query().Get("path")
-        if reqPath == "" {
-            reqPath = "/"
-        }
-        pageData, err := h.listService.Execute(reqPath)
-        if err != nil {
-            http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-            return
-        }
-        // Map to frontend shape
-        type item struct {
-            Name     string `json:"name"`
-            Path     string `json:"path"`
-            Size     int64  `json:"size"`
-            IsDir    bool   `json:"isDir"`
-            Modified string `json:"modified"`
-        }
-        var items []item
-        if pageData != nil {
-            for _, f := range pageData.Files {
-                p := strings.TrimPrefix(f.URL, "/")
-                items = append(items, item{
-                    Name:     f.Name,
-                    Path:     p,
-                    Size:     0, // repository doesn’t expose raw bytes; fill later if needed
-                    IsDir:    f.IsDir,
-                    Modified: time.Now().Format(time.RFC3339),
-                })
-            }
-        }
-        w.Header().Set("Content-Type", "application/json")
-        _ = json.NewEncoder(w).Encode(items)
-        return
-    }
+	// API endpoints
+	if r.Method == http.MethodGet && r.URL.Path == "/api/files" {
+		// JSON directory listing using ?path=
+		reqPath := r.URL.Query().Get("path")
+		if reqPath == "" {
+			reqPath = "/"
+		}
+		if containsPathTraversal(reqPath) {
+			http.Error(w, "Path traversal detected", http.StatusForbidden)
+			return
+		}
+		pageData, err := h.listService.Execute(currentUser(r), reqPath)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		// Map to frontend shape
+		items := []fileItem{}
+		if pageData != nil {
+			for _, f := range pageData.Files {
+				items = append(items, toFileItem(f))
+			}
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
 
-    if r.Method == http.MethodGet && r.URL.Path == "/api/files/download" {
-        reqPath := r.URL.Query().Get("path")
-        if reqPath == "" {
-            http.Error(w, "Missing path", http.StatusBadRequest)
-            return
-        }
-        // Normalize
-        safePath := filepath.ToSlash(strings.TrimPrefix(reqPath, "/"))
-        stream, filename, err := h.fileService.Execute(safePath)
-        if err != nil {
-            respondWithError(w, err)
-            return
-        }
-        if stream == nil {
-            http.Error(w, "Is a directory", http.StatusConflict)
-            return
-        }
-        serveDownload(w, stream, filename, "application/octet-stream")
-        return
-    }
+	if r.Method == http.MethodGet && r.URL.Path == "/api/files/info" {
+		reqPath := r.URL.Query().Get("path")
+		if reqPath == "" {
+			http.Error(w, "Missing path", http.StatusBadRequest)
+			return
+		}
+		if containsPathTraversal(reqPath) {
+			http.Error(w, "Path traversal detected", http.StatusForbidden)
+			return
+		}
+		info, err := h.infoService.Execute(currentUser(r), normalizePath(reqPath))
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, fileDetails{
+			fileItem:      toFileItem(info),
+			Mode:          info.Mode.String(),
+			SymlinkTarget: info.SymlinkTarget,
+			SizeFormatted: formatFileSize(info.Size, info.IsDir),
+		})
+		return
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == "/api/files/download" {
+		reqPath := r.URL.Query().Get("path")
+		if reqPath == "" {
+			http.Error(w, "Missing path", http.StatusBadRequest)
+			return
+		}
+		if containsPathTraversal(reqPath) {
+			http.Error(w, "Path traversal detected", http.StatusForbidden)
+			return
+		}
+		file, err := h.fileService.Execute(currentUser(r), normalizePath(reqPath))
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		if file == nil {
+			http.Error(w, "Is a directory", http.StatusConflict)
+			return
+		}
+		serveFile(w, r, file)
+		return
+	}
 
-    path := cleanPath(r.URL.Path)
+	path := cleanPath(r.URL.Path)
 	if containsPathTraversal(path) {
 		http.Error(w, "Path traversal detected", http.StatusForbidden)
 		return
@@ -91,7 +136,7 @@ func (h *RootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// ZIP request
 	if strings.HasSuffix(path, ".zip") {
 		path = strings.TrimSuffix(path, ".zip")
-		stream, filename, err := h.zipService.Execute(path)
+		stream, filename, err := h.zipService.Execute(currentUser(r), path)
 		if err != nil {
 			respondWithError(w, err)
 			return
@@ -100,14 +145,14 @@ func (h *RootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Not a directory", http.StatusBadRequest)
 			return
 		}
-		serveDownload(w, stream, filename, "application/zip")
+		serveDownload(w, r, stream, filename, "application/zip")
 		return
 	}
 
 	// Try as directory first
-	pageData, err := h.listService.Execute(path)
+	pageData, err := h.listService.Execute(currentUser(r), path)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		respondWithError(w, err)
 		return
 	}
 	if pageData != nil {
@@ -119,14 +164,14 @@ func (h *RootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fallback: serve as file
-	stream, filename, err := h.fileService.Execute(path)
+	file, err := h.fileService.Execute(currentUser(r), path)
 	if err != nil {
 		respondWithError(w, err)
 		return
 	}
-	if stream == nil {
+	if file == nil {
 		http.Error(w, "Is a directory", http.StatusConflict)
 		return
 	}
-	serveDownload(w, stream, filename, "application/octet-stream")
+	serveFile(w, r, file)
 }
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// linkBasePath is where public share links are served.
+const linkBasePath = "/s/"
+
+// LinkHandler lets authenticated users manage their share links:
+//
+//	GET    /api/links          list the caller's links
+//	POST   /api/links          create a link
+//	GET    /api/links/{token}  one link, with the files received through it
+//	DELETE /api/links/{token}  revoke a link
+type LinkHandler struct {
+	linkService *services.ShareLinkService
+}
+
+// NewLinkHandler creates a new LinkHandler.
+func NewLinkHandler(linkService *services.ShareLinkService) *LinkHandler {
+	return &LinkHandler{linkService: linkService}
+}
+
+// createLinkRequest is the body of POST /api/links.
+type createLinkRequest struct {
+	Path         string `json:"path"`
+	Permission   string `json:"permission"`
+	ExpiresIn    int64  `json:"expiresIn"` // Seconds; zero uses the default
+	Password     string `json:"password"`
+	MaxDownloads int    `json:"maxDownloads"`
+	// Upload-into links only
+	MaxFileSize       int64    `json:"maxFileSize"`
+	AllowedExtensions []string `json:"allowedExtensions"`
+}
+
+// linkResponse is the JSON shape of a share link shown to its owner.
+type linkResponse struct {
+	Token             string         `json:"token"`
+	URL               string         `json:"url"`
+	Path              string         `json:"path"`
+	IsDir             bool           `json:"isDir"`
+	Permission        string         `json:"permission"`
+	PasswordProtected bool           `json:"passwordProtected"`
+	MaxDownloads      int            `json:"maxDownloads"`
+	Downloads         int            `json:"downloads"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	ExpiresAt         time.Time      `json:"expiresAt"`
+	MaxFileSize       int64          `json:"maxFileSize,omitempty"`
+	AllowedExtensions []string       `json:"allowedExtensions,omitempty"`
+	Uploads           []receivedFile `json:"uploads,omitempty"`
+}
+
+// receivedFile is one file sent through an upload-into link, and by whom.
+type receivedFile struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	Uploader   string    `json:"uploader,omitempty"`
+	Email      string    `json:"email,omitempty"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+func toLinkResponse(link *models.ShareLink) linkResponse {
+	var received []receivedFile
+	for _, f := range link.Uploads {
+		received = append(received, receivedFile{
+			Name:       f.Name,
+			Size:       f.Size,
+			Uploader:   f.Uploader.Name,
+			Email:      f.Uploader.Email,
+			UploadedAt: f.UploadedAt.UTC(),
+		})
+	}
+	return linkResponse{
+		Token:             link.Token,
+		URL:               linkBasePath + link.Token,
+		Path:              link.Path,
+		IsDir:             link.IsDir,
+		Permission:        string(link.Permission),
+		PasswordProtected: link.PasswordHash != "",
+		MaxDownloads:      link.MaxDownloads,
+		Downloads:         link.Downloads,
+		CreatedAt:         link.CreatedAt.UTC(),
+		ExpiresAt:         link.ExpiresAt.UTC(),
+		MaxFileSize:       link.MaxFileSize,
+		AllowedExtensions: link.AllowedExtensions,
+		Uploads:           received,
+	}
+}
+
+func (h *LinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/links"), "/")
+	switch {
+	case token == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case token == "" && r.Method == http.MethodPost:
+		h.create(w, r)
+	case token != "" && r.Method == http.MethodGet:
+		link, err := h.linkService.Get(currentUser(r), token)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toLinkResponse(link))
+	case token != "" && r.Method == http.MethodDelete:
+		if err := h.linkService.Revoke(currentUser(r), token); err != nil {
+			respondWithError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LinkHandler) list(w http.ResponseWriter, r *http.Request) {
+	links, err := h.linkService.List(currentUser(r))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	items := make([]linkResponse, 0, len(links))
+	for _, link := range links {
+		items = append(items, toLinkResponse(link))
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (h *LinkHandler) create(w http.ResponseWriter, r *http.Request) {
+	body := createLinkRequest{Permission: string(models.LinkDownload)}
+	if err := decodeJSON(w, r, &body); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	if body.Path == "" {
+		http.Error(w, "Missing path", http.StatusBadRequest)
+		return
+	}
+	if containsPathTraversal(body.Path) {
+		http.Error(w, "Path traversal detected", http.StatusForbidden)
+		return
+	}
+
+	link, err := h.linkService.Create(currentUser(r), services.CreateLinkRequest{
+		Path:         body.Path,
+		Permission:   models.LinkPermission(body.Permission),
+		ExpiresIn:    time.Duration(body.ExpiresIn) * time.Second,
+		Password:     body.Password,
+		MaxDownloads: body.MaxDownloads,
+
+		MaxFileSize:       body.MaxFileSize,
+		AllowedExtensions: body.AllowedExtensions,
+	})
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toLinkResponse(link))
+}
+
+// PublicLinkHandler serves share links to anyone holding the token, without
+// authentication:
+//
+//	GET  /s/{token}[/sub]        list a directory as JSON or serve a file
+//	GET  /s/{token}[/sub]?zip=1  archive a directory (download links)
+//	GET  /s/{token}[/sub].zip    the same, unless sub.zip is a file
+//	POST /s/{token}              multipart upload (upload-into links)
+//
+// Uploaders may describe themselves with "name" and "email" form fields
+// sent before the files.
+//
+// Password-protected links take the password as HTTP Basic credentials
+// with any username.
+type PublicLinkHandler struct {
+	linkService *services.ShareLinkService
+}
+
+// NewPublicLinkHandler creates a new PublicLinkHandler.
+func NewPublicLinkHandler(linkService *services.ShareLinkService) *PublicLinkHandler {
+	return &PublicLinkHandler{linkService: linkService}
+}
+
+// linkInfo is what GET on an upload-into link reveals.
+type linkInfo struct {
+	Permission        string    `json:"permission"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	MaxFileSize       int64     `json:"maxFileSize,omitempty"`
+	AllowedExtensions []string  `json:"allowedExtensions,omitempty"`
+}
+
+// uploadResult reports one file sent through an upload-into link: the name
+// it was stored under, or the name it was sent with and why it was refused.
+type uploadResult struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+}
+
+func (h *PublicLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, linkBasePath)
+	token, sub, _ := strings.Cut(rest, "/")
+	sub = "/" + sub
+	zip := r.URL.Query().Get("zip") == "1"
+	if strings.HasSuffix(token, ".zip") {
+		token, zip = strings.TrimSuffix(token, ".zip"), true
+	}
+	if token == "" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if containsPathTraversal(sub) {
+		http.Error(w, "Path traversal detected", http.StatusForbidden)
+		return
+	}
+
+	_, password, _ := r.BasicAuth()
+	link, err := h.linkService.Open(token, password)
+	if err != nil {
+		if _, ok := err.(*errors.UnauthorizedError); ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="share link"`)
+		}
+		respondWithError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if trimmed, ok := strings.CutSuffix(sub, ".zip"); ok && !zip && h.linkService.IsDirectory(link, trimmed) {
+		// A directory's archive; a file named like one is served as is
+		sub, zip = trimmed, true
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, link, sub, zip)
+	case http.MethodPost:
+		h.upload(w, r, link)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PublicLinkHandler) get(w http.ResponseWriter, r *http.Request, link *models.ShareLink, sub string, zip bool) {
+	if link.Permission == models.LinkUpload {
+		writeJSON(w, http.StatusOK, linkInfo{
+			Permission:        string(link.Permission),
+			ExpiresAt:         link.ExpiresAt.UTC(),
+			MaxFileSize:       link.MaxFileSize,
+			AllowedExtensions: link.AllowedExtensions,
+		})
+		return
+	}
+
+	if zip {
+		stream, filename, err := h.linkService.Zip(link, sub)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		if stream == nil {
+			http.Error(w, "Not a directory", http.StatusBadRequest)
+			return
+		}
+		serveDownload(h.counting(w, r, link), r, stream, filename, "application/zip")
+		return
+	}
+
+	page, err := h.linkService.Browse(link, sub)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	if page != nil {
+		items := []fileItem{}
+		for _, f := range page.Files {
+			items = append(items, toFileItem(f))
+		}
+		writeJSON(w, http.StatusOK, items)
+		return
+	}
+
+	file, err := h.linkService.Download(link, sub)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	if file == nil {
+		http.Error(w, "Is a directory", http.StatusConflict)
+		return
+	}
+	w = h.counting(w, r, link)
+	if link.Permission == models.LinkView {
+		serveInline(w, r, file)
+		return
+	}
+	serveFile(w, r, file)
+}
+
+// counting wraps w so a GET uses up one of the link's downloads when its
+// response starts to carry content, whether the whole file or a range. A
+// link with no downloads left gets 410 instead.
+func (h *PublicLinkHandler) counting(w http.ResponseWriter, r *http.Request, link *models.ShareLink) http.ResponseWriter {
+	if r.Method != http.MethodGet {
+		return w
+	}
+	return &countingWriter{ResponseWriter: w, count: func() error { return h.linkService.CountDownload(link) }}
+}
+
+// countingWriter counts a download before the first successful status is
+// written, and drops the body if it could not be counted.
+type countingWriter struct {
+	http.ResponseWriter
+	count       func() error
+	wroteHeader bool
+	refused     bool
+}
+
+func (w *countingWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status == http.StatusOK || status == http.StatusPartialContent {
+		if err := w.count(); err != nil {
+			w.refused = true
+			// Drop what describes the content that is no longer sent
+			for _, key := range []string{"Content-Length", "Content-Range", "Content-Disposition", "Content-Security-Policy", "ETag", "Last-Modified"} {
+				w.Header().Del(key)
+			}
+			respondWithError(w.ResponseWriter, err)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.refused {
+		return 0, errDownloadRefused
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// errDownloadRefused stops copying a body the client was refused.
+var errDownloadRefused = fmt.Errorf("download limit reached")
+
+// upload stores each file part as it arrives, so parts need not be buffered.
+// Each refused file is reported with its error; when only some files are
+// stored the response is 207 Multi-Status, and when none are, the first
+// file's error.
+func (h *PublicLinkHandler) upload(w http.ResponseWriter, r *http.Request, link *models.ShareLink) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Invalid multipart request", http.StatusBadRequest)
+		return
+	}
+
+	results := []uploadResult{}
+	var uploader models.Uploader
+	var firstErr error
+	stored := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Invalid multipart request", http.StatusBadRequest)
+			return
+		}
+		if part.FileName() == "" {
+			switch part.FormName() {
+			case "name":
+				uploader.Name = readField(part)
+			case "email":
+				uploader.Email = readField(part)
+			}
+			continue
+		}
+		uploads, err := h.linkService.Upload(link, uploader, []models.UploadPart{&uploadPartWithName{name: part.FileName(), rc: part}})
+		if err == nil && len(uploads) == 0 {
+			err = errors.NewValidationError("filename", part.FileName(), "no usable file name")
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			results = append(results, uploadResult{Name: part.FileName(), Error: uploadErrorMessage(err)})
+		}
+		for _, upload := range uploads {
+			results = append(results, uploadResult{Name: upload.Filename, Size: upload.Size})
+			stored++
+		}
+	}
+
+	switch {
+	case firstErr == nil:
+		writeJSON(w, http.StatusCreated, results)
+	case stored == 0:
+		respondWithError(w, firstErr)
+	default:
+		writeJSON(w, http.StatusMultiStatus, results)
+	}
+}
+
+// uploadErrorMessage describes why a file was refused without revealing
+// anything about unexpected failures.
+func uploadErrorMessage(err error) string {
+	switch err.(type) {
+	case *errors.ValidationError, *errors.TooLargeError, *errors.QuotaExceededError, *errors.ConflictError:
+		return err.Error()
+	case *errors.ForbiddenError:
+		return "Forbidden"
+	}
+	return "Internal Server Error"
+}
+
+// maxFieldBytes caps how much of a form field readField keeps.
+const maxFieldBytes = 1 << 10
+
+// readField returns the value of a small multipart form field.
+func readField(part io.Reader) string {
+	value, _ := io.ReadAll(io.LimitReader(part, maxFieldBytes))
+	return string(value)
+}
+
+// serveInline sends a file for display in the browser rather than as an
+// attachment. nosniff keeps browsers from guessing a more dangerous type,
+// and the sandbox stops shared HTML from running script on this origin.
+func serveInline(w http.ResponseWriter, r *http.Request, file *models.FileContent) {
+	defer file.Content.Close()
+	contentType := mime.TypeByExtension(path.Ext(file.Name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", file.Name))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("ETag", fileETag(file))
+	http.ServeContent(w, r, file.Name, file.ModTime, file.Content)
+}
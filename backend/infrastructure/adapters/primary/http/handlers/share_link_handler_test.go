@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+// newPublicLinkTestHandler serves links to files in root, all owned by a
+// user who may do anything there.
+func newPublicLinkTestHandler(t *testing.T, root string) (*services.ShareLinkService, *PublicLinkHandler) {
+	t.Helper()
+	store, err := fs.NewLocalLinkStore(filepath.Join(t.TempDir(), "links.json"))
+	if err != nil {
+		t.Fatalf("Failed to create link store: %v", err)
+	}
+	repo := fs.NewLocalFileRepository(root)
+	authorizer := acl.AllowAllAuthorizer{}
+	service := services.NewShareLinkService(store, repo, authorizer, auth.PasswordHasher{Algorithm: auth.HashBcrypt},
+		services.NewListFilesService(repo, authorizer),
+		services.NewDownloadFileService(repo, authorizer),
+		services.NewDownloadZipService(repo, authorizer),
+		services.NewUploadService(repo, authorizer, models.ConflictRename))
+	return service, NewPublicLinkHandler(service)
+}
+
+func TestPublicLinkCountsDeliveredDownloads(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "empty.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	service, handler := newPublicLinkTestHandler(t, root)
+
+	type request struct {
+		method string
+		header http.Header
+		code   int
+	}
+	for _, tt := range []struct {
+		name     string
+		path     string
+		target   string
+		requests []request
+	}{
+		{"headers and validators are free", "docs/a.txt", "/s/%s", []request{
+			{http.MethodHead, nil, http.StatusOK},
+			{http.MethodGet, http.Header{"If-None-Match": {`*`}}, http.StatusNotModified},
+			{http.MethodGet, nil, http.StatusOK},
+			{http.MethodGet, nil, http.StatusGone},
+		}},
+		{"each range counts", "docs/a.txt", "/s/%s", []request{
+			{http.MethodGet, http.Header{"Range": {"bytes=0-3"}}, http.StatusPartialContent},
+			{http.MethodGet, http.Header{"Range": {"bytes=4-"}}, http.StatusGone},
+			{http.MethodGet, nil, http.StatusGone},
+		}},
+		{"empty file", "empty.txt", "/s/%s", []request{
+			{http.MethodGet, nil, http.StatusOK},
+			{http.MethodGet, nil, http.StatusGone},
+		}},
+		{"archive", "docs", "/s/%s.zip", []request{
+			{http.MethodHead, nil, http.StatusOK},
+			{http.MethodGet, nil, http.StatusOK},
+			{http.MethodGet, nil, http.StatusGone},
+		}},
+	} {
+		link, err := service.Create("alice", services.CreateLinkRequest{Path: tt.path, Permission: models.LinkDownload, MaxDownloads: 1})
+		if err != nil {
+			t.Fatalf("Failed to create link: %v", err)
+		}
+		for i, step := range tt.requests {
+			req := httptest.NewRequest(step.method, fmt.Sprintf(tt.target, link.Token), nil)
+			for key, values := range step.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != step.code {
+				t.Errorf("%s: request %d: expected %d, got %d", tt.name, i, step.code, rec.Code)
+			}
+			if rec.Code == http.StatusGone && rec.Header().Get("Content-Range") != "" {
+				t.Errorf("%s: request %d: expected no range in a refused response", tt.name, i)
+			}
+		}
+	}
+}
+
+func TestPublicLinkServesZipFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{"docs/foo.zip": "not an archive", "docs/sub/b.txt": "beta"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	service, handler := newPublicLinkTestHandler(t, root)
+	link, err := service.Create("alice", services.CreateLinkRequest{Path: "docs", Permission: models.LinkDownload})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+
+	for _, tt := range []struct {
+		target      string
+		contentType string
+		body        string
+	}{
+		{"/s/%s/foo.zip", "", "not an archive"},
+		{"/s/%s/sub.zip", "application/zip", ""},
+		{"/s/%s/sub?zip=1", "application/zip", ""},
+		{"/s/%s.zip", "application/zip", ""},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf(tt.target, link.Token), nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tt.target, rec.Code)
+			continue
+		}
+		if tt.contentType != "" && rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s: expected %s, got %s", tt.target, tt.contentType, rec.Header().Get("Content-Type"))
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Errorf("%s: expected %q, got %q", tt.target, tt.body, rec.Body.String())
+		}
+	}
+}
+
+func TestPublicLinkReportsRefusedUploads(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "inbox"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	service, handler := newPublicLinkTestHandler(t, root)
+	link, err := service.Create("alice", services.CreateLinkRequest{Path: "inbox", Permission: models.LinkUpload, AllowedExtensions: []string{"log"}})
+	if err != nil {
+		t.Fatalf("Failed to create link: %v", err)
+	}
+	upload := func(names ...string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for _, name := range names {
+			part, err := form.CreateFormFile("file", name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write([]byte("content of " + name))
+		}
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/s/"+link.Token, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := upload("a.log", "b.exe", "c.log")
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected 207 for a partly refused upload, got %d", rec.Code)
+	}
+	var results []uploadResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected a result per file, got %+v", results)
+	}
+	for i, want := range []struct {
+		name    string
+		refused bool
+	}{{"a.log", false}, {"b.exe", true}, {"c.log", false}} {
+		if results[i].Name != want.name || (results[i].Error != "") != want.refused {
+			t.Errorf("Result %d: expected %s refused=%v, got %+v", i, want.name, want.refused, results[i])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "inbox", "b.exe")); !os.IsNotExist(err) {
+		t.Errorf("Expected the refused file not to be stored, got %v", err)
+	}
+
+	if rec := upload("d.exe"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 when every file is refused, got %d", rec.Code)
+	}
+	if rec := upload("e.log"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201 when every file is stored, got %d", rec.Code)
+	}
+}
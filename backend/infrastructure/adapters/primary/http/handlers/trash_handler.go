@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// TrashHandler serves the recycle bin:
+//
+//	GET    /api/trash                 entries the caller may see
+//	POST   /api/trash/{id}/restore    {"to", "conflict"}; both optional
+//	DELETE /api/trash/{id}            purge one entry
+//	DELETE /api/trash                 purge every entry the caller may delete
+type TrashHandler struct {
+	trashService *services.TrashService
+}
+
+// NewTrashHandler creates a new TrashHandler.
+func NewTrashHandler(trashService *services.TrashService) *TrashHandler {
+	return &TrashHandler{trashService: trashService}
+}
+
+// trashEntry is the JSON shape of a trashed file or directory.
+type trashEntry struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	DeletedBy string    `json:"deletedBy"`
+	DeletedAt time.Time `json:"deletedAt"`
+	IsDir     bool      `json:"isDir"`
+	Size      int64     `json:"size"`
+}
+
+// restoreRequest is the body of a restore. An empty To restores to the
+// original path; Conflict is overwrite, rename or reject (the default).
+type restoreRequest struct {
+	To       string `json:"to"`
+	Conflict string `json:"conflict"`
+}
+
+func (h *TrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/trash"), "/")
+	id, restore := strings.CutSuffix(rest, "/restore")
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case rest == "" && r.Method == http.MethodDelete:
+		purged, err := h.trashService.Empty(currentUser(r))
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+	case restore && r.Method == http.MethodPost:
+		h.restore(w, r, id)
+	case rest != "" && !restore && r.Method == http.MethodDelete:
+		if err := h.trashService.Purge(currentUser(r), rest); err != nil {
+			respondWithError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TrashHandler) list(w http.ResponseWriter, r *http.Request) {
+	items, err := h.trashService.List(currentUser(r))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	entries := make([]trashEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, trashEntry{
+			ID:        item.ID,
+			Path:      item.OriginalPath,
+			DeletedBy: item.DeletedBy,
+			DeletedAt: item.DeletedAt.UTC(),
+			IsDir:     item.IsDir,
+			Size:      item.Size,
+		})
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (h *TrashHandler) restore(w http.ResponseWriter, r *http.Request, id string) {
+	body := restoreRequest{Conflict: string(models.ConflictReject)}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &body); err != nil {
+			respondWithError(w, err)
+			return
+		}
+	}
+	policy, err := models.ParseConflictPolicy(body.Conflict)
+	if err != nil {
+		respondWithError(w, errors.NewValidationError("conflict", body.Conflict, err.Error()))
+		return
+	}
+	dst := ""
+	if body.To != "" {
+		if containsPathTraversal(body.To) {
+			http.Error(w, "Path traversal detected", http.StatusForbidden)
+			return
+		}
+		dst = normalizePath(body.To)
+	}
+
+	restored, err := h.trashService.Restore(currentUser(r), id, dst, policy)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pathResponse{Path: restored})
+}
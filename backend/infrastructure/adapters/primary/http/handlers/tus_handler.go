@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+	// tusContentType is the only body type PATCH requests may carry.
+	tusContentType = "application/offset+octet-stream"
+	// tusChunkTimeout replaces the server's read and write timeouts for a
+	// PATCH, so one chunk may take longer than an ordinary request.
+	tusChunkTimeout = 10 * time.Minute
+)
+
+// TusHandler implements the tus 1.0 resumable upload protocol (core plus the
+// creation, termination and expiration extensions) under basePath:
+//
+//	OPTIONS basePath        protocol discovery
+//	POST    basePath        create an upload (Upload-Length, Upload-Metadata)
+//	HEAD    basePath/{id}   query the offset to resume from
+//	GET     basePath/{id}   the same status as JSON
+//	PATCH   basePath/{id}   append a chunk at Upload-Offset
+//	DELETE  basePath/{id}   abandon the upload
+//
+// Upload-Metadata must carry "filename"; an optional "path" metadata entry or
+// ?path= query names the destination directory.
+type TusHandler struct {
+	uploadService *services.ResumableUploadService
+	basePath      string
+}
+
+// NewTusHandler creates a TusHandler serving uploads below basePath.
+func NewTusHandler(uploadService *services.ResumableUploadService, basePath string) *TusHandler {
+	return &TusHandler{uploadService: uploadService, basePath: strings.TrimSuffix(basePath, "/")}
+}
+
+// uploadStatus is the JSON shape returned by GET on an upload.
+type uploadStatus struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	Offset    int64     `json:"offset"`
+	Length    int64     `json:"length"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (h *TusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Tus-Version", tusVersion)
+		w.Header().Set("Tus-Extension", tusExtensions)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// GET is a plain status query, not part of the tus protocol
+	if r.Method != http.MethodGet && r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		http.Error(w, "Unsupported tus version", http.StatusPreconditionFailed)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, h.basePath), "/")
+	if id == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.create(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodHead:
+		h.head(w, r, id)
+	case http.MethodGet:
+		h.status(w, r, id)
+	case http.MethodPatch:
+		h.patch(w, r, id)
+	case http.MethodDelete:
+		h.terminate(w, r, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TusHandler) create(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upload-Defer-Length") != "" {
+		http.Error(w, "Upload-Defer-Length is not supported", http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		http.Error(w, "Invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		http.Error(w, "Invalid Upload-Metadata", http.StatusBadRequest)
+		return
+	}
+	filename := metadata["filename"]
+	if filename == "" || strings.ContainsAny(filename, "/\\") || filename == "." || filename == ".." {
+		http.Error(w, "Upload-Metadata must include a plain filename", http.StatusBadRequest)
+		return
+	}
+	dir := metadata["path"]
+	if q := r.URL.Query().Get("path"); q != "" {
+		dir = q
+	}
+
+	session, err := h.uploadService.Create(currentUser(r), path.Join(normalizePath(dir), filename), length, metadata)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	w.Header().Set("Location", h.basePath+"/"+session.ID)
+	setUploadHeaders(w, session)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *TusHandler) head(w http.ResponseWriter, r *http.Request, id string) {
+	session, err := h.uploadService.Status(currentUser(r), id)
+	if err != nil {
+		// HEAD responses carry no body, so only the status code is sent
+		respondWithError(w, err)
+		return
+	}
+	setUploadHeaders(w, session)
+	w.Header().Set("Upload-Length", strconv.FormatInt(session.Length, 10))
+	if len(session.Metadata) > 0 {
+		w.Header().Set("Upload-Metadata", formatUploadMetadata(session.Metadata))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *TusHandler) status(w http.ResponseWriter, r *http.Request, id string) {
+	session, err := h.uploadService.Status(currentUser(r), id)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, uploadStatus{
+		ID:        session.ID,
+		Path:      session.Path,
+		Offset:    session.Offset,
+		Length:    session.Length,
+		ExpiresAt: session.ExpiresAt.UTC(),
+	})
+}
+
+func (h *TusHandler) patch(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Header.Get("Content-Type") != tusContentType {
+		http.Error(w, "Content-Type must be "+tusContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "Invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	// Not every ResponseWriter supports deadlines; the server timeouts apply then
+	rc := http.NewResponseController(w)
+	deadline := time.Now().Add(tusChunkTimeout)
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+
+	session, err := h.uploadService.Append(currentUser(r), id, offset, r.Body)
+	if err != nil {
+		if _, conflict := err.(*errors.ConflictError); !conflict && session != nil {
+			// The chunk was cut short; the client resumes from the stored offset
+			setUploadHeaders(w, session)
+		}
+		respondWithError(w, err)
+		return
+	}
+	setUploadHeaders(w, session)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TusHandler) terminate(w http.ResponseWriter, r *http.Request, id string) {
+	if err := h.uploadService.Terminate(currentUser(r), id); err != nil {
+		respondWithError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setUploadHeaders reports the session's offset and expiry.
+func setUploadHeaders(w http.ResponseWriter, session *models.UploadSession) {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	if !session.Complete() {
+		w.Header().Set("Upload-Expires", session.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseUploadMetadata decodes "key base64value,key2 base64value2".
+// A key may appear without a value.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.NewValidationError("Upload-Metadata", header, "empty key")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, errors.NewValidationError("Upload-Metadata", header, "value of "+key+" is not base64")
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// formatUploadMetadata encodes metadata in the Upload-Metadata header format.
+func formatUploadMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(metadata[key])))
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func newTusTestHandler(t *testing.T) (*TusHandler, string) {
+	t.Helper()
+	root := t.TempDir()
+	store, err := fs.NewLocalUploadStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create upload store: %v", err)
+	}
+	service := services.NewResumableUploadService(store, fs.NewLocalFileRepository(root), acl.AllowAllAuthorizer{}, models.ConflictOverwrite, time.Hour)
+	return NewTusHandler(service, "/api/uploads"), root
+}
+
+func tusRequest(handler http.Handler, method, target string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTusUploadLifecycle(t *testing.T) {
+	handler, root := newTusTestHandler(t)
+	content := "the quick brown fox"
+
+	rec := tusRequest(handler, http.MethodOptions, "/api/uploads", nil, "")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Tus-Version") != "1.0.0" {
+		t.Fatalf("Expected discovery response, got %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Tus-Extension"), "creation") {
+		t.Errorf("Expected creation extension, got %q", rec.Header().Get("Tus-Extension"))
+	}
+
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("fox.txt")) +
+		",path " + base64.StdEncoding.EncodeToString([]byte("animals"))
+	rec = tusRequest(handler, http.MethodPost, "/api/uploads", map[string]string{
+		"Upload-Length":   "19",
+		"Upload-Metadata": metadata,
+	}, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 on create, got %d: %s", rec.Code, rec.Body.String())
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/api/uploads/") {
+		t.Fatalf("Unexpected Location %q", location)
+	}
+
+	steps := []struct {
+		method     string
+		headers    map[string]string
+		body       string
+		want       int
+		wantOffset string
+	}{
+		{http.MethodHead, nil, "", http.StatusOK, "0"},
+		{http.MethodPatch, map[string]string{"Upload-Offset": "0"}, content[:9], http.StatusUnsupportedMediaType, ""},
+		{http.MethodPatch, map[string]string{"Upload-Offset": "0", "Content-Type": tusContentType}, content[:9], http.StatusNoContent, "9"},
+		{http.MethodPatch, map[string]string{"Upload-Offset": "3", "Content-Type": tusContentType}, content[3:], http.StatusConflict, ""},
+		{http.MethodHead, nil, "", http.StatusOK, "9"},
+		{http.MethodPatch, map[string]string{"Upload-Offset": "9", "Content-Type": tusContentType}, content[9:], http.StatusNoContent, "19"},
+		{http.MethodHead, nil, "", http.StatusNotFound, ""},
+	}
+	for i, step := range steps {
+		rec := tusRequest(handler, step.method, location, step.headers, step.body)
+		if rec.Code != step.want {
+			t.Fatalf("Step %d (%s): expected status %d, got %d: %s", i, step.method, step.want, rec.Code, rec.Body.String())
+		}
+		if step.wantOffset != "" && rec.Header().Get("Upload-Offset") != step.wantOffset {
+			t.Errorf("Step %d (%s): expected Upload-Offset %s, got %q", i, step.method, step.wantOffset, rec.Header().Get("Upload-Offset"))
+		}
+		if rec.Header().Get("Tus-Resumable") != "1.0.0" {
+			t.Errorf("Step %d (%s): missing Tus-Resumable header", i, step.method)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "animals", "fox.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read committed file: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %q, got %q", content, data)
+	}
+}
+
+func TestTusTerminateAndValidation(t *testing.T) {
+	handler, root := newTusTestHandler(t)
+	filename := "filename " + base64.StdEncoding.EncodeToString([]byte("big.bin"))
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"missing length", map[string]string{"Upload-Metadata": filename}, http.StatusBadRequest},
+		{"missing filename", map[string]string{"Upload-Length": "5"}, http.StatusBadRequest},
+		{"filename with slash", map[string]string{"Upload-Length": "5", "Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("../x"))}, http.StatusBadRequest},
+		{"deferred length", map[string]string{"Upload-Defer-Length": "1", "Upload-Metadata": filename}, http.StatusBadRequest},
+		{"wrong protocol version", map[string]string{"Tus-Resumable": "0.2.0", "Upload-Length": "5", "Upload-Metadata": filename}, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		rec := tusRequest(handler, http.MethodPost, "/api/uploads", tt.headers, "")
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.want, rec.Code)
+		}
+	}
+
+	rec := tusRequest(handler, http.MethodPost, "/api/uploads", map[string]string{"Upload-Length": "5", "Upload-Metadata": filename}, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 on create, got %d", rec.Code)
+	}
+	location := rec.Header().Get("Location")
+
+	status := httptest.NewRecorder()
+	handler.ServeHTTP(status, httptest.NewRequest(http.MethodGet, location, nil))
+	if status.Code != http.StatusOK || !strings.Contains(status.Body.String(), `"length":5`) {
+		t.Errorf("Expected JSON status, got %d: %s", status.Code, status.Body.String())
+	}
+
+	if rec := tusRequest(handler, http.MethodDelete, location, nil, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204 on terminate, got %d", rec.Code)
+	}
+	if rec := tusRequest(handler, http.MethodPatch, location, map[string]string{"Upload-Offset": "0", "Content-Type": tusContentType}, "hello"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after terminate, got %d", rec.Code)
+	}
+	if _, err := os.Stat(filepath.Join(root, "big.bin")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file after terminate, got %v", err)
+	}
+}
@@ -36,6 +36,12 @@ func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Optional destination path can be provided via query param or multipart field 'path'
 	destPrefix := strings.TrimPrefix(r.URL.Query().Get("path"), "/")
 
+	// Refuse a body that cannot fit before reading it
+	if err := h.uploadService.CheckSpace(currentUser(r), "/"+destPrefix, r.ContentLength); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
 	reader, err := r.MultipartReader()
 	if err != nil {
 		http.Error(w, "Invalid multipart request", http.StatusBadRequest)
@@ -73,9 +79,9 @@ func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		parts = append(parts, &uploadPartWithName{name: filename, rc: part})
 	}
 
-	uploads, err := h.uploadService.Execute(parts)
+	uploads, err := h.uploadService.Execute(currentUser(r), parts)
 	if err != nil {
-		http.Error(w, "Upload processing failed", http.StatusInternalServerError)
+		respondWithError(w, err)
 		return
 	}
 
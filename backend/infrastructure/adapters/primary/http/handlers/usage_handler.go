@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+)
+
+// UsageHandler reports storage use and quotas:
+//
+//	GET /api/usage    the caller's uploads and the areas they may list
+type UsageHandler struct {
+	quotaService *services.QuotaService
+}
+
+// NewUsageHandler creates a new UsageHandler.
+func NewUsageHandler(quotaService *services.QuotaService) *UsageHandler {
+	return &UsageHandler{quotaService: quotaService}
+}
+
+// usageEntry is the JSON shape of a usage. A zero limit means none.
+type usageEntry struct {
+	Name  string `json:"name"`
+	Used  int64  `json:"used"`
+	Limit int64  `json:"limit"`
+}
+
+// usageResponse is the body of GET /api/usage. Storage lists the root
+// directory, or each share.
+type usageResponse struct {
+	User    usageEntry   `json:"user"`
+	Storage []usageEntry `json:"storage"`
+}
+
+func (h *UsageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	own, areas, err := h.quotaService.Usage(currentUser(r))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	response := usageResponse{
+		User:    usageEntry{Name: own.Name, Used: own.Used, Limit: own.Limit},
+		Storage: make([]usageEntry, 0, len(areas)),
+	}
+	for _, area := range areas {
+		response.Storage = append(response.Storage, usageEntry{Name: area.Name, Used: area.Used, Limit: area.Limit})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
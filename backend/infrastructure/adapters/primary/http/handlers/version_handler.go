@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// VersionHandler serves the earlier contents of a file, named by ?path=:
+//
+//	GET  /api/versions?path=               versions, newest first
+//	GET  /api/versions/{id}?path=          download one version
+//	POST /api/versions/{id}/restore?path=  {"conflict"}; optional
+type VersionHandler struct {
+	versionService *services.VersionService
+}
+
+// NewVersionHandler creates a new VersionHandler.
+func NewVersionHandler(versionService *services.VersionService) *VersionHandler {
+	return &VersionHandler{versionService: versionService}
+}
+
+// versionEntry is the JSON shape of a file version.
+type versionEntry struct {
+	ID         string    `json:"id"`
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	Modified   time.Time `json:"modified"`
+	ReplacedAt time.Time `json:"replacedAt"`
+}
+
+// versionRestoreRequest is the body of a restore. Conflict is overwrite
+// (the default), rename or reject.
+type versionRestoreRequest struct {
+	Conflict string `json:"conflict"`
+}
+
+func (h *VersionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reqPath := r.URL.Query().Get("path")
+	if reqPath == "" {
+		respondWithError(w, errors.NewValidationError("path", reqPath, "is required"))
+		return
+	}
+	if containsPathTraversal(reqPath) {
+		http.Error(w, "Path traversal detected", http.StatusForbidden)
+		return
+	}
+	reqPath = normalizePath(reqPath)
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/versions"), "/")
+	id, restore := strings.CutSuffix(rest, "/restore")
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		h.list(w, r, reqPath)
+	case rest != "" && !restore && r.Method == http.MethodGet:
+		file, err := h.versionService.Download(currentUser(r), reqPath, rest)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		serveFile(w, r, file)
+	case restore && r.Method == http.MethodPost:
+		h.restore(w, r, reqPath, id)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *VersionHandler) list(w http.ResponseWriter, r *http.Request, reqPath string) {
+	versions, err := h.versionService.List(currentUser(r), reqPath)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	entries := make([]versionEntry, 0, len(versions))
+	for _, version := range versions {
+		entries = append(entries, versionEntry{
+			ID:         version.ID,
+			Path:       version.Path,
+			Size:       version.Size,
+			Modified:   version.Modified.UTC(),
+			ReplacedAt: version.ReplacedAt.UTC(),
+		})
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (h *VersionHandler) restore(w http.ResponseWriter, r *http.Request, reqPath, id string) {
+	body := versionRestoreRequest{Conflict: string(models.ConflictOverwrite)}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &body); err != nil {
+			respondWithError(w, err)
+			return
+		}
+	}
+	policy, err := models.ParseConflictPolicy(body.Conflict)
+	if err != nil {
+		respondWithError(w, errors.NewValidationError("conflict", body.Conflict, err.Error()))
+		return
+	}
+
+	restored, err := h.versionService.Restore(currentUser(r), reqPath, id, policy)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, pathResponse{Path: restored})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func TestVersionHandler(t *testing.T) {
+	root := t.TempDir()
+	repo := fs.NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, content := range []string{"one", "two"} {
+		if _, _, err := repo.WriteFile("/docs/a.txt", io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	handler := NewVersionHandler(services.NewVersionService(repo, acl.AllowAllAuthorizer{}))
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api/versions?path=docs/a.txt", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing versions, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	var versions []versionEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &versions); err != nil {
+		t.Fatalf("Failed to decode versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Path != "/docs/a.txt" || versions[0].Size != 3 {
+		t.Fatalf("Expected one version of /docs/a.txt, got %+v", versions)
+	}
+	id := versions[0].ID
+
+	rec = serve(http.MethodGet, "/api/versions/"+id+"?path=docs/a.txt", "")
+	if rec.Code != http.StatusOK || rec.Body.String() != "one" {
+		t.Errorf("Expected the version's content, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	steps := []struct {
+		method, target, body string
+		want                 int
+		restored             string // Path in the response, if any
+	}{
+		{http.MethodGet, "/api/versions", "", http.StatusBadRequest, ""},
+		{http.MethodGet, "/api/versions?path=../etc/passwd", "", http.StatusForbidden, ""},
+		{http.MethodGet, "/api/versions/20000101T000000.000000000-00000000?path=docs/a.txt", "", http.StatusNotFound, ""},
+		{http.MethodDelete, "/api/versions/" + id + "?path=docs/a.txt", "", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/api/versions/" + id + "/restore?path=docs/a.txt", `{"conflict": "sideways"}`, http.StatusBadRequest, ""},
+		{http.MethodPost, "/api/versions/" + id + "/restore?path=docs/a.txt", `{"conflict": "reject"}`, http.StatusConflict, ""},
+		{http.MethodPost, "/api/versions/" + id + "/restore?path=docs/a.txt", `{"conflict": "rename"}`, http.StatusOK, "/docs/a (1).txt"},
+		{http.MethodPost, "/api/versions/" + id + "/restore?path=docs/a.txt", "", http.StatusOK, "/docs/a.txt"},
+	}
+	for i, step := range steps {
+		rec := serve(step.method, step.target, step.body)
+		if rec.Code != step.want {
+			t.Fatalf("step %d %s %s: expected %d, got %d (%s)", i, step.method, step.target, step.want, rec.Code, rec.Body.String())
+		}
+		if step.restored == "" {
+			continue
+		}
+		var resp pathResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Path != step.restored {
+			t.Errorf("step %d: expected %q to be restored, got %q %v", i, step.restored, resp.Path, err)
+		}
+	}
+
+	for name, want := range map[string]string{"a.txt": "one", "a (1).txt": "one"} {
+		if data, err := os.ReadFile(filepath.Join(root, "docs", name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q %v", name, want, data, err)
+		}
+	}
+}
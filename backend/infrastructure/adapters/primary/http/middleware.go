@@ -8,6 +8,43 @@ import (
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
+// contextKey namespaces values this package stores in a request context.
+type contextKey string
+
+// userContextKey holds the *ports.JWTClaims of the authenticated caller.
+const userContextKey contextKey = "user"
+
+// loggerContextKey holds the request-scoped ports.Logger.
+const loggerContextKey contextKey = "logger"
+
+// bearerPrefix is the Authorization scheme carrying a JWT token.
+const bearerPrefix = "Bearer "
+
+// WithUser returns a copy of ctx carrying the authenticated caller.
+func WithUser(ctx context.Context, claims *ports.JWTClaims) context.Context {
+	return context.WithValue(ctx, userContextKey, claims)
+}
+
+// UserFromContext returns the claims stored by the auth middleware, if any.
+func UserFromContext(ctx context.Context) (*ports.JWTClaims, bool) {
+	claims, ok := ctx.Value(userContextKey).(*ports.JWTClaims)
+	return claims, ok
+}
+
+// WithLogger returns a copy of ctx carrying a request-scoped logger.
+func WithLogger(ctx context.Context, logger ports.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext returns the logger stored by the logging middleware,
+// which tags every line with the request ID, or fallback if there is none.
+func LoggerFromContext(ctx context.Context, fallback ports.Logger) ports.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(ports.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
 // AuthMiddleware returns an HTTP middleware that enforces Basic Auth.
 // It uses the domain.AuthProvider port to validate credentials.
 func AuthMiddleware(authProvider ports.AuthProvider) func(next http.HandlerFunc) http.HandlerFunc {
@@ -19,7 +56,7 @@ func AuthMiddleware(authProvider ports.AuthProvider) func(next http.HandlerFunc)
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
-			next(w, r)
+			next(w, r.WithContext(WithUser(r.Context(), &ports.JWTClaims{Username: user})))
 		}
 	}
 }
@@ -37,13 +74,12 @@ func JWTMiddleware(jwtProvider ports.JWTProvider) func(next http.HandlerFunc) ht
 			}
 
 			// The expected format is "Bearer <token>"
-			const prefix = "Bearer "
-			if !strings.HasPrefix(authHeader, prefix) {
+			if !strings.HasPrefix(authHeader, bearerPrefix) {
 				http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
 				return
 			}
 
-			tokenString := authHeader[len(prefix):]
+			tokenString := authHeader[len(bearerPrefix):]
 
 			// Validate the token
 			claims, err := jwtProvider.ValidateToken(tokenString)
@@ -52,11 +88,32 @@ func JWTMiddleware(jwtProvider ports.JWTProvider) func(next http.HandlerFunc) ht
 				return
 			}
 
+			// Refresh tokens are only good for obtaining new access tokens
+			if claims.TokenType != ports.TokenTypeAccess {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
 			// Add the claims to the request context
-			ctx := context.WithValue(r.Context(), "user", claims)
-			r = r.WithContext(ctx)
+			next(w, r.WithContext(WithUser(r.Context(), claims)))
+		}
+	}
+}
 
-			next(w, r)
+// BasicOrJWTMiddleware accepts either a Bearer token or Basic credentials.
+// A request carrying a Bearer token is judged by the token alone.
+func BasicOrJWTMiddleware(authProvider ports.AuthProvider, jwtProvider ports.JWTProvider) func(next http.HandlerFunc) http.HandlerFunc {
+	basic := AuthMiddleware(authProvider)
+	bearer := JWTMiddleware(jwtProvider)
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		viaBasic := basic(next)
+		viaBearer := bearer(next)
+		return func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasPrefix(r.Header.Get("Authorization"), bearerPrefix) {
+				viaBearer(w, r)
+				return
+			}
+			viaBasic(w, r)
 		}
 	}
-}
\ No newline at end of file
+}
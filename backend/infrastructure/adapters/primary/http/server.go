@@ -2,12 +2,16 @@ package xhttp
 
 import (
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	_ "embed"
 
@@ -15,15 +19,42 @@ import (
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
+// route is an extra endpoint registered through Handle or HandlePublic.
+type route struct {
+	pattern string
+	handler http.Handler
+	public  bool // Whether the route bypasses authentication
+}
+
 type Server struct {
-	port          string
-	tlsGenerator  ports.TLSCertGenerator
-	logger        ports.Logger
-	rootHandler   http.Handler
-	uploadHandler http.Handler
-	httpServer    *http.Server
-	staticDir     string // Directory to serve static files from
-	useTLS        bool   // Whether to use TLS/HTTPS
+	port            string
+	tlsGenerator    ports.TLSCertGenerator
+	logger          ports.Logger
+	rootHandler     http.Handler
+	uploadHandler   http.Handler
+	httpServer      *http.Server
+	staticDir       string              // Directory to serve static files from
+	useTLS          bool                // Whether to use TLS/HTTPS
+	certs           *certReloader       // Serves the current certificate once TLS is started
+	certSource      ports.TLSCertSource // Replaces tlsGenerator when set, e.g. for ACME
+	redirectAddr    string              // Plain HTTP listener redirecting to HTTPS, if any
+	hstsMaxAge      time.Duration       // Strict-Transport-Security max-age; zero disables it
+	listener        net.Listener        // Main listener, bound by Listen
+	sides           []sideListener      // Metrics and redirect listeners, bound by Listen
+	shutdownTimeout time.Duration       // How long Serve waits for requests when stopping
+
+	// authMiddleware guards protected routes; nil leaves everything open
+	authMiddleware func(next http.HandlerFunc) http.HandlerFunc
+	publicHealth   bool // Whether /health, /ready and /version bypass authentication
+	publicStatic   bool // Whether static assets and API docs bypass authentication
+
+	routes []route // Extra endpoints beyond the core file routes
+
+	metrics        ports.Metrics // Records every request when set
+	metricsAddr    string        // Separate listen address for metricsHandler, if any
+	metricsHandler http.Handler
+
+	reloadHooks []func() error // Run in order by Reload, e.g. on SIGHUP
 }
 
 func NewServer(
@@ -35,7 +66,6 @@ func NewServer(
 	server := &http.Server{
 		Addr: ":" + port,
 		TLSConfig: &tls.Config{
-			Certificates:             nil,
 			MinVersion:               tls.VersionTLS13,
 			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
 			PreferServerCipherSuites: true,
@@ -46,108 +76,466 @@ func NewServer(
 	}
 
 	return &Server{
-		port:          port,
-		tlsGenerator:  tlsGen,
-		logger:        logger,
-		rootHandler:   rootHandler,
-		uploadHandler: uploadHandler,
-		httpServer:    server,
+		port:            port,
+		tlsGenerator:    tlsGen,
+		logger:          logger,
+		rootHandler:     rootHandler,
+		uploadHandler:   uploadHandler,
+		httpServer:      server,
+		publicHealth:    true,
+		publicStatic:    true,
+		hstsMaxAge:      DefaultHSTSMaxAge,
+		shutdownTimeout: DefaultShutdownTimeout,
 	}
 }
 
-// Start initializes TLS and starts listening with graceful shutdown.
-// SetStaticFileServer configures the server to serve static files from the specified directory.
-// If the directory doesn't exist, this is a no-op.
 // SetStaticFileServer configures the server to serve static files from the specified directory.
 // If the directory doesn't exist, this is a no-op.
 func (s *Server) SetStaticFileServer(dir string) {
 	s.staticDir = dir
 }
 
+// SetListenAddr changes the host:port the server binds, e.g. "127.0.0.1:8443".
+func (s *Server) SetListenAddr(addr string) {
+	s.httpServer.Addr = addr
+}
+
+// SetTimeouts bounds reading a request, writing a response (zero for no
+// limit) and waiting for requests to finish on shutdown.
+func (s *Server) SetTimeouts(read, write, shutdown time.Duration) {
+	s.httpServer.ReadTimeout = read
+	s.httpServer.WriteTimeout = write
+	s.shutdownTimeout = shutdown
+}
+
+// SetMaxHeaderBytes limits the size of request headers.
+func (s *Server) SetMaxHeaderBytes(n int) {
+	s.httpServer.MaxHeaderBytes = n
+}
+
 // ConfigureTLS enables or disables TLS/HTTPS for the server
 func (s *Server) ConfigureTLS(enableTLS bool) {
 	s.useTLS = enableTLS
 }
 
-func (s *Server) Start() error {
-	mux := s.registerRoutes()
+// SetCertSource obtains certificates from source instead of the generator
+// passed to NewServer. The HTTP redirect listener also answers the source's
+// challenges, so ACME HTTP-01 needs SetHTTPRedirect(":80").
+func (s *Server) SetCertSource(source ports.TLSCertSource) {
+	s.certSource = source
+}
 
-	// If static directory is set and exists, serve static files
-	if s.staticDir != "" {
-		if _, err := os.Stat(s.staticDir); !os.IsNotExist(err) {
-			fs := http.FileServer(http.Dir(s.staticDir))
-			mux.Handle("/", fs)
-			s.logger.Info("Serving static files from", "directory", s.staticDir)
-		} else {
-			s.logger.Warn("Static directory does not exist, not serving static files", "directory", s.staticDir)
-		}
+// SetHTTPRedirect adds a plain HTTP listener at addr (e.g. ":80") that
+// permanently redirects every request to HTTPS. It is only started while
+// TLS is enabled; "" disables it.
+func (s *Server) SetHTTPRedirect(addr string) {
+	s.redirectAddr = addr
+}
+
+// SetHSTS sets the Strict-Transport-Security max-age sent on HTTPS
+// responses; zero disables the header.
+func (s *Server) SetHSTS(maxAge time.Duration) {
+	s.hstsMaxAge = maxAge
+}
+
+// ReplaceCertGenerator serves certificates from generator from now on. Once
+// TLS is running the new certificate is loaded first, and an error leaves
+// the current one in use. It has no effect while a cert source is set.
+func (s *Server) ReplaceCertGenerator(generator ports.TLSCertGenerator) error {
+	if s.certs == nil {
+		s.tlsGenerator = generator
+		return nil
+	}
+	if err := s.certs.replace(generator); err != nil {
+		return err
 	}
+	s.tlsGenerator = generator
+	return nil
+}
 
-	s.httpServer.Handler = mux
+// OnReload registers fn to run when the server is asked to reload its
+// configuration. A hook that fails should leave its old settings in place.
+func (s *Server) OnReload(fn func() error) {
+	s.reloadHooks = append(s.reloadHooks, fn)
+}
 
-	// Only generate and configure TLS if enabled
-	if s.useTLS {
-		certPEM, keyPEM, err := s.tlsGenerator.GenerateCert()
-		if err != nil {
-			return fmt.Errorf("failed to generate TLS certificate: %w", err)
+// Reload runs the reload hooks in registration order, stopping at the first
+// failure. Requests in flight are not interrupted.
+func (s *Server) Reload() error {
+	s.logger.Info("Reloading configuration")
+	for _, hook := range s.reloadHooks {
+		if err := hook(); err != nil {
+			s.logger.Error("Reload failed, keeping the previous configuration", "error", err)
+			return err
 		}
+	}
+	s.logger.Info("Configuration reloaded")
+	return nil
+}
 
-		cert, err := tls.X509KeyPair(certPEM, keyPEM)
-		if err != nil {
-			return fmt.Errorf("failed to parse TLS key pair: %w", err)
-		}
+// ConfigureAuth selects the middleware that guards the API routes.
+// The providers required by the mode must be non-nil.
+func (s *Server) ConfigureAuth(mode ports.AuthMode, authProvider ports.AuthProvider, jwtProvider ports.JWTProvider) error {
+	if (mode == ports.AuthModeBasic || mode == ports.AuthModeBoth) && authProvider == nil {
+		return fmt.Errorf("auth mode %q requires an auth provider", mode)
+	}
+	if mode.UsesJWT() && jwtProvider == nil {
+		return fmt.Errorf("auth mode %q requires a JWT provider", mode)
+	}
 
-		s.httpServer.TLSConfig.Certificates = []tls.Certificate{cert}
+	switch mode {
+	case ports.AuthModeNone:
+		s.authMiddleware = nil
+	case ports.AuthModeBasic:
+		s.authMiddleware = AuthMiddleware(authProvider)
+	case ports.AuthModeJWT:
+		s.authMiddleware = JWTMiddleware(jwtProvider)
+	case ports.AuthModeBoth:
+		s.authMiddleware = BasicOrJWTMiddleware(authProvider, jwtProvider)
+	default:
+		return fmt.Errorf("unknown auth mode %q", mode)
 	}
+	return nil
+}
+
+// SetPublicAccess controls whether the probe endpoints (/health, /ready,
+// /version) and the static assets (frontend files and API docs) stay
+// reachable without credentials.
+// The /api routes stay protected unless registered with HandlePublic.
+func (s *Server) SetPublicAccess(health, static bool) {
+	s.publicHealth = health
+	s.publicStatic = static
+}
+
+// SetMetrics records the method, route pattern, status and latency of every request.
+func (s *Server) SetMetrics(metrics ports.Metrics) {
+	s.metrics = metrics
+}
 
-	// Create context for shutdown
+// ServeMetrics exposes handler at /metrics on its own plain HTTP listener at
+// addr (e.g. "127.0.0.1:9090"), so metrics stay off the public port. The
+// listener has no authentication; bind it to a private interface.
+func (s *Server) ServeMetrics(addr string, handler http.Handler) {
+	s.metricsAddr = addr
+	s.metricsHandler = handler
+}
+
+// Handle registers an extra endpoint guarded by the configured authentication.
+// Patterns use http.ServeMux syntax, including an optional method such as
+// "POST /api/directories"; a pattern ending in "/" matches every path below it.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.routes = append(s.routes, route{pattern: pattern, handler: handler})
+}
+
+// HandlePublic registers an extra endpoint that bypasses authentication.
+func (s *Server) HandlePublic(pattern string, handler http.Handler) {
+	s.routes = append(s.routes, route{pattern: pattern, handler: handler, public: true})
+}
+
+// TLSCheck returns a readiness check that fails while TLS is enabled but no
+// certificate has been loaded yet.
+func (s *Server) TLSCheck() ports.ReadinessCheck {
+	return tlsCheck{server: s}
+}
+
+// tlsCheck reports whether the server's TLS material is in place.
+type tlsCheck struct {
+	server *Server
+}
+
+func (c tlsCheck) Name() string { return "tls" }
+
+func (c tlsCheck) Check() error {
+	if !c.server.useTLS || c.server.certSource != nil {
+		// On-demand sources obtain certificates during the first handshake
+		return nil
+	}
+	if c.server.certs == nil || !c.server.certs.loaded() {
+		return fmt.Errorf("certificate not loaded")
+	}
+	return nil
+}
+
+// Start listens, serves until SIGINT or SIGTERM, then shuts down gracefully.
+// SIGHUP reloads the configuration through the OnReload hooks.
+func (s *Server) Start() error {
+	if err := s.Listen(); err != nil {
+		return err
+	}
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Start server in goroutine
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
 	go func() {
-		// Start with the appropriate protocol
-		protocol := "http"
-		if s.useTLS {
-			protocol = "https"
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				_ = s.Reload()
+			}
 		}
-		s.logger.Info("Server starting",
-			"protocol", protocol,
-			"address", "0.0.0.0:"+s.port)
+	}()
+	return s.Serve(ctx)
+}
 
-		var err error
+// Listen loads the TLS certificate and binds the main and side listeners,
+// so configuration and port errors surface before anything is served.
+func (s *Server) Listen() error {
+	s.httpServer.Handler = s.buildHandler()
+
+	// Only load and configure TLS if enabled; a bad certificate fails startup
+	if s.useTLS && s.certSource != nil {
+		s.httpServer.TLSConfig.GetCertificate = s.certSource.GetCertificate
+		s.httpServer.TLSConfig.NextProtos = append([]string{"h2", "http/1.1"}, s.certSource.NextProtos()...)
+	} else if s.useTLS {
+		certs := newCertReloader(s.tlsGenerator, s.logger, CertCheckInterval)
+		if err := certs.load(); err != nil {
+			return err
+		}
+		s.certs = certs
+		s.httpServer.TLSConfig.GetCertificate = certs.GetCertificate
+	}
+
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
+	s.listener = listener
+	s.sides = nil
+
+	if s.metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", s.metricsHandler)
+		if err := s.listenSide("Metrics", s.metricsAddr, mux); err != nil {
+			s.closeListeners()
+			return err
+		}
+	}
+	if s.useTLS && s.redirectAddr != "" {
+		port := fmt.Sprint(listener.Addr().(*net.TCPAddr).Port)
+		handler := httpsRedirect(port)
+		if s.certSource != nil {
+			handler = s.certSource.HTTPHandler(handler)
+		}
+		if err := s.listenSide("HTTP redirect", s.redirectAddr, handler); err != nil {
+			s.closeListeners()
+			return err
+		}
+	}
+	return nil
+}
+
+// Addr returns the address the main listener is bound to, or nil before Listen.
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// RedirectAddr returns the address of the HTTP redirect listener, or nil if
+// there is none.
+func (s *Server) RedirectAddr() net.Addr {
+	for _, side := range s.sides {
+		if side.name == "HTTP redirect" {
+			return side.listener.Addr()
+		}
+	}
+	return nil
+}
+
+// Serve handles requests on the listeners opened by Listen until ctx is
+// done or a listener fails, then shuts everything down gracefully.
+func (s *Server) Serve(ctx context.Context) error {
+	if s.listener == nil {
+		return fmt.Errorf("server is not listening")
+	}
+	errs := make(chan error, 1+len(s.sides))
+
+	protocol := "http"
+	if s.useTLS {
+		protocol = "https"
+	}
+	s.logger.Info("Server starting", "protocol", protocol, "address", s.listener.Addr().String())
+	go func() {
 		if s.useTLS {
-			err = s.httpServer.ListenAndServeTLS("", "")
+			errs <- s.httpServer.ServeTLS(s.listener, "", "")
 		} else {
-			err = s.httpServer.ListenAndServe()
-		}
-		if err != nil && err != http.ErrServerClosed {
-			s.logger.Fatal("Server failed", "error", err)
+			errs <- s.httpServer.Serve(s.listener)
 		}
 	}()
+	for _, side := range s.sides {
+		s.logger.Info(side.name+" listener starting", "address", side.listener.Addr().String())
+		go func() {
+			errs <- side.server.Serve(side.listener)
+		}()
+	}
 
-	// Wait for interrupt signal
-	<-ctx.Done()
-	s.logger.Info("Shutdown signal received, gracefully stopping server...")
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		s.logger.Info("Shutdown signal received, gracefully stopping server...")
+	case serveErr = <-errs:
+		s.logger.Error("Server failed", "error", serveErr)
+	}
 
 	// Give active connections time to finish
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
+	for _, side := range s.sides {
+		_ = side.server.Shutdown(shutdownCtx)
+	}
 	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
 		s.logger.Error("Server forced to shutdown", "error", err)
 		return err
 	}
+	if serveErr != nil {
+		return serveErr
+	}
 
 	s.logger.Info("Server exited gracefully")
 	return nil
 }
 
-// registerRoutes maps URL paths to handlers and returns a mux.
-// loggingMiddleware adds logging for all requests
+// sideListener is a plain HTTP listener running next to the main server.
+type sideListener struct {
+	name     string
+	server   *http.Server
+	listener net.Listener
+}
+
+// listenSide binds a plain HTTP listener for handler at addr.
+func (s *Server) listenSide(name, addr string, handler http.Handler) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s for %s: %w", addr, strings.ToLower(name), err)
+	}
+	s.sides = append(s.sides, sideListener{
+		name: name,
+		server: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: s.httpServer.ReadTimeout,
+		},
+		listener: listener,
+	})
+	return nil
+}
+
+// closeListeners releases listeners bound by a Listen call that failed part way.
+func (s *Server) closeListeners() {
+	_ = s.listener.Close()
+	for _, side := range s.sides {
+		_ = side.listener.Close()
+	}
+	s.listener, s.sides = nil, nil
+}
+
+// httpsRedirect sends plain HTTP requests to the same URL on the HTTPS port.
+func httpsRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// hstsMiddleware tells browsers to use HTTPS for this host for maxAge.
+func hstsMiddleware(next http.Handler, maxAge time.Duration) http.Handler {
+	value := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", value)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// buildHandler assembles the routes, static file server, auth gate and request logging.
+func (s *Server) buildHandler() http.Handler {
+	mux := s.registerRoutes()
+
+	// If static directory is set and exists, serve static files
+	if s.staticDir != "" {
+		if _, err := os.Stat(s.staticDir); !os.IsNotExist(err) {
+			fs := http.FileServer(http.Dir(s.staticDir))
+			mux.Handle("/", fs)
+			s.logger.Info("Serving static files from", "directory", s.staticDir)
+		} else {
+			s.logger.Warn("Static directory does not exist, not serving static files", "directory", s.staticDir)
+		}
+	}
+
+	var handler http.Handler = mux
+	if s.authMiddleware != nil {
+		protected := s.authMiddleware(mux.ServeHTTP)
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.isPublic(r.URL.Path) {
+				mux.ServeHTTP(w, r)
+				return
+			}
+			protected(w, r)
+		})
+	}
+	if s.metrics != nil {
+		handler = metricsMiddleware(handler, mux, s.metrics)
+	}
+	if s.useTLS && s.hstsMaxAge > 0 {
+		handler = hstsMiddleware(handler, s.hstsMaxAge)
+	}
+	return loggingMiddleware(handler, s.logger)
+}
+
+// isPublic reports whether a request path bypasses authentication.
+func (s *Server) isPublic(path string) bool {
+	for _, rt := range s.routes {
+		if !rt.public {
+			continue
+		}
+		// Drop an optional "METHOD " prefix; the mux enforces the method
+		pattern := rt.pattern
+		if _, p, found := strings.Cut(pattern, " "); found {
+			pattern = p
+		}
+		if path == pattern || strings.HasSuffix(pattern, "/") && strings.HasPrefix(path, pattern) {
+			return true
+		}
+	}
+	if path == "/api" || strings.HasPrefix(path, "/api/") || path == "/metrics" {
+		return false
+	}
+	if isProbePath(path) {
+		return s.publicHealth
+	}
+	return s.publicStatic
+}
+
+// isProbePath reports whether path is one of the health, readiness or version endpoints.
+func isProbePath(path string) bool {
+	return path == "/health" || path == "/ready" || path == "/version"
+}
+
+// loggingMiddleware tags each request with an ID, taken from a well-formed
+// X-Request-ID header or generated, and echoes it in the response. Handlers
+// find a logger carrying the ID through LoggerFromContext.
 func loggingMiddleware(next http.Handler, logger ports.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logger.Info("Request started",
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = rand.Text()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		reqLogger := logger.With("request_id", requestID)
+		reqLogger.Debug("Request started",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr)
@@ -159,17 +547,61 @@ func loggingMiddleware(next http.Handler, logger ports.Logger) http.Handler {
 		}
 
 		// Serve the request
-		next.ServeHTTP(rw, r)
+		start := time.Now()
+		next.ServeHTTP(rw, r.WithContext(WithLogger(r.Context(), reqLogger)))
 
 		// Log the response
-		logger.Info("Request completed",
+		reqLogger.Info("Request completed",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", rw.status,
-			"bytes", rw.bytesWritten)
+			"bytes", rw.bytesWritten,
+			"duration_ms", time.Since(start).Milliseconds())
 	})
 }
 
+// metricsMiddleware reports each request under the mux pattern that serves
+// it, so the route label has one value per registered route, not per URL.
+func metricsMiddleware(next http.Handler, mux *http.ServeMux, metrics ports.Metrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		start := time.Now()
+		next.ServeHTTP(rw, r)
+
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(metricMethod(r.Method), route, rw.status, time.Since(start))
+	})
+}
+
+// metricMethod folds nonstandard methods into one label value.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
+
+// validRequestID accepts client-supplied IDs that are safe to log and echo.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
 // responseWriter wraps http.ResponseWriter to capture the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -188,6 +620,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// registerRoutes maps URL paths to handlers and returns a mux.
 func (s *Server) registerRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 
@@ -196,6 +634,9 @@ func (s *Server) registerRoutes() *http.ServeMux {
 	mux.Handle("/api/files/", s.rootHandler)
 	mux.Handle("/api/files/download", s.rootHandler)
 	mux.Handle("/api/upload", s.uploadHandler)
+	for _, rt := range s.routes {
+		mux.Handle(rt.pattern, rt.handler)
+	}
 
 	// Swagger documentation
 	mux.HandleFunc("/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
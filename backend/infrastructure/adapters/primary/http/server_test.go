@@ -0,0 +1,440 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	cryptotls "crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/application/services"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/auth"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/logging"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/tls"
+)
+
+// nopLogger discards everything written to it.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any)       {}
+func (nopLogger) Info(string, ...any)        {}
+func (nopLogger) Warn(string, ...any)        {}
+func (nopLogger) Error(string, ...any)       {}
+func (nopLogger) Fatal(string, ...any)       {}
+func (l nopLogger) With(...any) ports.Logger { return l }
+
+// okHandler answers 200 and echoes the authenticated username, if any.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if claims, ok := UserFromContext(r.Context()); ok {
+		w.Header().Set("X-User", claims.Username)
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func newTestServer(t *testing.T, mode ports.AuthMode) (*Server, *auth.JWTProviderImpl) {
+	t.Helper()
+	jwtProvider := auth.NewJWTProvider("test-secret", time.Hour)
+	server := NewServer("0", nil, nopLogger{}, okHandler, okHandler)
+	if err := server.ConfigureAuth(mode, auth.NewStaticAuthProvider("admin", "secret"), jwtProvider); err != nil {
+		t.Fatalf("Failed to configure auth: %v", err)
+	}
+	return server, jwtProvider
+}
+
+func TestServerAuthModes(t *testing.T) {
+	type credentials int
+	const (
+		anonymous credentials = iota
+		validBasic
+		wrongBasic
+		validBearer
+		wrongBearer
+	)
+
+	tests := []struct {
+		mode  ports.AuthMode
+		creds credentials
+		want  int
+	}{
+		{ports.AuthModeNone, anonymous, http.StatusOK},
+		{ports.AuthModeNone, wrongBasic, http.StatusOK},
+
+		{ports.AuthModeBasic, anonymous, http.StatusUnauthorized},
+		{ports.AuthModeBasic, validBasic, http.StatusOK},
+		{ports.AuthModeBasic, wrongBasic, http.StatusUnauthorized},
+		{ports.AuthModeBasic, validBearer, http.StatusUnauthorized},
+
+		{ports.AuthModeJWT, anonymous, http.StatusUnauthorized},
+		{ports.AuthModeJWT, validBasic, http.StatusUnauthorized},
+		{ports.AuthModeJWT, validBearer, http.StatusOK},
+		{ports.AuthModeJWT, wrongBearer, http.StatusUnauthorized},
+
+		{ports.AuthModeBoth, anonymous, http.StatusUnauthorized},
+		{ports.AuthModeBoth, validBasic, http.StatusOK},
+		{ports.AuthModeBoth, wrongBasic, http.StatusUnauthorized},
+		{ports.AuthModeBoth, validBearer, http.StatusOK},
+		{ports.AuthModeBoth, wrongBearer, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		server, jwtProvider := newTestServer(t, tt.mode)
+		handler := server.buildHandler()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/files", nil)
+		switch tt.creds {
+		case validBasic:
+			req.SetBasicAuth("admin", "secret")
+		case wrongBasic:
+			req.SetBasicAuth("admin", "wrong")
+		case validBearer:
+			token, err := jwtProvider.GenerateToken("admin")
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		case wrongBearer:
+			req.Header.Set("Authorization", "Bearer not.a.token")
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("mode=%s creds=%d: expected status %d, got %d", tt.mode, tt.creds, tt.want, rec.Code)
+		}
+		if rec.Code == http.StatusOK && tt.mode != ports.AuthModeNone && rec.Header().Get("X-User") != "admin" {
+			t.Errorf("mode=%s creds=%d: expected user in context, got %q", tt.mode, tt.creds, rec.Header().Get("X-User"))
+		}
+	}
+}
+
+func TestServerPublicAccess(t *testing.T) {
+	tests := []struct {
+		health, static bool
+		path           string
+		want           int
+	}{
+		{true, true, "/swagger.yaml", http.StatusOK},
+		{true, false, "/swagger.yaml", http.StatusUnauthorized},
+		{true, true, "/health", http.StatusFound}, // falls through to the root redirect
+		{false, true, "/health", http.StatusUnauthorized},
+		{true, true, "/api/upload", http.StatusUnauthorized},
+		{true, false, "/ready", http.StatusFound},
+		{false, true, "/version", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		server, _ := newTestServer(t, ports.AuthModeBasic)
+		server.SetPublicAccess(tt.health, tt.static)
+
+		rec := httptest.NewRecorder()
+		server.buildHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("health=%v static=%v %s: expected status %d, got %d", tt.health, tt.static, tt.path, tt.want, rec.Code)
+		}
+	}
+}
+
+func TestConfigureAuthRequiresProviders(t *testing.T) {
+	server := NewServer("0", nil, nopLogger{}, okHandler, okHandler)
+	if err := server.ConfigureAuth(ports.AuthModeBasic, nil, nil); err == nil {
+		t.Error("Expected error for basic mode without auth provider")
+	}
+	if err := server.ConfigureAuth(ports.AuthModeJWT, nil, nil); err == nil {
+		t.Error("Expected error for jwt mode without JWT provider")
+	}
+	if err := server.ConfigureAuth(ports.AuthMode("ldap"), nil, nil); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	server, jwtProvider := newTestServer(t, ports.AuthModeJWT)
+	handler := server.buildHandler()
+	authService := services.NewAuthService(auth.NewStaticAuthProvider("admin", "secret"), jwtProvider)
+
+	if _, err := authService.Login("admin", "wrong"); err == nil {
+		t.Fatal("Expected login with wrong password to fail")
+	}
+	pair, err := authService.Login("admin", "secret")
+	if err != nil {
+		t.Fatalf("Failed to log in: %v", err)
+	}
+
+	request := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/files", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(pair.AccessToken); code != http.StatusOK {
+		t.Fatalf("Expected access token to pass, got %d", code)
+	}
+	if code := request(pair.RefreshToken); code != http.StatusUnauthorized {
+		t.Fatalf("Expected refresh token to be rejected as access token, got %d", code)
+	}
+
+	refreshed, err := authService.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+	if _, err := authService.Refresh(pair.RefreshToken); err == nil {
+		t.Fatal("Expected a used refresh token to be rejected")
+	}
+
+	if err := authService.Logout(refreshed.AccessToken, refreshed.RefreshToken); err != nil {
+		t.Fatalf("Failed to log out: %v", err)
+	}
+	if code := request(refreshed.AccessToken); code != http.StatusUnauthorized {
+		t.Fatalf("Expected logged-out token to be rejected, got %d", code)
+	}
+	if _, err := authService.Refresh(refreshed.RefreshToken); err == nil {
+		t.Fatal("Expected logged-out refresh token to be rejected")
+	}
+}
+
+func TestTLSCheck(t *testing.T) {
+	server, _ := newTestServer(t, ports.AuthModeNone)
+	if err := server.TLSCheck().Check(); err != nil {
+		t.Errorf("Expected plain HTTP to be ready, got %v", err)
+	}
+	server.ConfigureTLS(true)
+	if err := server.TLSCheck().Check(); err == nil {
+		t.Error("Expected TLS check to fail before a certificate is loaded")
+	}
+
+	acmeSource, err := tls.NewACMECertSource(tls.ACMEOptions{Domains: []string{"files.example"}, CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create ACME source: %v", err)
+	}
+	server.SetCertSource(acmeSource)
+	if err := server.TLSCheck().Check(); err != nil {
+		t.Errorf("Expected on-demand certificates to count as ready, got %v", err)
+	}
+}
+
+func TestRequestIDLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.NewSlogLogger(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		LoggerFromContext(r.Context(), nopLogger{}).Info("Inside handler")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	server := NewServer("0", nil, logger, handler, handler)
+
+	tests := []struct {
+		incoming string
+		keep     bool
+	}{
+		{"", false},
+		{"client-id_1.2:3", true},
+		{"bad id with spaces", false},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/api/files", nil)
+		if tt.incoming != "" {
+			req.Header.Set(RequestIDHeader, tt.incoming)
+		}
+		rec := httptest.NewRecorder()
+		server.buildHandler().ServeHTTP(rec, req)
+
+		id := rec.Header().Get(RequestIDHeader)
+		if id == "" || (id == tt.incoming) != tt.keep {
+			t.Errorf("incoming %q: unexpected response ID %q", tt.incoming, id)
+		}
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		var sawHandler, sawCompleted bool
+		for _, line := range lines {
+			var entry map[string]any
+			if err := json.Unmarshal(line, &entry); err != nil {
+				t.Fatalf("Failed to parse log line %q: %v", line, err)
+			}
+			if entry["request_id"] != id {
+				t.Errorf("incoming %q: line %v lacks request ID %q", tt.incoming, entry, id)
+			}
+			switch entry["msg"] {
+			case "Inside handler":
+				sawHandler = true
+			case "Request completed":
+				sawCompleted = entry["status"] == float64(http.StatusTeapot)
+			}
+		}
+		if !sawHandler || !sawCompleted {
+			t.Errorf("incoming %q: expected handler and completion lines, got %s", tt.incoming, buf.String())
+		}
+	}
+}
+
+// recordingMetrics remembers the routes passed to ObserveRequest.
+type recordingMetrics struct {
+	routes []string
+}
+
+func (m *recordingMetrics) ObserveRequest(method, route string, status int, _ time.Duration) {
+	m.routes = append(m.routes, method+" "+route+" "+http.StatusText(status))
+}
+func (m *recordingMetrics) AddBytesUploaded(int64)   {}
+func (m *recordingMetrics) AddBytesDownloaded(int64) {}
+func (m *recordingMetrics) ZipStreamStarted()        {}
+func (m *recordingMetrics) ZipStreamFinished()       {}
+func (m *recordingMetrics) UploadFailed(string)      {}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server, _ := newTestServer(t, ports.AuthModeBasic)
+	server.SetPublicAccess(true, true)
+	server.Handle("GET /api/files/download/", okHandler)
+	server.Handle("GET /metrics", okHandler)
+	metrics := &recordingMetrics{}
+	server.SetMetrics(metrics)
+	handler := server.buildHandler()
+
+	tests := []struct {
+		method, path string
+		auth         bool
+		want         string
+	}{
+		// Route labels use the pattern, not the requested path
+		{http.MethodGet, "/api/files/download/a/b.txt", true, "GET GET /api/files/download/ OK"},
+		{http.MethodGet, "/api/files/download/c.txt", false, "GET GET /api/files/download/ Unauthorized"},
+		{http.MethodGet, "/metrics", false, "GET GET /metrics Unauthorized"},
+		{http.MethodGet, "/metrics", true, "GET GET /metrics OK"},
+		{"BREW", "/api/files/download/a", true, "OTHER /api/files/ OK"},
+	}
+	for _, tt := range tests {
+		metrics.routes = nil
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.auth {
+			req.SetBasicAuth("admin", "secret")
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if len(metrics.routes) != 1 || metrics.routes[0] != tt.want {
+			t.Errorf("%s %s: expected observation %q, got %v", tt.method, tt.path, tt.want, metrics.routes)
+		}
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		port, host, target string
+		want               string
+	}{
+		{"443", "files.example", "/a/b?x=1", "https://files.example/a/b?x=1"},
+		{"443", "files.example:80", "/", "https://files.example/"},
+		{"22010", "files.example:8080", "/dl", "https://files.example:22010/dl"},
+		{"443", "[::1]:80", "/", "https://[::1]/"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		httpsRedirect(tt.port).ServeHTTP(rec, req)
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tt.want {
+			t.Errorf("%s%s: expected 308 to %s, got %d %s", tt.host, tt.target, tt.want, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+}
+
+func TestServerBoot(t *testing.T) {
+	for _, useTLS := range []bool{false, true} {
+		server := NewServer("0", &tls.InMemoryTLSCertGenerator{}, nopLogger{}, okHandler, okHandler)
+		server.Handle("GET /ping", okHandler)
+		server.ConfigureTLS(useTLS)
+		server.SetHTTPRedirect("127.0.0.1:0")
+		if err := server.Listen(); err != nil {
+			t.Fatalf("tls=%v: failed to listen: %v", useTLS, err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- server.Serve(ctx) }()
+
+		port := server.Addr().(*net.TCPAddr).Port
+		client := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &cryptotls.Config{InsecureSkipVerify: true}},
+			// Report redirects instead of following them
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+		scheme := "http"
+		if useTLS {
+			scheme = "https"
+		}
+		resp, err := client.Get(fmt.Sprintf("%s://127.0.0.1:%d/ping", scheme, port))
+		if err != nil {
+			t.Fatalf("tls=%v: request failed: %v", useTLS, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("tls=%v: expected status 200, got %d", useTLS, resp.StatusCode)
+		}
+		if hsts := resp.Header.Get("Strict-Transport-Security"); (hsts != "") != useTLS {
+			t.Errorf("tls=%v: unexpected HSTS header %q", useTLS, hsts)
+		}
+
+		redirect := server.RedirectAddr()
+		if !useTLS {
+			if redirect != nil {
+				t.Errorf("Expected no redirect listener without TLS, got %v", redirect)
+			}
+		} else {
+			resp, err := client.Get("http://" + redirect.String() + "/ping?x=1")
+			if err != nil {
+				t.Fatalf("Redirect request failed: %v", err)
+			}
+			resp.Body.Close()
+			want := fmt.Sprintf("https://127.0.0.1:%d/ping?x=1", port)
+			if resp.StatusCode != http.StatusPermanentRedirect || resp.Header.Get("Location") != want {
+				t.Errorf("Expected 308 to %s, got %d %s", want, resp.StatusCode, resp.Header.Get("Location"))
+			}
+		}
+
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("tls=%v: expected clean shutdown, got %v", useTLS, err)
+		}
+	}
+}
+
+func TestServerReload(t *testing.T) {
+	server := NewServer("0", &tls.InMemoryTLSCertGenerator{}, nopLogger{}, okHandler, okHandler)
+	server.SetListenAddr("127.0.0.1:0")
+	server.ConfigureTLS(true)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer server.closeListeners()
+	first, err := server.certs.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+
+	// A failing hook keeps the old certificate and skips later hooks
+	laterRan := false
+	server.OnReload(func() error { return server.ReplaceCertGenerator(&watchedGenerator{fail: true}) })
+	server.OnReload(func() error { laterRan = true; return nil })
+	if err := server.Reload(); err == nil {
+		t.Fatal("Expected the broken certificate to fail the reload")
+	}
+	if cert, _ := server.certs.GetCertificate(nil); cert != first || laterRan {
+		t.Errorf("Expected the previous certificate and no later hooks after a failed reload")
+	}
+
+	server.reloadHooks = nil
+	server.OnReload(func() error { return server.ReplaceCertGenerator(&tls.InMemoryTLSCertGenerator{}) })
+	if err := server.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if cert, _ := server.certs.GetCertificate(nil); cert == first {
+		t.Error("Expected the new generator's certificate after a reload")
+	}
+}
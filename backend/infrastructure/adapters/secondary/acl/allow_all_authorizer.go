@@ -0,0 +1,14 @@
+package acl
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// AllowAllAuthorizer permits every action. It is used when no ACL file is configured.
+type AllowAllAuthorizer struct{}
+
+// Allowed always returns true.
+func (AllowAllAuthorizer) Allowed(string, models.Action, string) bool { return true }
+
+var _ ports.Authorizer = AllowAllAuthorizer{}
@@ -0,0 +1,211 @@
+package acl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Principals used in rule grants besides plain usernames
+const (
+	everyone    = "*"
+	groupPrefix = "@"
+)
+
+// Rule grants and revokes actions on a directory prefix.
+// Principals are usernames, "@group" or "*" for everyone (including anonymous).
+// An action of "*" stands for every action.
+type Rule struct {
+	Path  string              `json:"path"`
+	Allow map[string][]string `json:"allow,omitempty"`
+	Deny  map[string][]string `json:"deny,omitempty"`
+	// Inherit defaults to true; false discards grants from parent rules.
+	Inherit *bool `json:"inherit,omitempty"`
+}
+
+// Policy is the on-disk ACL document.
+type Policy struct {
+	Groups map[string][]string `json:"groups,omitempty"`
+	Rules  []Rule              `json:"rules"`
+}
+
+// RuleAuthorizer evaluates per-prefix rules from the shallowest matching
+// prefix to the deepest, so deeper rules refine what their parents grant.
+type RuleAuthorizer struct {
+	groups map[string]map[string]bool // group → members
+	rules  []compiledRule             // sorted by depth, shallowest first
+}
+
+// compiledRule is a Rule with its path cleaned and its action lists expanded.
+type compiledRule struct {
+	path    string
+	depth   int
+	inherit bool
+	allow   map[string][]models.Action
+	deny    map[string][]models.Action
+}
+
+// LoadRuleAuthorizer reads a JSON policy file.
+func LoadRuleAuthorizer(file string) (*RuleAuthorizer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	authorizer, err := NewRuleAuthorizer(policy)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return authorizer, nil
+}
+
+// NewRuleAuthorizer validates and compiles a policy.
+func NewRuleAuthorizer(policy Policy) (*RuleAuthorizer, error) {
+	a := &RuleAuthorizer{groups: make(map[string]map[string]bool)}
+	for name, members := range policy.Groups {
+		set := make(map[string]bool, len(members))
+		for _, member := range members {
+			set[member] = true
+		}
+		a.groups[name] = set
+	}
+
+	seen := make(map[string]bool)
+	for i, rule := range policy.Rules {
+		if rule.Path == "" {
+			return nil, fmt.Errorf("rule %d: path is required", i)
+		}
+		cleaned := normalize(rule.Path)
+		if seen[cleaned] {
+			return nil, fmt.Errorf("rule %d: duplicate rule for %s", i, cleaned)
+		}
+		seen[cleaned] = true
+
+		allow, err := a.compileGrants(rule.Allow)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, cleaned, err)
+		}
+		deny, err := a.compileGrants(rule.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, cleaned, err)
+		}
+
+		a.rules = append(a.rules, compiledRule{
+			path:    cleaned,
+			depth:   depth(cleaned),
+			inherit: rule.Inherit == nil || *rule.Inherit,
+			allow:   allow,
+			deny:    deny,
+		})
+	}
+	sort.SliceStable(a.rules, func(i, j int) bool { return a.rules[i].depth < a.rules[j].depth })
+	return a, nil
+}
+
+// Allowed reports whether user may perform action on p.
+// Paths no rule covers are denied.
+func (a *RuleAuthorizer) Allowed(user string, action models.Action, p string) bool {
+	target := normalize(p)
+	granted := make(map[models.Action]bool)
+
+	for _, rule := range a.rules {
+		if !covers(rule.path, target) {
+			continue
+		}
+		if !rule.inherit {
+			clear(granted)
+		}
+		for principal, actions := range rule.allow {
+			if a.matches(principal, user) {
+				for _, act := range actions {
+					granted[act] = true
+				}
+			}
+		}
+		for principal, actions := range rule.deny {
+			if a.matches(principal, user) {
+				for _, act := range actions {
+					delete(granted, act)
+				}
+			}
+		}
+	}
+	return granted[action]
+}
+
+// compileGrants validates principals and expands "*" actions.
+func (a *RuleAuthorizer) compileGrants(grants map[string][]string) (map[string][]models.Action, error) {
+	compiled := make(map[string][]models.Action, len(grants))
+	for principal, names := range grants {
+		if group, ok := strings.CutPrefix(principal, groupPrefix); ok {
+			if _, known := a.groups[group]; !known {
+				return nil, fmt.Errorf("unknown group %q", group)
+			}
+		}
+		for _, name := range names {
+			if name == everyone {
+				compiled[principal] = append(compiled[principal], models.AllActions...)
+				continue
+			}
+			action, err := parseAction(name)
+			if err != nil {
+				return nil, err
+			}
+			compiled[principal] = append(compiled[principal], action)
+		}
+	}
+	return compiled, nil
+}
+
+// matches reports whether principal refers to user.
+func (a *RuleAuthorizer) matches(principal, user string) bool {
+	if principal == everyone {
+		return true
+	}
+	if user == "" {
+		return false
+	}
+	if group, ok := strings.CutPrefix(principal, groupPrefix); ok {
+		return a.groups[group][user]
+	}
+	return principal == user
+}
+
+// parseAction converts a policy action name to a models.Action.
+func parseAction(name string) (models.Action, error) {
+	for _, action := range models.AllActions {
+		if string(action) == name {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("unknown action %q", name)
+}
+
+// normalize cleans p into an absolute slash path.
+func normalize(p string) string {
+	return path.Clean("/" + strings.ReplaceAll(p, "\\", "/"))
+}
+
+// covers reports whether rulePath is target or one of its ancestors.
+func covers(rulePath, target string) bool {
+	return rulePath == "/" || target == rulePath || strings.HasPrefix(target, rulePath+"/")
+}
+
+// depth counts path components; "/" has depth 0.
+func depth(p string) int {
+	if p == "/" {
+		return 0
+	}
+	return strings.Count(p, "/")
+}
+
+var _ ports.Authorizer = (*RuleAuthorizer)(nil)
@@ -0,0 +1,65 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+func TestRuleAuthorizer(t *testing.T) {
+	noInherit := false
+	authorizer, err := NewRuleAuthorizer(Policy{
+		Groups: map[string][]string{"devs": {"alice", "bob"}},
+		Rules: []Rule{
+			{Path: "/", Allow: map[string][]string{"*": {"list", "read", "zip"}, "admin": {"*"}}},
+			{Path: "/projects", Allow: map[string][]string{"@devs": {"write"}}},
+			{Path: "/projects/secret", Inherit: &noInherit, Allow: map[string][]string{"alice": {"*"}}},
+			{Path: "/releases", Deny: map[string][]string{"*": {"write", "delete"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile policy: %v", err)
+	}
+
+	tests := []struct {
+		user   string
+		action models.Action
+		path   string
+		want   bool
+	}{
+		{"", models.ActionRead, "/readme.txt", true},
+		{"", models.ActionWrite, "/readme.txt", false},
+		{"carol", models.ActionList, "/projects", true},
+		{"carol", models.ActionWrite, "/projects/app/main.go", false},
+		{"bob", models.ActionWrite, "/projects/app/main.go", true},
+		{"bob", models.ActionWrite, "projects/app/main.go", true}, // relative paths are normalized
+		{"bob", models.ActionDelete, "/projects/app/main.go", false},
+		{"bob", models.ActionRead, "/projects/secret/plan.txt", false}, // inheritance cut off
+		{"alice", models.ActionDelete, "/projects/secret/plan.txt", true},
+		{"admin", models.ActionRead, "/projects/secret/plan.txt", false},
+		{"admin", models.ActionDelete, "/projects/app", true},
+		{"admin", models.ActionWrite, "/releases/v1.zip", false}, // deny overrides parent grant
+		{"admin", models.ActionRead, "/releases/v1.zip", true},
+		{"bob", models.ActionWrite, "/projectsX/file", false}, // prefixes match whole components
+		{"bob", models.ActionRead, "/projects/../projects/secret/x", false},
+	}
+	for _, tt := range tests {
+		if got := authorizer.Allowed(tt.user, tt.action, tt.path); got != tt.want {
+			t.Errorf("Allowed(%q, %s, %q) = %v, want %v", tt.user, tt.action, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRuleAuthorizerRejectsInvalidPolicy(t *testing.T) {
+	policies := []Policy{
+		{Rules: []Rule{{Path: "/", Allow: map[string][]string{"*": {"fly"}}}}},
+		{Rules: []Rule{{Path: "/", Allow: map[string][]string{"@ghosts": {"read"}}}}},
+		{Rules: []Rule{{Path: "/a"}, {Path: "/a/"}}},
+		{Rules: []Rule{{Allow: map[string][]string{"*": {"read"}}}}},
+	}
+	for i, policy := range policies {
+		if _, err := NewRuleAuthorizer(policy); err == nil {
+			t.Errorf("policy %d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package acl
+
+import (
+	"sync/atomic"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// SwappableAuthorizer delegates to an authorizer that can be replaced while
+// requests are in flight, e.g. when the policy file is reloaded.
+type SwappableAuthorizer struct {
+	current atomic.Pointer[authorizerHolder]
+}
+
+// authorizerHolder lets an interface value live in an atomic.Pointer.
+type authorizerHolder struct {
+	ports.Authorizer
+}
+
+// NewSwappableAuthorizer starts out delegating to initial.
+func NewSwappableAuthorizer(initial ports.Authorizer) *SwappableAuthorizer {
+	a := &SwappableAuthorizer{}
+	a.Swap(initial)
+	return a
+}
+
+// Swap makes every later decision use next.
+func (a *SwappableAuthorizer) Swap(next ports.Authorizer) {
+	a.current.Store(&authorizerHolder{next})
+}
+
+// Allowed asks the current authorizer.
+func (a *SwappableAuthorizer) Allowed(user string, action models.Action, path string) bool {
+	return a.current.Load().Allowed(user, action, path)
+}
+
+var _ ports.Authorizer = (*SwappableAuthorizer)(nil)
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// fileCheckInterval bounds how often the credential file is stat'ed for changes.
+const fileCheckInterval = 2 * time.Second
+
+// verifiedTTL is how long a successful password check is remembered, so
+// that clients sending Basic credentials on every request skip the hash.
+const verifiedTTL = time.Minute
+
+// FileAuthProvider authenticates against an htpasswd-style file of hashed
+// passwords and picks up edits to the file without a restart. Successful
+// checks are cached for verifiedTTL, and at most one hash check per CPU
+// runs at a time, so bursts of requests cannot exhaust memory or CPU.
+type FileAuthProvider struct {
+	path      string
+	logger    ports.Logger
+	cacheKey  []byte        // Keys the password digests in verified
+	hashSlots chan struct{} // Semaphore bounding concurrent hash checks
+
+	mu        sync.RWMutex
+	file      *HtpasswdFile
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+	verified  map[string]time.Time // User and password digest -> expiry
+}
+
+// NewFileAuthProvider loads the credential file at path.
+// The file must exist; create it with the "user add" subcommand.
+func NewFileAuthProvider(path string, logger ports.Logger) (*FileAuthProvider, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := LoadHtpasswdFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := make([]byte, 32)
+	if _, err := rand.Read(cacheKey); err != nil {
+		return nil, err
+	}
+
+	return &FileAuthProvider{
+		path:      path,
+		logger:    logger,
+		cacheKey:  cacheKey,
+		hashSlots: make(chan struct{}, runtime.NumCPU()),
+		file:      file,
+		modTime:   info.ModTime(),
+		size:      info.Size(),
+		lastCheck: time.Now(),
+		verified:  make(map[string]time.Time),
+	}, nil
+}
+
+// Authenticate validates username and password against the current file contents.
+func (p *FileAuthProvider) Authenticate(username, password string) bool {
+	p.reloadIfChanged()
+
+	key := p.verifiedKey(username, password)
+	p.mu.RLock()
+	file := p.file
+	expiry, cached := p.verified[key]
+	p.mu.RUnlock()
+	if cached && time.Now().Before(expiry) {
+		return true
+	}
+
+	p.hashSlots <- struct{}{}
+	ok := file.Verify(username, password)
+	<-p.hashSlots
+	if !ok {
+		return false
+	}
+
+	p.mu.Lock()
+	// A reload while hashing may have changed the password; cache only
+	// what was checked against the current file
+	if p.file == file {
+		now := time.Now()
+		for k, expiry := range p.verified {
+			if now.After(expiry) {
+				delete(p.verified, k)
+			}
+		}
+		p.verified[key] = now.Add(verifiedTTL)
+	}
+	p.mu.Unlock()
+	return true
+}
+
+// verifiedKey identifies a username and password in the cache without
+// keeping the password, or a fast unkeyed hash of it, in memory.
+func (p *FileAuthProvider) verifiedKey(username, password string) string {
+	mac := hmac.New(sha256.New, p.cacheKey)
+	mac.Write([]byte(password))
+	return username + ":" + string(mac.Sum(nil))
+}
+
+// UserExists reports whether username is in the current file contents.
+func (p *FileAuthProvider) UserExists(username string) bool {
+	p.reloadIfChanged()
+
+	p.mu.RLock()
+	file := p.file
+	p.mu.RUnlock()
+
+	return file.Has(username)
+}
+
+// Reload re-reads the credential file unconditionally and forgets cached
+// checks. On error the previously loaded users stay in effect.
+func (p *FileAuthProvider) Reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	file, err := LoadHtpasswdFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.file = file
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	clear(p.verified)
+	p.mu.Unlock()
+
+	p.logger.Info("Reloaded credential file", "path", p.path, "users", len(file.users))
+	return nil
+}
+
+// reloadIfChanged reloads the file when its size or mtime differ from the
+// loaded copy, checking at most once per fileCheckInterval.
+func (p *FileAuthProvider) reloadIfChanged() {
+	p.mu.Lock()
+	if time.Since(p.lastCheck) < fileCheckInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastCheck = time.Now()
+	modTime, size := p.modTime, p.size
+	p.mu.Unlock()
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		p.logger.Warn("Credential file unavailable, keeping previous users", "path", p.path, "error", err)
+		return
+	}
+	if info.ModTime().Equal(modTime) && info.Size() == size {
+		return
+	}
+	if err := p.Reload(); err != nil {
+		p.logger.Error("Failed to reload credential file, keeping previous users", "path", p.path, "error", err)
+	}
+}
+
+var _ ports.AuthProvider = (*FileAuthProvider)(nil)
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// nopLogger discards everything written to it.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any)       {}
+func (nopLogger) Info(string, ...any)        {}
+func (nopLogger) Warn(string, ...any)        {}
+func (nopLogger) Error(string, ...any)       {}
+func (nopLogger) Fatal(string, ...any)       {}
+func (l nopLogger) With(...any) ports.Logger { return l }
+
+func TestFileAuthProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.htpasswd")
+
+	file, err := LoadHtpasswdFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load missing file: %v", err)
+	}
+	if err := file.SetPassword("alice", "alice-pw", HashBcrypt); err != nil {
+		t.Fatalf("Failed to set bcrypt password: %v", err)
+	}
+	if err := file.SetPassword("bob", "bob-pw", HashArgon2id); err != nil {
+		t.Fatalf("Failed to set argon2id password: %v", err)
+	}
+	if err := file.SetPassword("bad:name", "pw", HashBcrypt); err == nil {
+		t.Error("Expected error for username containing ':'")
+	}
+	if err := file.Save(); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+
+	provider, err := NewFileAuthProvider(path, nopLogger{})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	tests := []struct {
+		username, password string
+		want               bool
+	}{
+		{"alice", "alice-pw", true},
+		{"alice", "bob-pw", false},
+		{"bob", "bob-pw", true},
+		{"bob", "", false},
+		{"carol", "alice-pw", false},
+	}
+	for _, tt := range tests {
+		if got := provider.Authenticate(tt.username, tt.password); got != tt.want {
+			t.Errorf("Authenticate(%q, %q) = %v, want %v", tt.username, tt.password, got, tt.want)
+		}
+	}
+
+	// Remove alice and make sure the provider notices without a restart
+	file.Remove("alice")
+	if err := file.Save(); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	provider.mu.Lock()
+	provider.lastCheck = time.Time{}
+	provider.modTime = time.Time{}
+	provider.mu.Unlock()
+
+	if provider.Authenticate("alice", "alice-pw") {
+		t.Error("Expected removed user to be rejected after reload")
+	}
+	if !provider.Authenticate("bob", "bob-pw") {
+		t.Error("Expected remaining user to still authenticate")
+	}
+}
+
+func TestFileAuthProviderCachesVerifiedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.htpasswd")
+	file, err := LoadHtpasswdFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load missing file: %v", err)
+	}
+	if err := file.SetPassword("alice", "old-pw", HashBcrypt); err != nil {
+		t.Fatalf("Failed to set password: %v", err)
+	}
+	if err := file.Save(); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	provider, err := NewFileAuthProvider(path, nopLogger{})
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	if provider.Authenticate("alice", "wrong") || len(provider.verified) != 0 {
+		t.Fatal("Expected a failed check to be rejected and not cached")
+	}
+	if !provider.Authenticate("alice", "old-pw") || len(provider.verified) != 1 {
+		t.Fatal("Expected a successful check to be cached")
+	}
+	for key := range provider.verified {
+		if strings.Contains(key, "old-pw") {
+			t.Error("Expected the cache not to hold the password")
+		}
+	}
+	if !provider.Authenticate("alice", "old-pw") {
+		t.Error("Expected the cached password to pass")
+	}
+
+	// A reload forgets what was checked against the old file
+	if err := file.SetPassword("alice", "new-pw", HashBcrypt); err != nil {
+		t.Fatalf("Failed to set password: %v", err)
+	}
+	if err := file.Save(); err != nil {
+		t.Fatalf("Failed to save file: %v", err)
+	}
+	if err := provider.Reload(); err != nil {
+		t.Fatalf("Failed to reload: %v", err)
+	}
+	if provider.Authenticate("alice", "old-pw") {
+		t.Error("Expected the old password to be rejected after reload")
+	}
+	if !provider.Authenticate("alice", "new-pw") {
+		t.Error("Expected the new password to pass after reload")
+	}
+}
+
+func TestVerifyPasswordRejectsBadArgon2Parameters(t *testing.T) {
+	hash, err := HashPassword("pw", HashArgon2id)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if !VerifyPassword(hash, "pw") {
+		t.Fatal("Expected the generated hash to verify")
+	}
+
+	fields := strings.Split(hash, "$")
+	withParams := func(params string) string {
+		return strings.Join([]string{"", "argon2id", fields[2], params, fields[4], fields[5]}, "$")
+	}
+	for name, bad := range map[string]string{
+		"no threads":    withParams("m=65536,t=3,p=0"),
+		"many threads":  withParams("m=65536,t=3,p=255"),
+		"zero time":     withParams("m=65536,t=0,p=4"),
+		"huge memory":   withParams("m=4294967295,t=3,p=4"),
+		"many passes":   withParams("m=65536,t=1000000,p=4"),
+		"short salt":    strings.Join([]string{"", "argon2id", fields[2], fields[3], "c2FsdA", fields[5]}, "$"),
+		"short key":     strings.Join([]string{"", "argon2id", fields[2], fields[3], fields[4], "a2V5"}, "$"),
+		"missing field": "$argon2id$v=19$m=65536,t=3,p=4$" + fields[4],
+	} {
+		if VerifyPassword(bad, "pw") {
+			t.Errorf("%s: expected %q to be rejected", name, bad)
+		}
+	}
+}
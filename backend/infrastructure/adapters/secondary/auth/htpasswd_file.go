@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HtpasswdFile is an htpasswd-style credential file with one
+// "username:hash" entry per line. Blank lines and lines starting
+// with '#' are ignored.
+type HtpasswdFile struct {
+	path  string
+	users map[string]string
+}
+
+// LoadHtpasswdFile reads the credential file at path.
+// A missing file yields an empty set of users so it can be created with Save.
+func LoadHtpasswdFile(path string) (*HtpasswdFile, error) {
+	f := &HtpasswdFile{path: path, users: make(map[string]string)}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(line, ":")
+		if !ok || username == "" || hash == "" {
+			return nil, fmt.Errorf("%s:%d: expected username:hash", path, lineNo)
+		}
+		f.users[username] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Usernames returns the users in the file, sorted.
+func (f *HtpasswdFile) Usernames() []string {
+	names := make([]string, 0, len(f.users))
+	for name := range f.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether username has an entry.
+func (f *HtpasswdFile) Has(username string) bool {
+	_, ok := f.users[username]
+	return ok
+}
+
+// SetPassword hashes password and stores it for username, replacing any existing entry.
+func (f *HtpasswdFile) SetPassword(username, password string, algo HashAlgorithm) error {
+	if err := validateUsername(username); err != nil {
+		return err
+	}
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+	hash, err := HashPassword(password, algo)
+	if err != nil {
+		return err
+	}
+	f.users[username] = hash
+	return nil
+}
+
+// Remove deletes username and reports whether it existed.
+func (f *HtpasswdFile) Remove(username string) bool {
+	if !f.Has(username) {
+		return false
+	}
+	delete(f.users, username)
+	return true
+}
+
+// Verify reports whether password is correct for username.
+func (f *HtpasswdFile) Verify(username, password string) bool {
+	hash, ok := f.users[username]
+	if !ok {
+		VerifyPassword(string(dummyHash), password)
+		return false
+	}
+	return VerifyPassword(hash, password)
+}
+
+// Save writes the file atomically with owner-only permissions.
+func (f *HtpasswdFile) Save() error {
+	var b strings.Builder
+	for _, name := range f.Usernames() {
+		fmt.Fprintf(&b, "%s:%s\n", name, f.users[name])
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// validateUsername rejects names that would corrupt the file format.
+func validateUsername(username string) error {
+	if username == "" {
+		return fmt.Errorf("username cannot be empty")
+	}
+	if strings.ContainsAny(username, ":\r\n") || strings.HasPrefix(username, "#") || strings.TrimSpace(username) != username {
+		return fmt.Errorf("username %q contains invalid characters", username)
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,27 +15,77 @@ import (
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
+// DefaultRefreshExpiry is how long refresh tokens stay valid unless overridden
+const DefaultRefreshExpiry = 7 * 24 * time.Hour
+
 // JWTProviderImpl implements JWT token generation and validation
 type JWTProviderImpl struct {
-	secretKey []byte
-	expiry    time.Duration
+	secretKey     []byte
+	expiry        time.Duration
+	refreshExpiry time.Duration
+	revoked       ports.TokenRevocationList
 }
 
 // NewJWTProvider creates a new JWT provider with the given secret key and token expiry
 func NewJWTProvider(secretKey string, expiry time.Duration) *JWTProviderImpl {
 	return &JWTProviderImpl{
-		secretKey: []byte(secretKey),
-		expiry:    expiry,
+		secretKey:     []byte(secretKey),
+		expiry:        expiry,
+		refreshExpiry: DefaultRefreshExpiry,
+		revoked:       NewMemoryRevocationList(),
 	}
 }
 
-// GenerateToken creates a new JWT token for the given username
+// WithRefreshExpiry overrides how long refresh tokens stay valid
+func (p *JWTProviderImpl) WithRefreshExpiry(expiry time.Duration) *JWTProviderImpl {
+	p.refreshExpiry = expiry
+	return p
+}
+
+// WithRevocationList replaces the in-memory revocation list
+func (p *JWTProviderImpl) WithRevocationList(list ports.TokenRevocationList) *JWTProviderImpl {
+	p.revoked = list
+	return p
+}
+
+// AccessExpiry returns the lifetime of access tokens
+func (p *JWTProviderImpl) AccessExpiry() time.Duration { return p.expiry }
+
+// RefreshExpiry returns the lifetime of refresh tokens
+func (p *JWTProviderImpl) RefreshExpiry() time.Duration { return p.refreshExpiry }
+
+// GenerateToken creates a new access token for the given username
 func (p *JWTProviderImpl) GenerateToken(username string) (string, error) {
+	return p.sign(username, ports.TokenTypeAccess, p.expiry)
+}
+
+// GenerateRefreshToken creates a new refresh token for the given username
+func (p *JWTProviderImpl) GenerateRefreshToken(username string) (string, error) {
+	return p.sign(username, ports.TokenTypeRefresh, p.refreshExpiry)
+}
+
+// RevokeToken adds the token ID to the revocation list
+func (p *JWTProviderImpl) RevokeToken(claims *ports.JWTClaims) error {
+	if claims.ID == "" {
+		return errors.New("token has no ID and cannot be revoked")
+	}
+	return p.revoked.Revoke(claims.ID, claims.ExpiresAt)
+}
+
+// sign builds and signs a token of the given type
+func (p *JWTProviderImpl) sign(username, tokenType string, expiry time.Duration) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	now := time.Now()
 	claims := map[string]interface{}{
-		"username": username,
-		"exp":      now.Add(p.expiry).Unix(),
-		"iat":      now.Unix(),
+		"jti":       id,
+		"username":  username,
+		"token_use": tokenType,
+		"exp":       now.Add(expiry).Unix(),
+		"iat":       now.Unix(),
 	}
 
 	// Create header
@@ -83,7 +135,7 @@ func (p *JWTProviderImpl) ValidateToken(tokenString string) (*ports.JWTClaims, e
 	h.Write([]byte(message))
 	expectedSignature := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 
-	if signatureEncoded != expectedSignature {
+	if !hmac.Equal([]byte(signatureEncoded), []byte(expectedSignature)) {
 		return nil, errors.New("invalid signature")
 	}
 
@@ -123,11 +175,32 @@ func (p *JWTProviderImpl) ValidateToken(tokenString string) (*ports.JWTClaims, e
 	}
 	issuedAt := time.Unix(int64(iatFloat), 0)
 
+	// Tokens issued before token IDs existed carry neither claim
+	id, _ := claims["jti"].(string)
+	if id != "" && p.revoked.IsRevoked(id) {
+		return nil, errors.New("token revoked")
+	}
+	tokenType, _ := claims["token_use"].(string)
+	if tokenType == "" {
+		tokenType = ports.TokenTypeAccess
+	}
+
 	return &ports.JWTClaims{
+		ID:        id,
 		Username:  username,
+		TokenType: tokenType,
 		ExpiresAt: expiresAt,
 		IssuedAt:  issuedAt,
 	}, nil
 }
 
+// newTokenID returns a random identifier for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 var _ ports.JWTProvider = (*JWTProviderImpl)(nil)
@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
 func TestJWTProvider(t *testing.T) {
@@ -54,3 +57,45 @@ func TestJWTProvider(t *testing.T) {
 		t.Fatal("Expected error for invalid token")
 	}
 }
+
+func TestJWTProviderRefreshAndRevocation(t *testing.T) {
+	provider := NewJWTProvider("test-secret-key", time.Hour).WithRefreshExpiry(24 * time.Hour)
+
+	refresh, err := provider.GenerateRefreshToken("testuser")
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+
+	claims, err := provider.ValidateToken(refresh)
+	if err != nil {
+		t.Fatalf("Failed to validate refresh token: %v", err)
+	}
+	if claims.TokenType != "refresh" {
+		t.Errorf("Expected token type refresh, got %s", claims.TokenType)
+	}
+	if claims.ID == "" {
+		t.Error("Expected refresh token to carry an ID")
+	}
+	if claims.ExpiresAt.Sub(claims.IssuedAt) != 24*time.Hour {
+		t.Errorf("Expected 24h lifetime, got %v", claims.ExpiresAt.Sub(claims.IssuedAt))
+	}
+
+	if err := provider.RevokeToken(claims); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, err := provider.ValidateToken(refresh); err == nil {
+		t.Fatal("Expected error for revoked token")
+	}
+	if err := provider.RevokeToken(claims); !errors.Is(err, ports.ErrTokenRevoked) {
+		t.Errorf("Expected revoking twice to fail with ErrTokenRevoked, got %v", err)
+	}
+
+	// Other tokens for the same user are unaffected
+	access, err := provider.GenerateToken("testuser")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := provider.ValidateToken(access); err != nil {
+		t.Fatalf("Expected unrelated token to stay valid: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// MemoryRevocationList keeps revoked token IDs in memory.
+// Entries are dropped once the token would have expired on its own.
+type MemoryRevocationList struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewMemoryRevocationList creates an empty revocation list.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{entries: make(map[string]time.Time)}
+}
+
+// Revoke records tokenID as revoked until expiresAt.
+// It fails with ports.ErrTokenRevoked if tokenID is revoked already.
+func (l *MemoryRevocationList) Revoke(tokenID string, expiresAt time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.pruneLocked(time.Now())
+	if _, ok := l.entries[tokenID]; ok {
+		return ports.ErrTokenRevoked
+	}
+	l.entries[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked reports whether tokenID has been revoked.
+func (l *MemoryRevocationList) IsRevoked(tokenID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.entries[tokenID]
+	return ok
+}
+
+// pruneLocked forgets entries whose tokens have expired. Caller holds mu.
+func (l *MemoryRevocationList) pruneLocked(now time.Time) {
+	for id, expiresAt := range l.entries {
+		if now.After(expiresAt) {
+			delete(l.entries, id)
+		}
+	}
+}
+
+var _ ports.TokenRevocationList = (*MemoryRevocationList)(nil)
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HashAlgorithm names a supported password hashing scheme.
+type HashAlgorithm string
+
+const (
+	HashBcrypt   HashAlgorithm = "bcrypt"
+	HashArgon2id HashAlgorithm = "argon2id"
+)
+
+// argon2id parameters for newly hashed passwords (RFC 9106 second recommended option)
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// Bounds on the argon2id parameters of stored hashes, so a bad line in the
+// credential file cannot make one login take unbounded memory or time.
+const (
+	maxArgon2Time    = 16
+	maxArgon2Memory  = 256 * 1024 // KiB
+	maxArgon2Threads = 16
+	minArgon2SaltLen = 8
+	minArgon2KeyLen  = 16
+	maxArgon2SaltLen = 64
+	maxArgon2KeyLen  = 64
+)
+
+// dummyHash is verified against when a username is unknown so that
+// missing users take as long to reject as wrong passwords.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// HashPassword hashes password with the given algorithm.
+// bcrypt hashes use the "$2a$" form; argon2id hashes use the PHC string format.
+func HashPassword(password string, algo HashAlgorithm) (string, error) {
+	switch algo {
+	case HashBcrypt, "":
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	case HashArgon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	return "", fmt.Errorf("unsupported hash algorithm %q", algo)
+}
+
+// VerifyPassword reports whether password matches hash.
+// Unknown or malformed hashes never match.
+func VerifyPassword(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	}
+	return false
+}
+
+// verifyArgon2id checks password against a PHC-formatted argon2id hash.
+func verifyArgon2id(hash, password string) bool {
+	// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+	fields := strings.Split(hash, "$")
+	if len(fields) != 6 {
+		return false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false
+	}
+	if time < 1 || time > maxArgon2Time || threads < 1 || threads > maxArgon2Threads ||
+		memory < 8*uint32(threads) || memory > maxArgon2Memory {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil || len(salt) < minArgon2SaltLen || len(salt) > maxArgon2SaltLen {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(want) < minArgon2KeyLen || len(want) > maxArgon2KeyLen {
+		return false
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
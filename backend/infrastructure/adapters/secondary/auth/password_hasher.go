@@ -0,0 +1,18 @@
+package auth
+
+import "github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+
+// PasswordHasher hashes with one algorithm and verifies any supported hash.
+type PasswordHasher struct {
+	Algorithm HashAlgorithm
+}
+
+func (h PasswordHasher) Hash(password string) (string, error) {
+	return HashPassword(password, h.Algorithm)
+}
+
+func (h PasswordHasher) Verify(hash, password string) bool {
+	return VerifyPassword(hash, password)
+}
+
+var _ ports.PasswordHasher = PasswordHasher{}
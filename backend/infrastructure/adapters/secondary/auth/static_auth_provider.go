@@ -1,6 +1,10 @@
 package auth
 
-import "github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+import (
+	"crypto/subtle"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
 
 // StaticAuthProvider implements simple static credential authentication.
 type StaticAuthProvider struct {
@@ -16,9 +20,16 @@ func NewStaticAuthProvider(username, password string) *StaticAuthProvider {
 	}
 }
 
-// Authenticate validates username and password.
+// Authenticate validates username and password in constant time.
 func (p *StaticAuthProvider) Authenticate(username, password string) bool {
-	return username == p.username && password == p.password
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(p.username))
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(p.password))
+	return userOK&passOK == 1
+}
+
+// UserExists reports whether username is the configured user.
+func (p *StaticAuthProvider) UserExists(username string) bool {
+	return subtle.ConstantTimeCompare([]byte(username), []byte(p.username)) == 1
 }
 
 var _ ports.AuthProvider = (*StaticAuthProvider)(nil)
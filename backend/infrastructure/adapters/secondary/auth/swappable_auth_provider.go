@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"sync/atomic"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// SwappableAuthProvider delegates to a provider that can be replaced while
+// requests are in flight, e.g. when the credential source changes on reload.
+type SwappableAuthProvider struct {
+	current atomic.Pointer[providerHolder]
+}
+
+// providerHolder lets an interface value live in an atomic.Pointer.
+type providerHolder struct {
+	ports.AuthProvider
+}
+
+// NewSwappableAuthProvider starts out delegating to initial.
+func NewSwappableAuthProvider(initial ports.AuthProvider) *SwappableAuthProvider {
+	p := &SwappableAuthProvider{}
+	p.Swap(initial)
+	return p
+}
+
+// Swap makes every later authentication use next.
+func (p *SwappableAuthProvider) Swap(next ports.AuthProvider) {
+	p.current.Store(&providerHolder{next})
+}
+
+// Authenticate asks the current provider.
+func (p *SwappableAuthProvider) Authenticate(username, password string) bool {
+	return p.current.Load().Authenticate(username, password)
+}
+
+// UserExists asks the current provider.
+func (p *SwappableAuthProvider) UserExists(username string) bool {
+	return p.current.Load().UserExists(username)
+}
+
+var _ ports.AuthProvider = (*SwappableAuthProvider)(nil)
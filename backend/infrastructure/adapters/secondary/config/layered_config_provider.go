@@ -0,0 +1,407 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// LayeredConfigProvider merges configuration from, lowest first: built-in
+// defaults, a YAML or TOML file, environment variables and command-line
+// flags. APP_ENV=production selects the locked-down defaults; the file is
+// named by -config or CONFIG_FILE.
+type LayeredConfigProvider struct {
+	settings    settings
+	production  bool
+	file        string
+	printConfig bool
+
+	authMode   ports.AuthMode
+	conflict   models.ConflictPolicy
+	accessMode models.AccessMode
+	symlinks   models.SymlinkPolicy
+}
+
+// NewLayeredConfigProvider builds the configuration from args (without the
+// program name) and the environment seen through lookupEnv, and validates
+// it. -h returns flag.ErrHelp.
+func NewLayeredConfigProvider(args []string, lookupEnv func(string) (string, bool)) (*LayeredConfigProvider, error) {
+	env := func(key string) string {
+		value, _ := lookupEnv(key)
+		return strings.TrimSpace(value)
+	}
+	p := &LayeredConfigProvider{production: env("APP_ENV") == "production"}
+
+	// Flags are parsed first to find -config, but applied last
+	type flagValue struct {
+		opt   option
+		value string
+	}
+	var flagged []flagValue
+	fs := flag.NewFlagSet("file-share", flag.ContinueOnError)
+	fs.StringVar(&p.file, "config", env("CONFIG_FILE"), "YAML (.yaml, .yml) or TOML (.toml) config file")
+	fs.BoolVar(&p.printConfig, "print-config", false, "print the effective configuration and exit")
+	for _, opt := range options {
+		if opt.flag == "" {
+			continue
+		}
+		record := func(value string) error {
+			flagged = append(flagged, flagValue{opt: opt, value: value})
+			return nil
+		}
+		if _, isBool := opt.field(&settings{}).(*bool); isBool {
+			fs.BoolFunc(opt.flag, opt.usage, record)
+		} else {
+			fs.Func(opt.flag, opt.usage, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	s, err := defaultSettings(p.production)
+	if err != nil {
+		return nil, err
+	}
+	if p.file != "" {
+		if err := decodeFile(p.file, &s); err != nil {
+			return nil, err
+		}
+	}
+	for _, opt := range options {
+		value := env(opt.env)
+		if opt.env == "" || value == "" {
+			continue
+		}
+		apply := func() error { return setValue(opt.field(&s), value) }
+		if opt.fromEnv != nil {
+			apply = func() error { return opt.fromEnv(&s, value) }
+		}
+		if err := apply(); err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", opt.env, err)
+		}
+	}
+	for _, f := range flagged {
+		if err := setValue(f.opt.field(&s), f.value); err != nil {
+			return nil, fmt.Errorf("flag -%s: %w", f.opt.flag, err)
+		}
+	}
+
+	// ACME keeps its state next to the generated certificates and needs
+	// port 80 for HTTP-01 challenges unless configured otherwise
+	if s.ACME.CacheDir == "" {
+		s.ACME.CacheDir = filepath.Join(s.TLS.CertDir, "acme")
+	}
+	if len(s.ACME.Domains) > 0 && s.TLS.RedirectAddr == "" {
+		s.TLS.RedirectAddr = ":80"
+	}
+
+	p.settings = s
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodeFile overlays the config file at path onto s. Unknown keys are
+// rejected so typos do not go unnoticed.
+func decodeFile(path string, s *settings) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(s); err != nil && err != io.EOF {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), s)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// validate reports every invalid setting at once, named as in the config file.
+func (p *LayeredConfigProvider) validate() error {
+	s := &p.settings
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+	checkVersions := func(key string, v versionSettings) {
+		check(v.Keep >= 0, key+".keep", "must not be negative")
+		check(v.MaxAge >= 0, key+".max_age", "must not be negative")
+	}
+
+	if info, err := os.Stat(s.RootDir); err != nil {
+		check(false, "root_dir", "%v", err)
+	} else {
+		check(info.IsDir(), "root_dir", "%s is not a directory", s.RootDir)
+	}
+	seen := make(map[string]bool)
+	for i, share := range s.Shares {
+		key := fmt.Sprintf("shares[%d]", i)
+		check(validShareName(share.Name), key+".name", "%q must be a single path segment", share.Name)
+		check(!seen[share.Name], key+".name", "%q is used by another share", share.Name)
+		seen[share.Name] = true
+		if info, err := os.Stat(share.Path); err != nil {
+			check(false, key+".path", "%v", err)
+		} else {
+			check(info.IsDir(), key+".path", "%s is not a directory", share.Path)
+		}
+		check(share.Quota >= 0, key+".quota", "must not be negative")
+		if share.Versions != nil {
+			checkVersions(key+".versions", *share.Versions)
+		}
+		if share.AccessMode != "" {
+			_, err := models.ParseAccessMode(share.AccessMode)
+			check(err == nil, key+".access_mode", "%v", err)
+		}
+	}
+	_, _, err := net.SplitHostPort(s.Listen)
+	check(err == nil, "listen", "%q is not a host:port address", s.Listen)
+	check(s.Timeouts.Read > 0, "timeouts.read", "must be positive")
+	check(s.Timeouts.Write >= 0, "timeouts.write", "must not be negative")
+	check(s.Timeouts.Shutdown > 0, "timeouts.shutdown", "must be positive")
+	check(s.Limits.MaxHeaderBytes > 0, "limits.max_header_bytes", "must be positive")
+	check(s.Limits.MaxUploadBytes >= 0, "limits.max_upload_bytes", "must not be negative")
+
+	check((s.TLS.CertFile == "") == (s.TLS.KeyFile == ""), "tls", "cert_file and key_file must be set together")
+	check(len(s.ACME.Domains) == 0 || s.TLS.CertFile == "", "acme.domains", "cannot be combined with tls.cert_file")
+	check(s.TLS.HSTSMaxAge >= 0, "tls.hsts_max_age", "must not be negative")
+	for key, addr := range map[string]string{"tls.redirect_addr": s.TLS.RedirectAddr, "metrics.addr": s.Metrics.Addr} {
+		if addr != "" {
+			_, _, err := net.SplitHostPort(addr)
+			check(err == nil, key, "%q is not a host:port address", addr)
+		}
+	}
+
+	p.accessMode, err = models.ParseAccessMode(s.AccessMode)
+	check(err == nil, "access_mode", "%v", err)
+	p.symlinks, err = models.ParseSymlinkPolicy(s.SymlinkPolicy)
+	check(err == nil, "symlink_policy", "%v", err)
+	p.authMode, err = ports.ParseAuthMode(s.Auth.Mode)
+	check(err == nil, "auth.mode", "%v", err)
+	check(!p.authMode.UsesJWT() || s.Auth.JWTSecret != "", "auth.jwt_secret", "must be set when auth.mode is jwt or both")
+	p.conflict, err = models.ParseConflictPolicy(s.Uploads.ConflictPolicy)
+	check(err == nil, "uploads.conflict_policy", "%v", err)
+	check(s.Trash.Retention >= 0, "trash.retention", "must not be negative")
+	checkVersions("versions", s.Versions)
+	check(s.Quotas.Root >= 0, "quotas.root", "must not be negative")
+	check(s.Quotas.User >= 0, "quotas.user", "must not be negative")
+	for user, limit := range s.Quotas.Users {
+		check(limit >= 0, "quotas.users."+user, "must not be negative")
+	}
+	check(s.Quotas.UsageFile != "", "quotas.usage_file", "must be set")
+	check(s.Links.File != "", "links.file", "must be set")
+	check(s.Log.Format == "json" || s.Log.Format == "text", "log.format", "must be json or text, got %q", s.Log.Format)
+	switch strings.ToLower(s.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level", "must be debug, info, warn or error, got %q", s.Log.Level)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validShareName accepts names that form exactly one path segment.
+func validShareName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// PrintRequested reports whether -print-config was given.
+func (p *LayeredConfigProvider) PrintRequested() bool { return p.printConfig }
+
+// PrintConfig writes the effective configuration as YAML, with secrets
+// redacted, in a form that can be used as a config file.
+func (p *LayeredConfigProvider) PrintConfig(w io.Writer) error {
+	s := p.settings
+	for _, secret := range []*string{&s.Auth.Password, &s.Auth.JWTSecret} {
+		if *secret != "" {
+			*secret = "REDACTED"
+		}
+	}
+	profile := "development"
+	if p.production {
+		profile = "production"
+	}
+	source := p.file
+	if source == "" {
+		source = "none"
+	}
+	if _, err := fmt.Fprintf(w, "# Effective configuration (profile: %s, config file: %s)\n", profile, source); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Changes lists the config file keys, such as "tls.hosts", whose values
+// differ in next, in sorted order.
+func (p *LayeredConfigProvider) Changes(next *LayeredConfigProvider) ([]string, error) {
+	before, err := flatten(p.settings)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatten(next.settings)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for key, value := range after {
+		if !reflect.DeepEqual(before[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// flatten maps each leaf of s to its dotted config file key.
+func flatten(s settings) (map[string]any, error) {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	flat := make(map[string]any)
+	var walk func(prefix string, node map[string]any)
+	walk = func(prefix string, node map[string]any) {
+		for key, value := range node {
+			if child, ok := value.(map[string]any); ok {
+				walk(prefix+key+".", child)
+			} else {
+				flat[prefix+key] = value
+			}
+		}
+	}
+	walk("", tree)
+	return flat, nil
+}
+
+func (p *LayeredConfigProvider) GetShares() []models.Share {
+	var shares []models.Share
+	for _, share := range p.settings.Shares {
+		var versions *models.VersionPolicy
+		if share.Versions != nil {
+			policy := share.Versions.policy()
+			versions = &policy
+		}
+		shares = append(shares, models.Share{
+			Name:     share.Name,
+			Path:     share.Path,
+			Mode:     models.AccessMode(share.AccessMode),
+			Quota:    share.Quota,
+			ACLFile:  share.ACLFile,
+			Versions: versions,
+		})
+	}
+	return shares
+}
+
+func (p *LayeredConfigProvider) GetAccessMode() models.AccessMode { return p.accessMode }
+
+func (p *LayeredConfigProvider) GetSymlinkPolicy() models.SymlinkPolicy { return p.symlinks }
+
+func (p *LayeredConfigProvider) GetPort() string {
+	_, port, _ := net.SplitHostPort(p.settings.Listen)
+	return port
+}
+func (p *LayeredConfigProvider) GetListenAddr() string                    { return p.settings.Listen }
+func (p *LayeredConfigProvider) GetStaticDir() string                     { return p.settings.StaticDir }
+func (p *LayeredConfigProvider) GetUsername() string                      { return p.settings.Auth.Username }
+func (p *LayeredConfigProvider) GetPassword() string                      { return p.settings.Auth.Password }
+func (p *LayeredConfigProvider) GetRootDir() string                       { return p.settings.RootDir }
+func (p *LayeredConfigProvider) EnableTLS() bool                          { return p.settings.TLS.Enabled }
+func (p *LayeredConfigProvider) GetAuthMode() ports.AuthMode              { return p.authMode }
+func (p *LayeredConfigProvider) GetJWTSecret() string                     { return p.settings.Auth.JWTSecret }
+func (p *LayeredConfigProvider) PublicHealth() bool                       { return p.settings.Auth.PublicHealth }
+func (p *LayeredConfigProvider) PublicStatic() bool                       { return p.settings.Auth.PublicStatic }
+func (p *LayeredConfigProvider) GetUsersFile() string                     { return p.settings.Auth.UsersFile }
+func (p *LayeredConfigProvider) GetACLFile() string                       { return p.settings.Auth.ACLFile }
+func (p *LayeredConfigProvider) GetUploadDir() string                     { return p.settings.Uploads.Dir }
+func (p *LayeredConfigProvider) GetConflictPolicy() models.ConflictPolicy { return p.conflict }
+func (p *LayeredConfigProvider) GetLinksFile() string                     { return p.settings.Links.File }
+func (p *LayeredConfigProvider) GetLogFormat() string                     { return p.settings.Log.Format }
+func (p *LayeredConfigProvider) GetLogLevel() string                      { return p.settings.Log.Level }
+func (p *LayeredConfigProvider) GetMetricsAddr() string                   { return p.settings.Metrics.Addr }
+func (p *LayeredConfigProvider) GetTLSCertFile() string                   { return p.settings.TLS.CertFile }
+func (p *LayeredConfigProvider) GetTLSKeyFile() string                    { return p.settings.TLS.KeyFile }
+func (p *LayeredConfigProvider) GetTLSCertDir() string                    { return p.settings.TLS.CertDir }
+func (p *LayeredConfigProvider) GetTLSHosts() []string                    { return p.settings.TLS.Hosts }
+func (p *LayeredConfigProvider) GetACMEDomains() []string                 { return p.settings.ACME.Domains }
+func (p *LayeredConfigProvider) GetACMEEmail() string                     { return p.settings.ACME.Email }
+func (p *LayeredConfigProvider) GetACMEDirectoryURL() string              { return p.settings.ACME.DirectoryURL }
+func (p *LayeredConfigProvider) GetACMECacheDir() string                  { return p.settings.ACME.CacheDir }
+func (p *LayeredConfigProvider) GetACMECAFile() string                    { return p.settings.ACME.CAFile }
+func (p *LayeredConfigProvider) GetHTTPRedirectAddr() string              { return p.settings.TLS.RedirectAddr }
+func (p *LayeredConfigProvider) GetHSTSMaxAge() time.Duration {
+	return time.Duration(p.settings.TLS.HSTSMaxAge)
+}
+func (p *LayeredConfigProvider) GetReadTimeout() time.Duration {
+	return time.Duration(p.settings.Timeouts.Read)
+}
+func (p *LayeredConfigProvider) GetWriteTimeout() time.Duration {
+	return time.Duration(p.settings.Timeouts.Write)
+}
+func (p *LayeredConfigProvider) GetShutdownTimeout() time.Duration {
+	return time.Duration(p.settings.Timeouts.Shutdown)
+}
+func (p *LayeredConfigProvider) GetVersionPolicy() models.VersionPolicy {
+	return p.settings.Versions.policy()
+}
+func (p *LayeredConfigProvider) TrashEnabled() bool { return p.settings.Trash.Enabled }
+func (p *LayeredConfigProvider) GetTrashRetention() time.Duration {
+	return time.Duration(p.settings.Trash.Retention)
+}
+func (p *LayeredConfigProvider) GetRootQuota() int64 { return p.settings.Quotas.Root }
+func (p *LayeredConfigProvider) GetUserQuota() int64 { return p.settings.Quotas.User }
+func (p *LayeredConfigProvider) GetUserQuotas() map[string]int64 {
+	return maps.Clone(p.settings.Quotas.Users)
+}
+func (p *LayeredConfigProvider) GetUsageFile() string     { return p.settings.Quotas.UsageFile }
+func (p *LayeredConfigProvider) GetMaxHeaderBytes() int   { return p.settings.Limits.MaxHeaderBytes }
+func (p *LayeredConfigProvider) GetMaxUploadBytes() int64 { return p.settings.Limits.MaxUploadBytes }
+
+var _ ports.ConfigProvider = (*LayeredConfigProvider)(nil)
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// envMap is a lookupEnv backed by a map.
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLayeredConfigPrecedence(t *testing.T) {
+	root := t.TempDir()
+	yamlFile := writeConfig(t, "config.yaml", `
+root_dir: `+root+`
+listen: 127.0.0.1:9000
+timeouts:
+  read: 5s
+  write: 1m
+limits:
+  max_upload_bytes: 100
+auth:
+  mode: jwt
+  jwt_secret: from-file
+log:
+  level: debug
+`)
+	tomlFile := writeConfig(t, "config.toml", `
+root_dir = "`+root+`"
+listen = "127.0.0.1:9000"
+
+[timeouts]
+read = "5s"
+write = "1m"
+
+[limits]
+max_upload_bytes = 100
+
+[auth]
+mode = "jwt"
+jwt_secret = "from-file"
+
+[log]
+level = "debug"
+`)
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		env := map[string]string{
+			"APP_ENV":          "production",
+			"CONFIG_FILE":      file,
+			"WRITE_TIMEOUT":    "2m",  // env beats the file
+			"MAX_UPLOAD_BYTES": "200", // and is beaten by the flag
+			"HSTS_MAX_AGE":     "60",
+		}
+		cfg, err := NewLayeredConfigProvider([]string{"-max-upload-bytes", "300", "-tls=false"}, envMap(env))
+		if err != nil {
+			t.Fatalf("%s: failed to load config: %v", file, err)
+		}
+
+		checks := []struct {
+			name      string
+			got, want any
+		}{
+			{"root", cfg.GetRootDir(), root},
+			{"listen", cfg.GetListenAddr(), "127.0.0.1:9000"},
+			{"port", cfg.GetPort(), "9000"},
+			{"read timeout", cfg.GetReadTimeout(), 5 * time.Second},
+			{"write timeout", cfg.GetWriteTimeout(), 2 * time.Minute},
+			{"shutdown timeout", cfg.GetShutdownTimeout(), 30 * time.Second},
+			{"max upload", cfg.GetMaxUploadBytes(), int64(300)},
+			{"tls", cfg.EnableTLS(), false},
+			{"hsts", cfg.GetHSTSMaxAge(), time.Minute},
+			{"auth mode", cfg.GetAuthMode(), ports.AuthModeJWT},
+			{"jwt secret", cfg.GetJWTSecret(), "from-file"},
+			{"log level", cfg.GetLogLevel(), "debug"},
+			{"log format", cfg.GetLogFormat(), "json"}, // production default
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("%s: expected %s %v, got %v", filepath.Ext(file), c.name, c.want, c.got)
+			}
+		}
+	}
+}
+
+func TestLayeredConfigProfiles(t *testing.T) {
+	dev, err := NewLayeredConfigProvider(nil, envMap(nil))
+	if err != nil {
+		t.Fatalf("Failed to load development config: %v", err)
+	}
+	if dev.EnableTLS() || dev.GetAuthMode() != ports.AuthModeNone || dev.GetPort() != "3000" {
+		t.Errorf("Unexpected development defaults: tls=%v auth=%s port=%s", dev.EnableTLS(), dev.GetAuthMode(), dev.GetPort())
+	}
+
+	prod, err := NewLayeredConfigProvider(nil, envMap(map[string]string{"APP_ENV": "production", "PORT": "8443"}))
+	if err != nil {
+		t.Fatalf("Failed to load production config: %v", err)
+	}
+	if !prod.EnableTLS() || prod.GetAuthMode() != ports.AuthModeBasic || prod.GetListenAddr() != ":8443" {
+		t.Errorf("Unexpected production config: tls=%v auth=%s listen=%s", prod.EnableTLS(), prod.GetAuthMode(), prod.GetListenAddr())
+	}
+}
+
+func TestLayeredConfigErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		want []string
+	}{
+		{"bad flag value", []string{"-read-timeout", "soon"}, nil, []string{"flag -read-timeout", "invalid duration"}},
+		{"bad env value", nil, map[string]string{"ENABLE_TLS": "maybe"}, []string{"ENABLE_TLS", "invalid boolean"}},
+		{"missing root", []string{"-root", "/does/not/exist"}, nil, []string{"root_dir"}},
+		{"several problems", []string{"-listen", "nope", "-auth-mode", "jwt", "-log-format", "xml"},
+			map[string]string{"JWT_SECRET": "", "APP_ENV": "production"},
+			[]string{"listen:", "auth.jwt_secret:", "log.format:"}},
+		{"half a key pair", []string{"-tls-cert", "cert.pem"}, nil, []string{"key_file must be set together"}},
+		{"unknown file key", []string{"-config", writeConfig(t, "c.yaml", "listne: :80\n")}, nil, []string{"listne"}},
+		{"unknown toml key", []string{"-config", writeConfig(t, "c.toml", "listne = \":80\"\n")}, nil, []string{"listne"}},
+		{"unsupported format", []string{"-config", writeConfig(t, "c.json", "{}")}, nil, []string{"unsupported format"}},
+		{"stray argument", []string{"serve"}, nil, []string{"unexpected argument"}},
+		{"bad shares", []string{"-config", writeConfig(t, "c.yaml", `
+shares:
+  - {name: a/b, path: /does/not/exist}
+  - {name: dup, path: /tmp}
+  - {name: dup, path: /tmp, quota: -1, access_mode: public, versions: {keep: -1}}
+`)}, nil, []string{"shares[0].name:", "shares[0].path:", "shares[2].name:", "shares[2].quota:", "shares[2].access_mode:", "shares[2].versions.keep:"}},
+		{"bad access mode", []string{"-access-mode", "append"}, nil, []string{"access_mode:"}},
+		{"bad symlink policy", nil, map[string]string{"SYMLINK_POLICY": "follow"}, []string{"symlink_policy:"}},
+		{"bad quotas", []string{"-config", writeConfig(t, "c.yaml", "quotas: {users: {alice: -5}}\n")},
+			map[string]string{"USER_QUOTA": "-1"}, []string{"quotas.user:", "quotas.users.alice:"}},
+	}
+	for _, tt := range tests {
+		_, err := NewLayeredConfigProvider(tt.args, envMap(tt.env))
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: expected error to mention %q, got %v", tt.name, want, err)
+			}
+		}
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	cfg, err := NewLayeredConfigProvider([]string{"-print-config"}, envMap(map[string]string{"PASSWORD": "hunter2"}))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.PrintRequested() {
+		t.Error("Expected -print-config to be reported")
+	}
+	var out bytes.Buffer
+	if err := cfg.PrintConfig(&out); err != nil {
+		t.Fatalf("Failed to print config: %v", err)
+	}
+	if strings.Contains(out.String(), "hunter2") || !strings.Contains(out.String(), "password: REDACTED") {
+		t.Errorf("Expected the password to be redacted:\n%s", out.String())
+	}
+
+	// The dump is itself a valid config file
+	reloaded, err := NewLayeredConfigProvider([]string{"-config", writeConfig(t, "dump.yaml", out.String())}, envMap(nil))
+	if err != nil {
+		t.Fatalf("Failed to load printed config: %v", err)
+	}
+	if reloaded.GetListenAddr() != cfg.GetListenAddr() || reloaded.GetRootDir() != cfg.GetRootDir() {
+		t.Errorf("Printed config did not round-trip: %s %s", reloaded.GetListenAddr(), reloaded.GetRootDir())
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	before, err := NewLayeredConfigProvider(nil, envMap(map[string]string{"TLS_HOSTS": "a.lan"}))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	after, err := NewLayeredConfigProvider([]string{"-listen", ":9999", "-log-level", "debug"}, envMap(map[string]string{"TLS_HOSTS": "a.lan,b.lan"}))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	changes, err := before.Changes(after)
+	if err != nil {
+		t.Fatalf("Failed to compare configs: %v", err)
+	}
+	if strings.Join(changes, " ") != "listen log.level tls.hosts" {
+		t.Errorf("Expected listen, log.level and tls.hosts to change, got %v", changes)
+	}
+	if changes, _ := after.Changes(after); len(changes) != 0 {
+		t.Errorf("Expected no changes against itself, got %v", changes)
+	}
+}
+
+func TestConfigShares(t *testing.T) {
+	projects, releases := t.TempDir(), t.TempDir()
+	file := writeConfig(t, "c.toml", `
+[[shares]]
+name = "projects"
+path = "`+projects+`"
+quota = 1000
+
+[[shares]]
+name = "releases"
+path = "`+releases+`"
+access_mode = "read-only"
+
+[shares.versions]
+keep = 3
+max_age = "24h"
+`)
+	cfg, err := NewLayeredConfigProvider([]string{"-config", file}, envMap(nil))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	shares := cfg.GetShares()
+	if len(shares) != 2 || shares[0].Name != "projects" || shares[0].Path != projects || shares[0].Quota != 1000 ||
+		shares[1].Name != "releases" || shares[1].Mode != models.AccessReadOnly || shares[0].Mode != "" {
+		t.Errorf("Unexpected shares: %+v", shares)
+	}
+	// Shares without a policy of their own inherit the server's
+	if shares[0].Versions != nil || cfg.GetVersionPolicy() != (models.VersionPolicy{Keep: 10}) {
+		t.Errorf("Expected projects to inherit the default policy, got %+v", shares[0].Versions)
+	}
+	if v := shares[1].Versions; v == nil || *v != (models.VersionPolicy{Keep: 3, MaxAge: 24 * time.Hour}) {
+		t.Errorf("Expected releases to keep 3 versions for a day, got %+v", v)
+	}
+}
+
+func TestConfigQuotas(t *testing.T) {
+	file := writeConfig(t, "c.yaml", "quotas:\n  user: 100\n  users: {alice: 0, bob: 50}\n")
+	cfg, err := NewLayeredConfigProvider([]string{"-config", file, "-root-quota", "1000"}, envMap(map[string]string{"USER_QUOTA": "200"}))
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.GetRootQuota() != 1000 || cfg.GetUserQuota() != 200 {
+		t.Errorf("Expected the flag and environment to set the quotas, got %d and %d", cfg.GetRootQuota(), cfg.GetUserQuota())
+	}
+	if users := cfg.GetUserQuotas(); len(users) != 2 || users["alice"] != 0 || users["bob"] != 50 {
+		t.Errorf("Expected per-user quotas from the file, got %v", users)
+	}
+	if cfg.GetUsageFile() == "" {
+		t.Error("Expected a default usage file")
+	}
+}
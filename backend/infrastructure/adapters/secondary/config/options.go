@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option binds one setting to its environment variable and command-line
+// flag. Either name may be empty; secrets have no flag so they never show
+// up in process listings.
+type option struct {
+	env   string
+	flag  string
+	usage string
+	field func(s *settings) any // Pointer to the value set by env and flag
+	// fromEnv overrides how the environment variable is applied
+	fromEnv func(s *settings, value string) error
+}
+
+// options lists every setting reachable from the environment or the command
+// line. Later entries win when two set the same value.
+var options = []option{
+	{env: "ROOT_DIR", flag: "root", usage: "directory to share", field: func(s *settings) any { return &s.RootDir }},
+	{env: "PORT", usage: "port to listen on", field: func(s *settings) any { return &s.Listen },
+		fromEnv: func(s *settings, value string) error { s.Listen = ":" + value; return nil }},
+	{env: "LISTEN_ADDR", flag: "listen", usage: "address to listen on, e.g. :22010 or 127.0.0.1:8443", field: func(s *settings) any { return &s.Listen }},
+	{env: "STATIC_DIR", flag: "static-dir", usage: "frontend build to serve at /, empty for none", field: func(s *settings) any { return &s.StaticDir }},
+	{env: "SYMLINK_POLICY", flag: "symlink-policy", usage: "deny, follow-within-root or follow-all symlinks in shared directories", field: func(s *settings) any { return &s.SymlinkPolicy }},
+	{env: "ACCESS_MODE", flag: "access-mode", usage: "read-write, read-only, drop-box or write-once", field: func(s *settings) any { return &s.AccessMode }},
+	{env: "READ_TIMEOUT", flag: "read-timeout", usage: "maximum time to read a request", field: func(s *settings) any { return &s.Timeouts.Read }},
+	{env: "WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum time to write a response", field: func(s *settings) any { return &s.Timeouts.Write }},
+	{env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "how long to let requests finish on shutdown", field: func(s *settings) any { return &s.Timeouts.Shutdown }},
+	{env: "MAX_HEADER_BYTES", flag: "max-header-bytes", usage: "maximum size of request headers", field: func(s *settings) any { return &s.Limits.MaxHeaderBytes }},
+	{env: "MAX_UPLOAD_BYTES", flag: "max-upload-bytes", usage: "maximum size of one uploaded file, 0 for no limit", field: func(s *settings) any { return &s.Limits.MaxUploadBytes }},
+	{env: "ENABLE_TLS", flag: "tls", usage: "serve HTTPS", field: func(s *settings) any { return &s.TLS.Enabled }},
+	{env: "TLS_CERT_FILE", flag: "tls-cert", usage: "PEM certificate to serve", field: func(s *settings) any { return &s.TLS.CertFile }},
+	{env: "TLS_KEY_FILE", flag: "tls-key", usage: "PEM private key for -tls-cert", field: func(s *settings) any { return &s.TLS.KeyFile }},
+	{env: "TLS_CERT_DIR", flag: "tls-cert-dir", usage: "where the generated local CA is kept", field: func(s *settings) any { return &s.TLS.CertDir }},
+	{env: "TLS_HOSTS", flag: "tls-hosts", usage: "comma-separated names and IPs the generated certificate covers", field: func(s *settings) any { return &s.TLS.Hosts }},
+	{env: "HTTP_REDIRECT_ADDR", flag: "http-redirect", usage: "plain HTTP listener that redirects to HTTPS", field: func(s *settings) any { return &s.TLS.RedirectAddr }},
+	{env: "HSTS_MAX_AGE", flag: "hsts-max-age", usage: "Strict-Transport-Security max-age, 0 to disable", field: func(s *settings) any { return &s.TLS.HSTSMaxAge },
+		fromEnv: func(s *settings, value string) error {
+			// The environment variable counts seconds, like the header itself
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number of seconds %q", value)
+			}
+			s.TLS.HSTSMaxAge = duration(time.Duration(seconds) * time.Second)
+			return nil
+		}},
+	{env: "ACME_DOMAINS", flag: "acme-domains", usage: "comma-separated domains to obtain ACME certificates for", field: func(s *settings) any { return &s.ACME.Domains }},
+	{env: "ACME_EMAIL", flag: "acme-email", usage: "contact address registered with the ACME CA", field: func(s *settings) any { return &s.ACME.Email }},
+	{env: "ACME_DIRECTORY_URL", flag: "acme-directory", usage: "ACME directory URL, Let's Encrypt when empty", field: func(s *settings) any { return &s.ACME.DirectoryURL }},
+	{env: "ACME_CACHE_DIR", flag: "acme-cache-dir", usage: "where ACME keys and certificates are cached", field: func(s *settings) any { return &s.ACME.CacheDir }},
+	{env: "ACME_CA_FILE", flag: "acme-ca-file", usage: "extra PEM roots trusted for the ACME directory", field: func(s *settings) any { return &s.ACME.CAFile }},
+	{env: "AUTH_MODE", flag: "auth-mode", usage: "none, basic, jwt or both", field: func(s *settings) any { return &s.Auth.Mode }},
+	{env: "USERNAME", field: func(s *settings) any { return &s.Auth.Username }},
+	{env: "PASSWORD", field: func(s *settings) any { return &s.Auth.Password }},
+	{env: "JWT_SECRET", field: func(s *settings) any { return &s.Auth.JWTSecret }},
+	{env: "USERS_FILE", flag: "users-file", usage: "htpasswd-style credential file", field: func(s *settings) any { return &s.Auth.UsersFile }},
+	{env: "ACL_FILE", flag: "acl-file", usage: "JSON access control policy", field: func(s *settings) any { return &s.Auth.ACLFile }},
+	{env: "PUBLIC_HEALTH", flag: "public-health", usage: "serve /health, /ready and /version without credentials", field: func(s *settings) any { return &s.Auth.PublicHealth }},
+	{env: "PUBLIC_STATIC", flag: "public-static", usage: "serve the frontend and API docs without credentials", field: func(s *settings) any { return &s.Auth.PublicStatic }},
+	{env: "UPLOAD_DIR", flag: "upload-dir", usage: "staging directory for resumable uploads", field: func(s *settings) any { return &s.Uploads.Dir }},
+	{env: "CONFLICT_POLICY", flag: "conflict-policy", usage: "overwrite, rename or reject existing upload names", field: func(s *settings) any { return &s.Uploads.ConflictPolicy }},
+	{env: "TRASH_ENABLED", flag: "trash", usage: "move deleted entries to a per-share .trash instead of removing them", field: func(s *settings) any { return &s.Trash.Enabled }},
+	{env: "TRASH_RETENTION", flag: "trash-retention", usage: "how long trashed entries are kept, 0 to keep them until purged", field: func(s *settings) any { return &s.Trash.Retention }},
+	{env: "VERSIONS_KEEP", flag: "versions-keep", usage: "earlier contents kept per overwritten file, 0 to turn versioning off", field: func(s *settings) any { return &s.Versions.Keep }},
+	{env: "VERSIONS_MAX_AGE", flag: "versions-max-age", usage: "prune versions replaced longer ago than this, 0 to keep them regardless of age", field: func(s *settings) any { return &s.Versions.MaxAge }},
+	{env: "ROOT_QUOTA", flag: "root-quota", usage: "bytes the root directory may hold when no shares are configured, 0 for no limit", field: func(s *settings) any { return &s.Quotas.Root }},
+	{env: "USER_QUOTA", flag: "user-quota", usage: "bytes each user's uploads may take, 0 for no limit", field: func(s *settings) any { return &s.Quotas.User }},
+	{env: "USAGE_FILE", flag: "usage-file", usage: "JSON file recording who uploaded each file", field: func(s *settings) any { return &s.Quotas.UsageFile }},
+	{env: "LINKS_FILE", flag: "links-file", usage: "JSON file where share links are kept", field: func(s *settings) any { return &s.Links.File }},
+	{env: "LOG_FORMAT", flag: "log-format", usage: "json or text", field: func(s *settings) any { return &s.Log.Format }},
+	{env: "LOG_LEVEL", flag: "log-level", usage: "debug, info, warn or error", field: func(s *settings) any { return &s.Log.Level }},
+	{env: "METRICS_ADDR", flag: "metrics-addr", usage: "separate listener for /metrics", field: func(s *settings) any { return &s.Metrics.Addr }},
+}
+
+// setValue parses value into the setting field points to.
+func setValue(field any, value string) error {
+	switch f := field.(type) {
+	case *string:
+		*f = value
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*f = parsed
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*f = parsed
+	case *int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		*f = parsed
+	case *duration:
+		return f.UnmarshalText([]byte(value))
+	case *[]string:
+		*f = splitList(value)
+	default:
+		return fmt.Errorf("unsupported setting type %T", field)
+	}
+	return nil
+}
+
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
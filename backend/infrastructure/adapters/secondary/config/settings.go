@@ -0,0 +1,228 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// settings is every configurable value, in the shape of the config file.
+type settings struct {
+	RootDir   string `yaml:"root_dir" toml:"root_dir"`
+	Listen    string `yaml:"listen" toml:"listen"`
+	StaticDir string `yaml:"static_dir" toml:"static_dir"`
+	// AccessMode is read-write, read-only, drop-box or write-once
+	AccessMode string `yaml:"access_mode" toml:"access_mode"`
+	// SymlinkPolicy is deny, follow-within-root or follow-all
+	SymlinkPolicy string `yaml:"symlink_policy" toml:"symlink_policy"`
+
+	// Shares replace root_dir with several named directories when set
+	Shares []shareSettings `yaml:"shares" toml:"shares"`
+
+	Timeouts struct {
+		Read     duration `yaml:"read" toml:"read"`
+		Write    duration `yaml:"write" toml:"write"`
+		Shutdown duration `yaml:"shutdown" toml:"shutdown"`
+	} `yaml:"timeouts" toml:"timeouts"`
+
+	Limits struct {
+		MaxHeaderBytes int   `yaml:"max_header_bytes" toml:"max_header_bytes"`
+		MaxUploadBytes int64 `yaml:"max_upload_bytes" toml:"max_upload_bytes"`
+	} `yaml:"limits" toml:"limits"`
+
+	TLS struct {
+		Enabled      bool     `yaml:"enabled" toml:"enabled"`
+		CertFile     string   `yaml:"cert_file" toml:"cert_file"`
+		KeyFile      string   `yaml:"key_file" toml:"key_file"`
+		CertDir      string   `yaml:"cert_dir" toml:"cert_dir"`
+		Hosts        []string `yaml:"hosts" toml:"hosts"`
+		RedirectAddr string   `yaml:"redirect_addr" toml:"redirect_addr"`
+		HSTSMaxAge   duration `yaml:"hsts_max_age" toml:"hsts_max_age"`
+	} `yaml:"tls" toml:"tls"`
+
+	ACME struct {
+		Domains      []string `yaml:"domains" toml:"domains"`
+		Email        string   `yaml:"email" toml:"email"`
+		DirectoryURL string   `yaml:"directory_url" toml:"directory_url"`
+		CacheDir     string   `yaml:"cache_dir" toml:"cache_dir"`
+		CAFile       string   `yaml:"ca_file" toml:"ca_file"`
+	} `yaml:"acme" toml:"acme"`
+
+	Auth struct {
+		Mode         string `yaml:"mode" toml:"mode"`
+		Username     string `yaml:"username" toml:"username"`
+		Password     string `yaml:"password" toml:"password"`
+		JWTSecret    string `yaml:"jwt_secret" toml:"jwt_secret"`
+		UsersFile    string `yaml:"users_file" toml:"users_file"`
+		ACLFile      string `yaml:"acl_file" toml:"acl_file"`
+		PublicHealth bool   `yaml:"public_health" toml:"public_health"`
+		PublicStatic bool   `yaml:"public_static" toml:"public_static"`
+	} `yaml:"auth" toml:"auth"`
+
+	Uploads struct {
+		Dir            string `yaml:"dir" toml:"dir"`
+		ConflictPolicy string `yaml:"conflict_policy" toml:"conflict_policy"`
+	} `yaml:"uploads" toml:"uploads"`
+
+	Trash struct {
+		Enabled bool `yaml:"enabled" toml:"enabled"`
+		// Retention is how long deleted entries are kept; zero keeps them until purged
+		Retention duration `yaml:"retention" toml:"retention"`
+	} `yaml:"trash" toml:"trash"`
+
+	// Versions is the version policy of the root directory and of shares without their own
+	Versions versionSettings `yaml:"versions" toml:"versions"`
+
+	Quotas struct {
+		// Root limits the root directory; shares set their own quota
+		Root int64 `yaml:"root" toml:"root"`
+		// User limits the bytes each user's uploads take; zero means no limit
+		User int64 `yaml:"user" toml:"user"`
+		// Users replaces User for the users listed
+		Users map[string]int64 `yaml:"users" toml:"users"`
+		// UsageFile records who uploaded each file
+		UsageFile string `yaml:"usage_file" toml:"usage_file"`
+	} `yaml:"quotas" toml:"quotas"`
+
+	Links struct {
+		File string `yaml:"file" toml:"file"`
+	} `yaml:"links" toml:"links"`
+
+	Log struct {
+		Format string `yaml:"format" toml:"format"`
+		Level  string `yaml:"level" toml:"level"`
+	} `yaml:"log" toml:"log"`
+
+	Metrics struct {
+		Addr string `yaml:"addr" toml:"addr"`
+	} `yaml:"metrics" toml:"metrics"`
+}
+
+// shareSettings describes one entry of the shares list.
+type shareSettings struct {
+	Name       string `yaml:"name" toml:"name"`
+	Path       string `yaml:"path" toml:"path"`
+	AccessMode string `yaml:"access_mode" toml:"access_mode"` // Empty inherits the server's mode
+	Quota      int64  `yaml:"quota" toml:"quota"`
+	ACLFile    string `yaml:"acl_file" toml:"acl_file"`
+	// Versions replaces the server's version policy for this share when set
+	Versions *versionSettings `yaml:"versions" toml:"versions"`
+}
+
+// versionSettings is a policy for keeping the contents overwritten files had.
+type versionSettings struct {
+	// Keep is how many versions each file keeps; zero turns versioning off
+	Keep int `yaml:"keep" toml:"keep"`
+	// MaxAge prunes versions replaced longer ago; zero keeps them regardless of age
+	MaxAge duration `yaml:"max_age" toml:"max_age"`
+}
+
+func (v versionSettings) policy() models.VersionPolicy {
+	return models.VersionPolicy{Keep: v.Keep, MaxAge: time.Duration(v.MaxAge)}
+}
+
+// defaultSettings returns the lowest configuration layer. Production
+// defaults are locked down; development ones serve the bundled frontend
+// over plain HTTP without authentication.
+func defaultSettings(production bool) (settings, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return settings{}, err
+	}
+
+	var s settings
+	s.RootDir = cwd
+	s.Listen = ":22010"
+	s.AccessMode = string(models.AccessReadWrite)
+	s.SymlinkPolicy = string(models.SymlinkFollowWithinRoot)
+	s.Timeouts.Read = duration(30 * time.Second)
+	s.Timeouts.Write = duration(30 * time.Second)
+	s.Timeouts.Shutdown = duration(30 * time.Second)
+	s.Limits.MaxHeaderBytes = 1 << 20
+	s.TLS.Enabled = true
+	s.TLS.CertDir = defaultCertDir()
+	s.TLS.HSTSMaxAge = duration(365 * 24 * time.Hour)
+	s.Auth.Mode = "basic"
+	s.Auth.Username = "admin"
+	s.Auth.Password = "admin"
+	s.Auth.PublicHealth = true
+	s.Auth.PublicStatic = true
+	s.Uploads.Dir = filepath.Join(os.TempDir(), "file-share-uploads")
+	s.Uploads.ConflictPolicy = "overwrite"
+	s.Trash.Enabled = true
+	s.Trash.Retention = duration(30 * 24 * time.Hour)
+	s.Versions.Keep = 10
+	s.Quotas.UsageFile = defaultUsageFile()
+	s.Links.File = defaultLinksFile()
+	s.Log.Format = "json"
+	s.Log.Level = "info"
+
+	if !production {
+		s.RootDir = devRootDir(cwd)
+		s.Listen = ":3000"
+		// `make run` starts the binary from the project root
+		s.StaticDir = "frontend/dist"
+		s.TLS.Enabled = false
+		s.Auth.Mode = "none"
+		s.Auth.JWTSecret = "dev-insecure-jwt-secret"
+		s.Log.Format = "text"
+	}
+	return s, nil
+}
+
+// devRootDir shares the frontend directory during development. When running
+// via `make run` from the project root it is "frontend"; from backend/ it is
+// "../frontend". As a last resort cwd is used.
+func devRootDir(cwd string) string {
+	for _, candidate := range []string{filepath.Join(cwd, "frontend"), filepath.Join(cwd, "..", "frontend")} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return cwd
+}
+
+// defaultCertDir keeps generated certificates in the user's config directory,
+// outside any shared root, so they survive restarts.
+func defaultCertDir() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "file-share", "tls")
+	}
+	return filepath.Join(os.TempDir(), "file-share-tls")
+}
+
+// defaultLinksFile keeps share links beside the generated certificates.
+func defaultLinksFile() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "file-share", "links.json")
+	}
+	return filepath.Join(os.TempDir(), "file-share-links.json")
+}
+
+// defaultUsageFile keeps the usage ledger beside the share links.
+func defaultUsageFile() string {
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "file-share", "usage.json")
+	}
+	return filepath.Join(os.TempDir(), "file-share-usage.json")
+}
+
+// duration reads and writes time.Duration values as strings like "30s".
+type duration time.Duration
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q (use a value like 30s or 5m)", text)
+	}
+	*d = duration(parsed)
+	return nil
+}
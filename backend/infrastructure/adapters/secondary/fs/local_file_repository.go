@@ -1,67 +1,185 @@
 package fs
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"net/http"
 	"os"
-	"path/filepath"
+	"path"
+	"slices"
 	"strings"
 
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
 	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
 	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/utils"
 )
 
+// tempFilePrefix marks in-progress writes. Such files are hidden from
+// listings and removed by CleanupTempFiles.
+const tempFilePrefix = ".fileshare-tmp-"
+
 // LocalFileRepository implements domain.FileRepository using local filesystem.
+// Every path is resolved beneath rootDir through a tree, so no request can
+// leave it except through a symlink the policy allows.
 type LocalFileRepository struct {
-	rootDir string
+	rootDir  string
+	symlinks models.SymlinkPolicy
+	versions models.VersionPolicy // Off unless set
+	quota    int64                // Zero means no limit
+	usage    *usageCounter
 }
 
-// NewLocalFileRepository creates a new file repository adapter.
+// NewLocalFileRepository creates a new file repository adapter that follows
+// symlinks only while they stay inside rootDir.
 func NewLocalFileRepository(rootDir string) *LocalFileRepository {
-	return &LocalFileRepository{rootDir: rootDir}
+	return &LocalFileRepository{rootDir: rootDir, symlinks: models.SymlinkFollowWithinRoot, usage: &usageCounter{}}
+}
+
+// WithSymlinkPolicy sets which symlinks inside rootDir are followed
+func (r *LocalFileRepository) WithSymlinkPolicy(policy models.SymlinkPolicy) *LocalFileRepository {
+	r.symlinks = policy
+	return r
+}
+
+// open starts an operation on rootDir. The root is opened per operation so
+// a directory that is replaced while the server runs is picked up. The
+// reserved directories are hidden; openAll also reaches them.
+func (r *LocalFileRepository) open() (tree, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return nil, err
+	}
+	return hidingTree{t}, nil
 }
 
-// resolve converts a relative path to absolute within rootDir.
-func (r *LocalFileRepository) resolve(path string) string {
-	cleaned := filepath.FromSlash(strings.TrimPrefix(path, "/"))
-	return filepath.Join(r.rootDir, cleaned)
+func (r *LocalFileRepository) openAll() (tree, error) {
+	return openTree(r.rootDir, r.symlinks)
 }
 
 // ListDirectory returns metadata for all entries in a directory.
-func (r *LocalFileRepository) ListDirectory(path string) ([]*models.FileInfo, error) {
-	fullPath := r.resolve(path)
-	entries, err := os.ReadDir(fullPath)
+func (r *LocalFileRepository) ListDirectory(p string) ([]*models.FileInfo, error) {
+	t, err := r.open()
 	if err != nil {
 		return nil, err
 	}
+	defer t.Close()
+
+	dir := treeName(p)
+	d, err := t.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := d.ReadDir(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return cmp.Compare(a.Name(), b.Name()) })
 
 	var files []*models.FileInfo
 	for _, entry := range entries {
 		name := entry.Name()
-		url := "/" + filepath.ToSlash(filepath.Join(strings.TrimPrefix(path, "/"), name))
-		zipURL := url + ".zip"
-		if path == "/" || path == "" {
-			url = "/" + name
-			zipURL = "/" + name + ".zip"
+		if strings.HasPrefix(name, tempFilePrefix) || reserved(path.Join(dir, name)) {
+			continue
 		}
+		fileInfo, err := entry.Info()
+		if err != nil {
+			continue // Removed between ReadDir and Info
+		}
+		files = append(files, describe(t, path.Join(dir, name), path.Join("/", dir, name), fileInfo, false))
+	}
+	return files, nil
+}
 
-		fileInfo, _ := entry.Info()
-		size := formatFileSize(fileInfo.Size(), entry.IsDir())
+// Stat returns metadata for the entry at path.
+func (r *LocalFileRepository) Stat(p string) (*models.FileInfo, error) {
+	t, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
 
-		files = append(files, &models.FileInfo{
-			Name:   name,
-			URL:    url,
-			ZipURL: zipURL,
-			Size:   size,
-			IsDir:  entry.IsDir(),
-		})
+	name := treeName(p)
+	info, err := t.Lstat(name)
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: p}
 	}
-	return files, nil
+	if err != nil {
+		return nil, err
+	}
+
+	fileInfo := describe(t, name, path.Join("/", name), info, true)
+	if name == "." {
+		fileInfo.Name = "/"
+	}
+	return fileInfo, nil
+}
+
+// describe builds a FileInfo from lstat data. Symlinks report their target
+// and, where the policy lets them be followed, the type and size of what
+// they point to. MIME types come from the extension; sniff additionally
+// reads the content when that is inconclusive.
+func describe(t tree, name, url string, info os.FileInfo, sniff bool) *models.FileInfo {
+	fileInfo := &models.FileInfo{
+		Name:    info.Name(),
+		URL:     url,
+		ZipURL:  url + ".zip",
+		ModTime: info.ModTime(),
+		Mode:    info.Mode(),
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		fileInfo.SymlinkTarget, _ = t.Readlink(name)
+		if target, err := t.Stat(name); err == nil {
+			info = target
+		}
+	}
+
+	fileInfo.IsDir = info.IsDir()
+	if fileInfo.IsDir {
+		fileInfo.MimeType = "inode/directory"
+		return fileInfo
+	}
+
+	fileInfo.Size = info.Size()
+	fileInfo.MimeType = detectMimeType(t, name, fileInfo.SymlinkTarget, sniff)
+	return fileInfo
+}
+
+// detectMimeType guesses a MIME type from the extension (of the link target
+// for symlinks), falling back to content sniffing when allowed and to
+// application/octet-stream otherwise.
+func detectMimeType(t tree, name, linkTarget string, sniff bool) string {
+	ext := path.Ext(name)
+	if linkTarget != "" {
+		ext = path.Ext(linkTarget)
+	}
+	if byExt := mime.TypeByExtension(ext); byExt != "" {
+		return byExt
+	}
+	if sniff {
+		if file, err := t.Open(name); err == nil {
+			defer file.Close()
+			head := make([]byte, 512)
+			n, _ := io.ReadFull(file, head)
+			return http.DetectContentType(head[:n])
+		}
+	}
+	return "application/octet-stream"
 }
 
 // IsDirectory checks if the path is a directory.
-func (r *LocalFileRepository) IsDirectory(path string) (bool, error) {
-	info, err := os.Stat(r.resolve(path))
+func (r *LocalFileRepository) IsDirectory(p string) (bool, error) {
+	t, err := r.open()
+	if err != nil {
+		return false, err
+	}
+	defer t.Close()
+
+	info, err := t.Stat(treeName(p))
 	if err != nil {
 		return false, err
 	}
@@ -69,54 +187,304 @@ func (r *LocalFileRepository) IsDirectory(path string) (bool, error) {
 }
 
 // FileExists checks if a file or directory exists at the given path.
-func (r *LocalFileRepository) FileExists(path string) (bool, error) {
-	_, err := os.Stat(r.resolve(path))
+func (r *LocalFileRepository) FileExists(p string) (bool, error) {
+	t, err := r.open()
+	if err != nil {
+		return false, err
+	}
+	defer t.Close()
+
+	_, err = t.Stat(treeName(p))
 	if os.IsNotExist(err) {
 		return false, nil
 	}
 	return err == nil, err
 }
 
-// ServeFile opens a file for reading and returns its stream and name.
-func (r *LocalFileRepository) ServeFile(path string) (models.ReadCloser, string, error) {
-	fullPath := r.resolve(path)
-	file, err := os.Open(fullPath)
+// ServeFile opens a file for reading and returns it with its name, size and modification time.
+func (r *LocalFileRepository) ServeFile(p string) (*models.FileContent, error) {
+	t, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close() // The open file stays usable
+
+	name := treeName(p)
+	file, err := t.Open(name)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	return file, filepath.Base(fullPath), nil
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &models.FileContent{
+		Content: file,
+		Name:    path.Base(name),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
 }
 
 // CreateDirectory creates all directories in the given path.
-func (r *LocalFileRepository) CreateDirectory(path string) error {
-	return os.MkdirAll(r.resolve(path), 0755)
+func (r *LocalFileRepository) CreateDirectory(p string) error {
+	t, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	return t.MkdirAll(treeName(p), 0755)
 }
 
-// WriteFile writes content from reader to the specified file path.
-func (r *LocalFileRepository) WriteFile(path string, reader models.ReadCloser) (int64, error) {
+// WriteFile streams reader into a temporary file beside path, syncs it and
+// moves it into place according to policy, so an interrupted write never
+// leaves a truncated file under the final name. With versioning on, a file
+// it overwrites is kept as a version. The write fails with a
+// QuotaExceededError as soon as it would cross the quota, after evicting
+// trashed entries and versions that stand in its way.
+func (r *LocalFileRepository) WriteFile(p string, reader models.ReadCloser, policy models.ConflictPolicy) (string, int64, error) {
 	defer reader.Close()
 
-	fullPath := r.resolve(path)
-	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
-		return 0, err
+	all, err := r.openAll()
+	if err != nil {
+		return "", 0, err
+	}
+	defer all.Close()
+	t := hidingTree{all}
+	r.usage.measure(all)
+
+	name := treeName(p)
+	var replaced int64
+	if info, err := t.Stat(name); err == nil && info.IsDir() {
+		return "", 0, &errors.ConflictError{Path: p, Reason: "is a directory"}
+	} else if err == nil && policy == models.ConflictOverwrite {
+		replaced = sizeAt(t, name)
+	}
+	dir := path.Dir(name)
+	if err := t.MkdirAll(dir, 0755); err != nil {
+		return "", 0, err
+	}
+
+	tmp, tmpName, err := createTemp(t, dir)
+	if err != nil {
+		return "", 0, err
+	}
+	// Harmless after a rename; drops the extra name after a link
+	defer t.Remove(tmpName)
+
+	body := &reservingReader{
+		ReadCloser: reader,
+		usage:      r.usage,
+		limit:      r.quota,
+		credit:     replaced,
+		makeRoom:   func(credit int64) { r.makeRoom(all, credit) },
+		err:        r.quotaFor(p),
+	}
+	// Released unless the file lands
+	defer func() { r.usage.add(-body.reserved) }()
+	written, err := io.Copy(tmp, body)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	var finalName string
+	err = r.countVersions(all, name, func() error {
+		finalName, err = placeVersioned(all, tmpName, name, policy, r.versions)
+		return err
+	})
+	if err != nil {
+		if os.IsExist(err) {
+			return "", 0, &errors.ConflictError{Path: p, Reason: "already exists"}
+		}
+		return "", 0, err
+	}
+	syncDir(t, dir)
+	// The reserved bytes now count as the file's
+	body.reserved = 0
+	if finalName == name {
+		r.usage.add(-replaced)
+		// A replaced file kept as a version is held from now on
+		r.makeRoom(all, 0)
+	} else {
+		// Report the numbered name in the caller's own path form
+		p = strings.TrimSuffix(p, path.Base(name)) + path.Base(finalName)
+	}
+	return p, written, nil
+}
+
+// maxRenameAttempts bounds the search for a free "name (n).ext".
+const maxRenameAttempts = 1000
+
+// placeFile moves the finished temporary file to name, or to the first
+// free numbered variant of it under ConflictRename. It returns the name used.
+func placeFile(t tree, tmpName, name string, policy models.ConflictPolicy) (string, error) {
+	switch policy {
+	case models.ConflictOverwrite:
+		return name, t.Rename(tmpName, name)
+	case models.ConflictReject:
+		return name, linkNew(t, tmpName, name)
+	case models.ConflictRename:
+		for n := 0; n < maxRenameAttempts; n++ {
+			candidate := numberedName(name, n)
+			err := linkNew(t, tmpName, candidate)
+			if os.IsExist(err) {
+				continue
+			}
+			return candidate, err
+		}
+		return "", os.ErrExist
+	}
+	return "", fmt.Errorf("unknown conflict policy %q", policy)
+}
+
+// placeVersioned is placeFile that, with versioning on, keeps a file it
+// overwrites as a version. The file is saved before the rename, which loses
+// it, but older versions are only pruned once the new file is in place; a
+// failed placement takes the saved version back.
+func placeVersioned(all tree, tmpName, name string, policy models.ConflictPolicy, versions models.VersionPolicy) (string, error) {
+	if policy != models.ConflictOverwrite || !versions.Enabled() {
+		return placeFile(hidingTree{all}, tmpName, name, policy)
 	}
+	kept, err := saveVersion(all, name)
+	if err != nil {
+		return "", err
+	}
+	finalName, err := placeFile(hidingTree{all}, tmpName, name, policy)
+	if kept == "" {
+		return finalName, err
+	}
+	if err != nil {
+		all.Remove(kept)
+		all.Remove(path.Dir(kept)) // Only if that left it empty
+		return "", err
+	}
+	// The write stands even if pruning fails; the periodic pruning catches up
+	_, _ = pruneVersions(all, name, versions)
+	return finalName, nil
+}
 
-	dst, err := os.Create(fullPath)
+// linkNew gives tmpName the name dst only if dst does not exist yet. A hard
+// link makes the check and the creation one atomic step.
+func linkNew(t tree, tmpName, dst string) error {
+	err := t.Link(tmpName, dst)
+	if err == nil || os.IsExist(err) {
+		return err
+	}
+	// No hard links on this filesystem; fall back to check-then-rename
+	if _, statErr := t.Lstat(dst); statErr == nil {
+		return os.ErrExist
+	}
+	return t.Rename(tmpName, dst)
+}
+
+// renameNew renames oldname to newname only if newname does not exist yet,
+// failing with an error satisfying os.IsExist otherwise. Where that cannot
+// be one step, a file is linked to its new name and then unlinked, and a
+// directory falls back to check-then-rename.
+func renameNew(t tree, oldname, newname string) error {
+	if ok, err := t.RenameNoReplace(oldname, newname); ok {
+		return err
+	}
+	info, err := t.Lstat(oldname)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		err := t.Link(oldname, newname)
+		if err == nil {
+			if err := t.Remove(oldname); err != nil {
+				t.Remove(newname)
+				return err
+			}
+			return nil
+		}
+		if os.IsExist(err) {
+			return err
+		}
+		// No hard links on this filesystem
+	}
+	if _, err := t.Lstat(newname); err == nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: os.ErrExist}
+	}
+	return t.Rename(oldname, newname)
+}
+
+// numberedName returns name for n == 0 and "name (n).ext" otherwise.
+func numberedName(name string, n int) string {
+	if n == 0 {
+		return name
+	}
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// syncDir flushes a directory entry change to disk. Not every platform
+// supports it, so failures are ignored.
+func syncDir(t tree, dir string) {
+	if d, err := t.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+// CleanupTempFiles removes temporary files left behind by writes that were
+// interrupted, e.g. by a crash. Call it at startup before serving requests.
+func (r *LocalFileRepository) CleanupTempFiles() (int, error) {
+	t, err := r.open()
 	if err != nil {
 		return 0, err
 	}
-	defer dst.Close()
+	defer t.Close()
 
-	return io.Copy(dst, reader)
+	removed := 0
+	err = fs.WalkDir(treeFS{t}, ".", func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if current == "." {
+				return err
+			}
+			return nil // Unreadable subtree; leave it alone
+		}
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), tempFilePrefix) {
+			if err := t.Remove(current); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
 }
 
-// ZipDirectory returns a streaming ZIP archive of the directory.
-func (r *LocalFileRepository) ZipDirectory(root string) (models.ReadCloser, error) {
+// ZipDirectory returns a streaming ZIP archive of the directory. Symlinks
+// are included only as far as the policy lets them be followed.
+func (r *LocalFileRepository) ZipDirectory(root string, include func(path string, isDir bool) bool) (models.ReadCloser, error) {
+	t, err := r.open()
+	if err != nil {
+		return nil, err
+	}
 	pr, pw := io.Pipe()
 
+	dir := treeName(root)
+	filter := func(relPath string, isDir bool) bool {
+		if reserved(path.Join(dir, relPath)) {
+			return false
+		}
+		return include == nil || include(path.Join("/", root, relPath), isDir)
+	}
+
 	go func() {
+		defer t.Close()
 		defer pw.Close()
-		if err := utils.ZipDirectory(r.resolve(root), pw); err != nil {
+		if err := utils.ZipDirectory(treeFS{t}, dir, pw, filter); err != nil {
 			pw.CloseWithError(err)
 		}
 	}()
@@ -124,19 +492,208 @@ func (r *LocalFileRepository) ZipDirectory(root string) (models.ReadCloser, erro
 	return pr, nil
 }
 
-// formatFileSize returns human-readable size string.
-func formatFileSize(size int64, isDir bool) string {
-	if isDir {
-		return "[Directory]"
+// Delete removes a file or directory, and the versions of its files. Without
+// recursive, only files and empty directories can be removed.
+func (r *LocalFileRepository) Delete(p string, recursive bool) error {
+	all, err := r.openAll()
+	if err != nil {
+		return err
+	}
+	defer all.Close()
+	t := hidingTree{all}
+	r.usage.measure(all)
+
+	name := treeName(p)
+	size := sizeAt(t, name)
+	if recursive {
+		err = t.RemoveAll(name)
+	} else {
+		err = t.Remove(name)
+	}
+	if err != nil {
+		// Part of a tree may be gone; count what is left
+		size -= sizeAt(t, name)
+	}
+	r.usage.add(-size)
+	if err != nil {
+		return err
+	}
+	return r.countVersions(all, name, func() error { return dropVersions(all, name) })
+}
+
+// Move renames src to dst, creating dst's parent directories as needed.
+// The versions of its files move with it. A file it overwrites is kept as
+// a version, as by WriteFile. Without overwrite, anything at dst, even an
+// empty directory or an entry that appeared since the caller looked, fails
+// the move with a ConflictError.
+func (r *LocalFileRepository) Move(src, dst string, overwrite bool) error {
+	all, err := r.openAll()
+	if err != nil {
+		return err
+	}
+	defer all.Close()
+	t := hidingTree{all}
+	r.usage.measure(all)
+
+	from, to := treeName(src), treeName(dst)
+	if err := t.MkdirAll(path.Dir(to), 0755); err != nil {
+		return err
+	}
+	if overwrite {
+		if err := r.countVersions(all, to, func() error { return r.keepReplaced(all, to) }); err != nil {
+			return err
+		}
+		replaced := sizeAt(t, to)
+		err := t.RemoveAll(to)
+		r.usage.add(sizeAt(t, to) - replaced)
+		r.makeRoom(all, 0)
+		if err != nil {
+			return err
+		}
+	}
+	if overwrite {
+		err = t.Rename(from, to)
+	} else {
+		err = renameNew(t, from, to)
+	}
+	if os.IsExist(err) {
+		return &errors.ConflictError{Path: dst, Reason: "destination already exists"}
+	}
+	if err != nil {
+		return err
+	}
+	return moveVersions(all, path.Join(versionsDir, from), path.Join(versionsDir, to))
+}
+
+// Copy duplicates a file or directory tree, without its versions. A file it
+// overwrites is kept as a version, as by WriteFile. Symlinks and other
+// special files inside a directory are skipped. A copy that may
+// not fit in the quota fails with a QuotaExceededError before anything is
+// copied. Without overwrite, an entry at dst fails the copy with a
+// ConflictError, as for Move; a directory is never merged into one.
+func (r *LocalFileRepository) Copy(src, dst string, overwrite bool, include func(path string, isDir bool) bool) error {
+	err := r.copy(src, dst, overwrite, include)
+	if os.IsExist(err) {
+		return &errors.ConflictError{Path: dst, Reason: "destination already exists"}
+	}
+	return err
+}
+
+func (r *LocalFileRepository) copy(src, dst string, overwrite bool, include func(path string, isDir bool) bool) error {
+	all, err := r.openAll()
+	if err != nil {
+		return err
+	}
+	defer all.Close()
+	t := hidingTree{all}
+	used := r.usage.measure(all)
+
+	from, to := treeName(src), treeName(dst)
+	info, err := t.Stat(from)
+	if err != nil {
+		return err
+	}
+	existing := sizeAt(t, to)
+	var freed int64
+	if overwrite {
+		freed = existing
+	}
+	if r.quota > 0 && used-freed+treeSize(t, from, info) > r.quota {
+		return r.quotaFor(dst)
+	}
+	// Whatever happens, count what ended up at dst
+	defer func() {
+		r.usage.add(sizeAt(t, to) - existing)
+		r.makeRoom(all, 0)
+	}()
+	if overwrite {
+		if err := r.countVersions(all, to, func() error { return r.keepReplaced(all, to) }); err != nil {
+			return err
+		}
+		if err := t.RemoveAll(to); err != nil {
+			return err
+		}
+	}
+	if !info.IsDir() {
+		if err := t.MkdirAll(path.Dir(to), 0755); err != nil {
+			return err
+		}
+		return copyFile(t, from, to, info.Mode().Perm(), overwrite)
+	}
+	if !overwrite {
+		// Creating the top directory claims dst in one step
+		if err := t.MkdirAll(path.Dir(to), 0755); err != nil {
+			return err
+		}
+		if err := t.Mkdir(to, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+	}
+
+	return fs.WalkDir(treeFS{t}, from, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath := relative(from, current)
+		if relPath != "." && (reserved(current) || include != nil && !include(path.Join("/", src, relPath), entry.IsDir())) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		target := path.Join(to, relPath)
+		switch {
+		case entry.IsDir():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return t.MkdirAll(target, info.Mode().Perm()|0700)
+		case entry.Type().IsRegular():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			return copyFile(t, current, target, info.Mode().Perm(), overwrite)
+		}
+		return nil
+	})
+}
+
+// copyFile copies src to a temporary file beside dst and renames it into
+// place. Without overwrite, an existing dst fails it with an error
+// satisfying os.IsExist.
+func copyFile(t tree, src, dst string, perm os.FileMode, overwrite bool) error {
+	in, err := t.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, tmpName, err := createTemp(t, path.Dir(dst))
+	if err != nil {
+		return err
+	}
+	defer t.Remove(tmpName)
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
 	}
-	const unit = 1024
-	if size < unit {
-		return fmt.Sprintf("%d B", size)
+	if err := tmp.Close(); err != nil {
+		return err
 	}
-	div, exp := int64(unit), 0
-	for n := size / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	if !overwrite {
+		return renameNew(t, tmpName, dst)
 	}
-	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+	return t.Rename(tmpName, dst)
 }
@@ -0,0 +1,230 @@
+package fs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	domainerrors "github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// failingReader returns some data and then an error, like a dropped upload.
+type failingReader struct{ sent bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func (r *failingReader) Close() error { return nil }
+
+func TestWriteFileConflictPolicies(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	write := func(name, content string, policy models.ConflictPolicy) (string, error) {
+		stored, _, err := repo.WriteFile(name, io.NopCloser(strings.NewReader(content)), policy)
+		return stored, err
+	}
+
+	tests := []struct {
+		policy     models.ConflictPolicy
+		content    string
+		wantStored string
+		wantErr    bool
+	}{
+		{models.ConflictReject, "first", "docs/report.txt", false},
+		{models.ConflictReject, "second", "", true},
+		{models.ConflictRename, "third", "docs/report (1).txt", false},
+		{models.ConflictRename, "fourth", "docs/report (2).txt", false},
+		{models.ConflictOverwrite, "fifth", "docs/report.txt", false},
+	}
+	for _, tt := range tests {
+		stored, err := write("docs/report.txt", tt.content, tt.policy)
+		if tt.wantErr {
+			if _, ok := err.(*domainerrors.ConflictError); !ok {
+				t.Errorf("%s: expected ConflictError, got %v", tt.policy, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to write: %v", tt.policy, err)
+		}
+		if stored != tt.wantStored {
+			t.Errorf("%s: expected stored path %q, got %q", tt.policy, tt.wantStored, stored)
+		}
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(stored)))
+		if err != nil || string(data) != tt.content {
+			t.Errorf("%s: expected %q at %s, got %q (%v)", tt.policy, tt.content, stored, data, err)
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := write("dir", "x", models.ConflictOverwrite); err == nil {
+		t.Error("Expected writing over a directory to fail")
+	}
+}
+
+func TestMoveWithoutOverwriteNeverReplaces(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "dir/c.txt": "c"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository enforces this itself, whatever the caller checked
+	for _, tt := range []struct{ src, dst string }{
+		{"/a.txt", "/b.txt"},
+		{"/dir", "/empty"},
+		{"/a.txt", "/empty"},
+	} {
+		err := repo.Move(tt.src, tt.dst, false)
+		if _, ok := err.(*domainerrors.ConflictError); !ok {
+			t.Errorf("Expected moving %s onto %s to conflict, got %T %v", tt.src, tt.dst, err, err)
+		}
+	}
+	for name, want := range map[string]string{"a.txt": "a", "b.txt": "b", "dir/c.txt": "c"} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q %v", name, want, data, err)
+		}
+	}
+	if err := repo.Move("/a.txt", "/moved/a.txt", false); err != nil {
+		t.Errorf("Failed to move to a free name: %v", err)
+	}
+}
+
+func TestCopyWithoutOverwriteNeverReplaces(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	for name, content := range map[string]string{"a.txt": "a", "b.txt": "b", "d1/x": "new", "d2/x": "old"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ src, dst string }{
+		{"/a.txt", "/b.txt"},
+		{"/d1", "/d2"},
+		{"/d1", "/empty"},
+		{"/a.txt", "/empty"},
+	} {
+		err := repo.Copy(tt.src, tt.dst, false, nil)
+		if _, ok := err.(*domainerrors.ConflictError); !ok {
+			t.Errorf("Expected copying %s onto %s to conflict, got %T %v", tt.src, tt.dst, err, err)
+		}
+	}
+	for name, want := range map[string]string{"a.txt": "a", "b.txt": "b", "d1/x": "new", "d2/x": "old"} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q %v", name, want, data, err)
+		}
+	}
+	if entries, err := os.ReadDir(filepath.Join(root, "empty")); err != nil || len(entries) != 0 {
+		t.Errorf("Expected empty to stay empty, got %v %v", entries, err)
+	}
+
+	if err := repo.Copy("/d1", "/d3", false, nil); err != nil {
+		t.Fatalf("Failed to copy to a free name: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "d3", "x")); err != nil || string(data) != "new" {
+		t.Errorf("Expected d3/x to hold %q, got %q %v", "new", data, err)
+	}
+	if err := repo.Copy("/a.txt", "/b.txt", true, nil); err != nil {
+		t.Fatalf("Failed to copy with overwrite: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "b.txt")); err != nil || string(data) != "a" {
+		t.Errorf("Expected b.txt to hold %q, got %q %v", "a", data, err)
+	}
+}
+
+func TestWriteFileIsAtomic(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	target := filepath.Join(root, "data.bin")
+	if err := os.WriteFile(target, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := repo.WriteFile("data.bin", &failingReader{}, models.ConflictOverwrite); err == nil {
+		t.Fatal("Expected interrupted write to fail")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "original" {
+		t.Errorf("Expected interrupted write to keep the old file, got %q", data)
+	}
+
+	// Concurrent writers each leave a complete file, never an interleaving
+	contents := []string{strings.Repeat("a", 1<<20), strings.Repeat("b", 1<<20)}
+	var wg sync.WaitGroup
+	for _, content := range contents {
+		wg.Add(1)
+		go func(content string) {
+			defer wg.Done()
+			if _, _, err := repo.WriteFile("data.bin", io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+				t.Errorf("Failed to write: %v", err)
+			}
+		}(content)
+	}
+	wg.Wait()
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if string(data) != contents[0] && string(data) != contents[1] {
+		t.Error("Expected the file to hold exactly one writer's content")
+	}
+
+	entries, err := repo.ListDirectory("/")
+	if err != nil {
+		t.Fatalf("Failed to list: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only data.bin in listing, got %d entries", len(entries))
+	}
+}
+
+func TestCleanupTempFiles(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	for _, name := range []string{tempFilePrefix + "1", "nested/" + tempFilePrefix + "2", "keep.txt"} {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := repo.CleanupTempFiles()
+	if err != nil {
+		t.Fatalf("Failed to clean up: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 removed files, got %d", removed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "keep.txt")); err != nil {
+		t.Errorf("Expected keep.txt to survive: %v", err)
+	}
+}
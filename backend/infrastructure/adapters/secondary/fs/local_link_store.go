@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// LocalLinkStore keeps share links in memory and in one JSON file, which is
+// rewritten atomically after every change. Expired links are dropped from
+// the file the next time it is written.
+type LocalLinkStore struct {
+	file string
+
+	mu    sync.Mutex
+	links map[string]*models.ShareLink
+}
+
+// NewLocalLinkStore loads the links in file, creating its directory if needed.
+func NewLocalLinkStore(file string) (*LocalLinkStore, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create link store directory: %w", err)
+	}
+	s := &LocalLinkStore{file: file, links: make(map[string]*models.ShareLink)}
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var links []*models.ShareLink
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("corrupt link store %s: %w", file, err)
+	}
+	for _, link := range links {
+		s.links[link.Token] = link
+	}
+	return s, nil
+}
+
+func (s *LocalLinkStore) Save(link *models.ShareLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.links[link.Token]
+	s.links[link.Token] = cloneLink(link)
+	if err := s.writeLocked(); err != nil {
+		if existed {
+			s.links[link.Token] = previous
+		} else {
+			delete(s.links, link.Token)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *LocalLinkStore) Get(token string) (*models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[token]
+	if !ok {
+		return nil, &errors.NotFoundError{Path: token}
+	}
+	return cloneLink(link), nil
+}
+
+// List returns the links, oldest first.
+func (s *LocalLinkStore) List() ([]*models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := make([]*models.ShareLink, 0, len(s.links))
+	for _, link := range s.links {
+		links = append(links, cloneLink(link))
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.Before(links[j].CreatedAt) })
+	return links, nil
+}
+
+func (s *LocalLinkStore) Update(token string, fn func(link *models.ShareLink) error) (*models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.links[token]
+	if !ok {
+		return nil, &errors.NotFoundError{Path: token}
+	}
+	updated := cloneLink(previous)
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	s.links[token] = updated
+	if err := s.writeLocked(); err != nil {
+		s.links[token] = previous
+		return nil, err
+	}
+	return cloneLink(updated), nil
+}
+
+func (s *LocalLinkStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.links[token]
+	if !ok {
+		return nil
+	}
+	delete(s.links, token)
+	if err := s.writeLocked(); err != nil {
+		s.links[token] = previous
+		return err
+	}
+	return nil
+}
+
+// writeLocked drops expired links and replaces the file with the rest.
+func (s *LocalLinkStore) writeLocked() error {
+	now := time.Now()
+	links := make([]*models.ShareLink, 0, len(s.links))
+	for token, link := range s.links {
+		if link.Expired(now) {
+			delete(s.links, token)
+			continue
+		}
+		links = append(links, link)
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.Before(links[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// cloneLink copies link deeply enough that callers cannot change stored state.
+func cloneLink(link *models.ShareLink) *models.ShareLink {
+	clone := *link
+	clone.AllowedExtensions = slices.Clone(link.AllowedExtensions)
+	clone.Uploads = slices.Clone(link.Uploads)
+	return &clone
+}
+
+var _ ports.LinkStore = (*LocalLinkStore)(nil)
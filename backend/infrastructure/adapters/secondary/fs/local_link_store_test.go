@@ -0,0 +1,51 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+func TestLocalLinkStorePersists(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "links", "links.json")
+	store, err := NewLocalLinkStore(file)
+	if err != nil {
+		t.Fatalf("Failed to create link store: %v", err)
+	}
+
+	now := time.Now()
+	links := []*models.ShareLink{
+		{Token: "live", Path: "/docs", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{Token: "stale", Path: "/old", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	}
+	for _, link := range links {
+		if err := store.Save(link); err != nil {
+			t.Fatalf("Failed to save link: %v", err)
+		}
+	}
+	if _, err := store.Update("live", func(l *models.ShareLink) error { l.Downloads++; return nil }); err != nil {
+		t.Fatalf("Failed to update link: %v", err)
+	}
+
+	// Expired links are dropped when the file is written
+	reopened, err := NewLocalLinkStore(file)
+	if err != nil {
+		t.Fatalf("Failed to reopen link store: %v", err)
+	}
+	saved, err := reopened.List()
+	if err != nil {
+		t.Fatalf("Failed to list links: %v", err)
+	}
+	if len(saved) != 1 || saved[0].Token != "live" || saved[0].Downloads != 1 {
+		t.Errorf("Expected only the live link with one download, got %+v", saved)
+	}
+
+	if err := reopened.Delete("live"); err != nil {
+		t.Fatalf("Failed to delete link: %v", err)
+	}
+	if _, err := reopened.Get("live"); err == nil {
+		t.Error("Expected a deleted link to be gone")
+	}
+}
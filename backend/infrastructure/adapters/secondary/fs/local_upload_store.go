@@ -0,0 +1,240 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// uploadIDPattern keeps session IDs from naming anything outside the staging directory.
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+const uploadInfoSuffix = ".info"
+
+// LocalUploadStore stages resumable uploads in a directory: "<id>" holds the
+// bytes received so far and "<id>.info" the JSON session record. The offset
+// is always the size of the data file, so it survives restarts.
+type LocalUploadStore struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewLocalUploadStore creates dir if needed and stages uploads in it.
+func NewLocalUploadStore(dir string) (*LocalUploadStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create upload staging directory: %w", err)
+	}
+	return &LocalUploadStore{dir: dir, locks: make(map[string]*sync.Mutex)}, nil
+}
+
+// Create writes the session record and an empty data file.
+func (s *LocalUploadStore) Create(session *models.UploadSession) error {
+	dataPath, err := s.dataPath(session.ID)
+	if err != nil {
+		return err
+	}
+	lock := s.lock(session.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	data, err := os.OpenFile(dataPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	if err := s.writeRecord(dataPath, session); err != nil {
+		os.Remove(dataPath)
+		return err
+	}
+	return nil
+}
+
+// Save rewrites the record of a session whose data file still exists. It
+// holds the session's lock, so it cannot interleave with Delete.
+func (s *LocalUploadStore) Save(session *models.UploadSession) error {
+	dataPath, err := s.dataPath(session.ID)
+	if err != nil {
+		return err
+	}
+	lock := s.lock(session.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(dataPath); os.IsNotExist(err) {
+		return &errors.NotFoundError{Path: session.ID}
+	} else if err != nil {
+		return err
+	}
+	return s.writeRecord(dataPath, session)
+}
+
+// writeRecord replaces the JSON record beside dataPath. Caller holds the
+// session's lock.
+func (s *LocalUploadStore) writeRecord(dataPath string, session *models.UploadSession) error {
+	record, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	tmp := dataPath + uploadInfoSuffix + ".tmp"
+	if err := os.WriteFile(tmp, record, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dataPath+uploadInfoSuffix)
+}
+
+// Get loads the session record and sets Offset from the staged bytes.
+func (s *LocalUploadStore) Get(id string) (*models.UploadSession, error) {
+	dataPath, err := s.dataPath(id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := os.ReadFile(dataPath + uploadInfoSuffix)
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session models.UploadSession
+	if err := json.Unmarshal(record, &session); err != nil {
+		return nil, fmt.Errorf("corrupt upload record %s: %w", id, err)
+	}
+	info, err := os.Stat(dataPath)
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.Offset = info.Size()
+	return &session, nil
+}
+
+// List returns every session with a readable record.
+func (s *LocalUploadStore) List() ([]*models.UploadSession, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*models.UploadSession
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), uploadInfoSuffix)
+		if !ok || !uploadIDPattern.MatchString(id) {
+			continue
+		}
+		session, err := s.Get(id)
+		if err != nil {
+			continue // Deleted concurrently or unreadable; expiry cleans it up
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Append copies at most limit bytes from r onto the staged data at offset.
+func (s *LocalUploadStore) Append(id string, offset, limit int64, r io.Reader) (int64, error) {
+	dataPath, err := s.dataPath(id)
+	if err != nil {
+		return 0, err
+	}
+	lock := s.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	data, err := os.OpenFile(dataPath, os.O_WRONLY|os.O_APPEND, 0)
+	if os.IsNotExist(err) {
+		return 0, &errors.NotFoundError{Path: id}
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer data.Close()
+
+	info, err := data.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() != offset {
+		return info.Size(), &errors.ConflictError{
+			Path:   id,
+			Reason: fmt.Sprintf("offset %d does not match current offset %d", offset, info.Size()),
+		}
+	}
+
+	written, copyErr := io.Copy(data, io.LimitReader(r, limit))
+	if err := data.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	return offset + written, copyErr
+}
+
+// Open returns the staged bytes for reading.
+func (s *LocalUploadStore) Open(id string) (models.ReadCloser, error) {
+	dataPath, err := s.dataPath(id)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(dataPath)
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	return file, err
+}
+
+// Delete removes the session record and data. Missing files are not an error.
+func (s *LocalUploadStore) Delete(id string) error {
+	dataPath, err := s.dataPath(id)
+	if err != nil {
+		return err
+	}
+	lock := s.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	for _, p := range []string{dataPath + uploadInfoSuffix, dataPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.locks, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// dataPath maps a session ID to its data file, rejecting malformed IDs.
+func (s *LocalUploadStore) dataPath(id string) (string, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", &errors.NotFoundError{Path: id}
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+// lock returns the mutex serializing changes to one session.
+func (s *LocalUploadStore) lock(id string) *sync.Mutex {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[id] = l
+	}
+	return l
+}
+
+var _ ports.UploadStore = (*LocalUploadStore)(nil)
@@ -0,0 +1,349 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// minCompaction is how many journal lines are always allowed before the
+// ledger is rewritten, however few entries it holds.
+const minCompaction = 1024
+
+// LocalUsageLedger keeps who uploaded each file in memory, in a tree of
+// path components so a directory's entries are found without a scan, and
+// with a running total per user. On disk a JSON snapshot is followed by a
+// journal of changes, "<file>.journal", which is folded into the snapshot
+// once it outgrows it. A change only takes effect once it is journaled,
+// so a failed write changes nothing.
+type LocalUsageLedger struct {
+	file string
+
+	mu        sync.Mutex
+	root      *ledgerNode
+	totals    map[string]int64 // Bytes recorded per user
+	entries   int              // Files recorded
+	journaled int              // Changes in the journal
+}
+
+// ledgerEntry is one uploaded file, as stored in the snapshot.
+type ledgerEntry struct {
+	Path string `json:"path"`
+	User string `json:"user"`
+	Size int64  `json:"size"`
+}
+
+// ledgerOp is one step of a journaled change: it drops everything at or
+// below Path when Forget is set, and records an entry at Path otherwise.
+// Each step replaces what is there, so replaying the journal over a
+// snapshot that already holds some of it gives the same ledger.
+type ledgerOp struct {
+	Path   string `json:"path"`
+	Forget bool   `json:"forget,omitempty"`
+	User   string `json:"user,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+}
+
+// ledgerNode is one path component. entry is nil for directories that only
+// lead to recorded files.
+type ledgerNode struct {
+	entry    *ledgerEntry
+	children map[string]*ledgerNode
+}
+
+// NewLocalUsageLedger loads the ledger in file, creating its directory if
+// needed, and folds any journal left by the previous run into it.
+func NewLocalUsageLedger(file string) (*LocalUsageLedger, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+	l := &LocalUsageLedger{file: file, root: &ledgerNode{}, totals: make(map[string]int64)}
+
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var entries []ledgerEntry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("corrupt usage ledger %s: %w", file, err)
+		}
+		for _, entry := range entries {
+			l.apply(ledgerOp{Path: entry.Path, User: entry.User, Size: entry.Size})
+		}
+	}
+
+	journal, err := os.ReadFile(l.journalFile())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(journal))
+	scanner.Buffer(nil, len(journal)+1)
+	for scanner.Scan() {
+		var ops []ledgerOp
+		if err := json.Unmarshal(scanner.Bytes(), &ops); err != nil {
+			// A change cut short by a crash never took effect
+			break
+		}
+		for _, op := range ops {
+			l.apply(op)
+		}
+	}
+	if len(journal) > 0 {
+		if err := l.compactLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *LocalUsageLedger) Record(p, user string, size int64) error {
+	return l.change([]ledgerOp{{Path: path.Clean("/" + p), User: user, Size: size}})
+}
+
+func (l *LocalUsageLedger) Forget(p string) error {
+	return l.change([]ledgerOp{{Path: path.Clean("/" + p), Forget: true}})
+}
+
+func (l *LocalUsageLedger) Rename(p, newPath string) error {
+	p, newPath = path.Clean("/"+p), path.Clean("/"+newPath)
+	if p == newPath {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Whatever the move replaced is gone
+	ops := []ledgerOp{{Path: p, Forget: true}, {Path: newPath, Forget: true}}
+	if node := l.find(p); node != nil {
+		walkLedger(node, newPath, func(entry *ledgerEntry, at string) {
+			ops = append(ops, ledgerOp{Path: at, User: entry.User, Size: entry.Size})
+		})
+	}
+	return l.changeLocked(ops)
+}
+
+func (l *LocalUsageLedger) UserUsage(user string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.totals[user], nil
+}
+
+func (l *LocalUsageLedger) change(ops []ledgerOp) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.changeLocked(ops)
+}
+
+// changeLocked journals ops as one line and then applies them, rewriting
+// the snapshot once the journal holds more changes than it has entries.
+func (l *LocalUsageLedger) changeLocked(ops []ledgerOp) error {
+	line, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	if err := appendLine(l.journalFile(), line); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		l.apply(op)
+	}
+	l.journaled++
+	if l.journaled > max(l.entries, minCompaction) {
+		// The change is already journaled, so it stands either way
+		_ = l.compactLocked()
+	}
+	return nil
+}
+
+// apply carries out op in memory.
+func (l *LocalUsageLedger) apply(op ledgerOp) {
+	if op.Forget {
+		l.detach(op.Path)
+		return
+	}
+	node := l.root
+	for _, name := range splitLedgerPath(op.Path) {
+		child := node.children[name]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[string]*ledgerNode)
+			}
+			child = &ledgerNode{}
+			node.children[name] = child
+		}
+		node = child
+	}
+	if node.entry != nil {
+		l.count(node.entry, -1)
+	}
+	node.entry = &ledgerEntry{Path: op.Path, User: op.User, Size: op.Size}
+	l.count(node.entry, 1)
+}
+
+// detach removes p and everything below it, along with directories left
+// leading nowhere.
+func (l *LocalUsageLedger) detach(p string) {
+	names := splitLedgerPath(p)
+	nodes := []*ledgerNode{l.root}
+	for _, name := range names {
+		child := nodes[len(nodes)-1].children[name]
+		if child == nil {
+			return
+		}
+		nodes = append(nodes, child)
+	}
+	walkLedger(nodes[len(nodes)-1], p, func(entry *ledgerEntry, _ string) {
+		l.count(entry, -1)
+	})
+	if len(names) == 0 {
+		l.root = &ledgerNode{}
+		return
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		delete(nodes[i].children, names[i])
+		if i == 0 || nodes[i].entry != nil || len(nodes[i].children) > 0 {
+			break
+		}
+	}
+}
+
+// count adds sign times entry to the totals.
+func (l *LocalUsageLedger) count(entry *ledgerEntry, sign int64) {
+	l.entries += int(sign)
+	l.totals[entry.User] += sign * entry.Size
+	if l.totals[entry.User] == 0 {
+		delete(l.totals, entry.User)
+	}
+}
+
+// find returns the node at p, or nil.
+func (l *LocalUsageLedger) find(p string) *ledgerNode {
+	node := l.root
+	for _, name := range splitLedgerPath(p) {
+		if node = node.children[name]; node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// compactLocked replaces the snapshot with the ledger in path order and
+// empties the journal.
+func (l *LocalUsageLedger) compactLocked() error {
+	entries := make([]ledgerEntry, 0, l.entries)
+	walkLedger(l.root, "/", func(entry *ledgerEntry, at string) {
+		entries = append(entries, ledgerEntry{Path: at, User: entry.User, Size: entry.Size})
+	})
+	if err := writeLedger(l.file, entries); err != nil {
+		return err
+	}
+	if err := os.Truncate(l.journalFile(), 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	l.journaled = 0
+	return nil
+}
+
+func (l *LocalUsageLedger) journalFile() string {
+	return l.file + ".journal"
+}
+
+// walkLedger calls fn for each entry at or below node, which sits at p, in
+// path order, with the path it has there.
+func walkLedger(node *ledgerNode, p string, fn func(entry *ledgerEntry, at string)) {
+	if node.entry != nil {
+		fn(node.entry, p)
+	}
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		walkLedger(node.children[name], path.Join(p, name), fn)
+	}
+}
+
+// splitLedgerPath returns the components of the clean absolute path p.
+func splitLedgerPath(p string) []string {
+	if p == "/" {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// appendLine adds line to file and syncs it. A line cut short or not
+// synced is removed again, so the journal never holds a half-written
+// change followed by whole ones.
+func appendLine(file string, line []byte) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		if _, err = f.Write(append(line, '\n')); err == nil {
+			err = f.Sync()
+		}
+		if err != nil {
+			f.Truncate(info.Size())
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && info.Size() == 0 {
+		// The journal may have just been created
+		syncHostDir(filepath.Dir(file))
+	}
+	return err
+}
+
+// writeLedger replaces file with entries. The new snapshot is on disk
+// before it returns, so the journal it folds in can be emptied.
+func writeLedger(file string, entries []ledgerEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return err
+	}
+	syncHostDir(filepath.Dir(file))
+	return nil
+}
+
+// syncHostDir is syncDir for a directory outside any tree.
+func syncHostDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
+
+var _ ports.UsageLedger = (*LocalUsageLedger)(nil)
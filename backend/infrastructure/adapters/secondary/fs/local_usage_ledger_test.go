@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalUsageLedger(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "usage.json")
+	ledger, err := NewLocalUsageLedger(file)
+	if err != nil {
+		t.Fatalf("Failed to create usage ledger: %v", err)
+	}
+
+	records := []struct {
+		path, user string
+		size       int64
+	}{
+		{"/docs/a.txt", "alice", 10},
+		{"/docs/b.txt", "alice", 20},
+		{"/docs/sub/c.txt", "bob", 5},
+		{"/other/a.txt", "alice", 100},
+		{"/docs/a.txt", "alice", 15}, // Overwritten
+	}
+	for _, r := range records {
+		if err := ledger.Record(r.path, r.user, r.size); err != nil {
+			t.Fatalf("Failed to record upload: %v", err)
+		}
+	}
+	if used, _ := ledger.UserUsage("alice"); used != 135 {
+		t.Errorf("Expected 135 bytes for alice, got %d", used)
+	}
+
+	// Moving /docs over /other drops what /other held
+	if err := ledger.Rename("/docs", "/other"); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	if err := ledger.Forget("/other/sub"); err != nil {
+		t.Fatalf("Failed to forget: %v", err)
+	}
+
+	reopened, err := NewLocalUsageLedger(file)
+	if err != nil {
+		t.Fatalf("Failed to reopen usage ledger: %v", err)
+	}
+	if used, _ := reopened.UserUsage("alice"); used != 35 {
+		t.Errorf("Expected 35 bytes for alice after the move, got %d", used)
+	}
+	if used, _ := reopened.UserUsage("bob"); used != 0 {
+		t.Errorf("Expected nothing left for bob, got %d", used)
+	}
+	if err := reopened.Forget("/"); err != nil {
+		t.Fatalf("Failed to forget everything: %v", err)
+	}
+	if used, _ := reopened.UserUsage("alice"); used != 0 {
+		t.Errorf("Expected nothing left for alice, got %d", used)
+	}
+}
+
+func TestLocalUsageLedgerJournal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "usage.json")
+	ledger, err := NewLocalUsageLedger(file)
+	if err != nil {
+		t.Fatalf("Failed to create usage ledger: %v", err)
+	}
+	steps := []func() error{
+		func() error { return ledger.Record("/a/one.txt", "alice", 10) },
+		func() error { return ledger.Record("/a/two.txt", "alice", 5) },
+		func() error { return ledger.Record("/b/one.txt", "bob", 7) },
+		func() error { return ledger.Rename("/a", "/b") },
+		func() error { return ledger.Record("/b/three.txt", "alice", 1) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Failed to change the ledger: %v", err)
+		}
+	}
+	journal, err := os.ReadFile(file + ".journal")
+	if err != nil || len(journal) == 0 {
+		t.Fatalf("Expected the changes to be journaled, got %q %v", journal, err)
+	}
+
+	check := func(name string) {
+		t.Helper()
+		reopened, err := NewLocalUsageLedger(file)
+		if err != nil {
+			t.Fatalf("%s: failed to reopen usage ledger: %v", name, err)
+		}
+		if used, _ := reopened.UserUsage("alice"); used != 16 {
+			t.Errorf("%s: expected 16 bytes for alice, got %d", name, used)
+		}
+		if used, _ := reopened.UserUsage("bob"); used != 0 {
+			t.Errorf("%s: expected the move to drop bob's file, got %d", name, used)
+		}
+		if info, err := os.Stat(file + ".journal"); err != nil || info.Size() != 0 {
+			t.Errorf("%s: expected the journal to be folded into the snapshot, got %v", name, err)
+		}
+	}
+	check("journal")
+
+	// A crash between writing the snapshot and emptying the journal
+	// replays changes the snapshot already holds
+	if err := os.WriteFile(file+".journal", journal, 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("replayed journal")
+
+	// A change cut short by a crash is dropped
+	torn := append(append([]byte{}, journal...), `[{"path":"/c.txt","user":"alice","si`...)
+	if err := os.WriteFile(file+".journal", torn, 0600); err != nil {
+		t.Fatal(err)
+	}
+	check("torn journal")
+}
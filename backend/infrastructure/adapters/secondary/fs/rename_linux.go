@@ -0,0 +1,20 @@
+//go:build linux
+
+package fs
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// renameNoReplace renames oldBase in oldDir to newBase in newDir in one
+// step that fails with EEXIST if newBase exists. It reports false when the
+// filesystem cannot do that.
+func renameNoReplace(oldDir *os.File, oldBase string, newDir *os.File, newBase string) (bool, error) {
+	err := unix.Renameat2(int(oldDir.Fd()), oldBase, int(newDir.Fd()), newBase, unix.RENAME_NOREPLACE)
+	if err == unix.ENOSYS || err == unix.EINVAL {
+		return false, nil
+	}
+	return true, err
+}
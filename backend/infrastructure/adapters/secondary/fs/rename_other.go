@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fs
+
+import "os"
+
+// renameNoReplace is not available on this platform.
+func renameNoReplace(*os.File, string, *os.File, string) (bool, error) {
+	return false, nil
+}
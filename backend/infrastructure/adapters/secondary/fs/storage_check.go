@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// StorageCheck verifies that the shared directory can be listed and written.
+type StorageCheck struct {
+	name     string
+	rootDir  string
+	readOnly bool // Skip the write probe
+}
+
+// NewStorageCheck creates a readiness check for rootDir.
+func NewStorageCheck(rootDir string) *StorageCheck {
+	return &StorageCheck{name: "storage", rootDir: rootDir}
+}
+
+// NewShareStorageCheck creates a readiness check for one share's directory.
+// Read-only shares only need to be listable; mode is the share's effective mode.
+func NewShareStorageCheck(share models.Share, mode models.AccessMode) *StorageCheck {
+	return &StorageCheck{name: "storage:" + share.Name, rootDir: share.Path, readOnly: mode == models.AccessReadOnly}
+}
+
+func (c *StorageCheck) Name() string { return c.name }
+
+// Check lists the root and creates and removes a temporary file in it.
+func (c *StorageCheck) Check() error {
+	dir, err := os.Open(c.rootDir)
+	if err != nil {
+		return fmt.Errorf("root directory not readable: %w", err)
+	}
+	_, err = dir.ReadDir(1)
+	dir.Close()
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("root directory not readable: %w", err)
+	}
+
+	if c.readOnly {
+		return nil
+	}
+	probe, err := os.CreateTemp(c.rootDir, tempFilePrefix+"ready-*")
+	if err != nil {
+		return fmt.Errorf("root directory not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+var _ ports.ReadinessCheck = (*StorageCheck)(nil)
@@ -0,0 +1,358 @@
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// trashDir holds trashed entries at the root of the repository. Each entry
+// is renamed to its ID and described by a sidecar "<id>.json"; the versions
+// of its files wait in "<id>.versions".
+const trashDir = ".trash"
+
+// MoveToTrash renames path into the trash, so trashing is as cheap as a
+// rename whatever its size. The entry stops counting as a file and is held
+// in the trash until a change needs its room.
+func (r *LocalFileRepository) MoveToTrash(p, user string) (*models.TrashItem, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	name := treeName(p)
+	if name == "." || reserved(name) {
+		return nil, errors.NewValidationError("path", p, "cannot be moved to the trash")
+	}
+	info, err := t.Lstat(name)
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: p}
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.usage.measure(t)
+	item, err := trashEntry(t, name, user, info, true)
+	if err != nil {
+		return nil, err
+	}
+	r.usage.add(-item.Size)
+	r.usage.hold(item.Size)
+	return item, nil
+}
+
+// trashEntry renames name, described by info, into the trash and records
+// who deleted it. With withVersions, the versions of its files go along.
+func trashEntry(t tree, name, user string, info os.FileInfo, withVersions bool) (*models.TrashItem, error) {
+	if err := t.MkdirAll(trashDir, 0700); err != nil {
+		return nil, err
+	}
+	id, err := newTrashID()
+	if err != nil {
+		return nil, err
+	}
+	item := &models.TrashItem{
+		ID:           id,
+		OriginalPath: path.Join("/", name),
+		DeletedBy:    user,
+		DeletedAt:    time.Now().UTC(),
+		IsDir:        info.IsDir(),
+		Size:         treeSize(t, name, info),
+	}
+	data := path.Join(trashDir, id)
+	if err := t.Rename(name, data); err != nil {
+		return nil, err
+	}
+	if withVersions {
+		err = moveVersions(t, path.Join(versionsDir, name), data+".versions")
+	}
+	if err == nil {
+		err = writeTrashItem(t, item)
+	}
+	if err != nil {
+		// Without its record the entry could never be restored
+		if restoreErr := t.Rename(data, name); restoreErr != nil {
+			return nil, restoreErr
+		}
+		if restoreErr := moveVersions(t, data+".versions", path.Join(versionsDir, name)); restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *LocalFileRepository) ListTrash() ([]*models.TrashItem, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+	return listTrash(t)
+}
+
+// RestoreFromTrash renames the entry back into place. Under ConflictRename
+// the first free "name (n).ext" is used; under ConflictOverwrite whatever
+// is in the way is trashed in turn, as deleted by user, and a file in the
+// way is also kept as a version, as by WriteFile. An entry that no
+// longer fits in the quota stays in the trash with a QuotaExceededError.
+func (r *LocalFileRepository) RestoreFromTrash(id, dst, user string, policy models.ConflictPolicy) (string, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+
+	used := r.usage.measure(t)
+	item, err := readTrashItem(t, id)
+	if err != nil {
+		return "", err
+	}
+	if dst == "" {
+		dst = item.OriginalPath
+	}
+	name := treeName(dst)
+	if name == "." || reserved(name) {
+		return "", errors.NewValidationError("to", dst, "cannot restore there")
+	}
+	if err := t.MkdirAll(path.Dir(name), 0755); err != nil {
+		return "", err
+	}
+
+	data := path.Join(trashDir, id)
+	var replaced int64
+	if policy == models.ConflictOverwrite {
+		replaced = sizeAt(t, name)
+	}
+	if r.quota > 0 && used-replaced+item.Size > r.quota {
+		return "", r.quotaFor(dst)
+	}
+	switch policy {
+	case models.ConflictOverwrite:
+		if info, err := t.Lstat(name); err == nil {
+			// A file's history stays with its path, with the file as its
+			// latest version; a directory takes its files' history along
+			isFile := info.Mode().IsRegular()
+			if isFile && r.versions.Enabled() {
+				err := r.countVersions(t, name, func() error { return keepVersion(t, name, r.versions) })
+				if err != nil {
+					return "", err
+				}
+			}
+			displaced, err := trashEntry(t, name, user, info, !isFile)
+			if err != nil {
+				return "", err
+			}
+			r.usage.add(-replaced)
+			r.usage.hold(displaced.Size)
+		}
+		err = t.Rename(data, name)
+	case models.ConflictReject:
+		err = renameNew(t, data, name)
+	case models.ConflictRename:
+		// The entry takes the first free name in the same step as it
+		// checks it, so nothing that appears meanwhile is replaced
+		err = os.ErrExist
+		for n := 0; n < maxRenameAttempts && os.IsExist(err); n++ {
+			candidate := numberedName(name, n)
+			if err = renameNew(t, data, candidate); err == nil {
+				name = candidate
+			}
+		}
+	default:
+		return "", errors.NewValidationError("conflict", policy, "unknown conflict policy")
+	}
+	if os.IsExist(err) {
+		return "", &errors.ConflictError{Path: dst, Reason: "already exists"}
+	}
+	if err != nil {
+		return "", err
+	}
+	restored := sizeAt(t, name)
+	r.usage.add(restored)
+	r.usage.hold(-restored)
+	t.Remove(data + ".json")
+	if err := moveVersions(t, data+".versions", path.Join(versionsDir, name)); err != nil {
+		return "", err
+	}
+	r.makeRoom(t, 0)
+	return path.Join("/", name), nil
+}
+
+func (r *LocalFileRepository) PurgeTrash(id string) error {
+	t, err := r.openAll()
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	r.usage.measure(t)
+	if _, err := readTrashItem(t, id); err != nil {
+		return err
+	}
+	return r.purgeTrashed(t, id)
+}
+
+func (r *LocalFileRepository) PurgeTrashBefore(cutoff time.Time) (int, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return 0, err
+	}
+	defer t.Close()
+
+	r.usage.measure(t)
+	items, err := listTrash(t)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, item := range items {
+		if !item.DeletedAt.Before(cutoff) {
+			continue
+		}
+		if err := r.purgeTrashed(t, item.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// listTrash reads every record in the trash, oldest first.
+func listTrash(t tree) ([]*models.TrashItem, error) {
+	d, err := t.Open(trashDir)
+	if os.IsNotExist(err) {
+		return []*models.TrashItem{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := d.ReadDir(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*models.TrashItem{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		item, err := readTrashItem(t, id)
+		if err != nil {
+			continue // Half-written or hand-edited; leave it alone
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.Before(items[j].DeletedAt) })
+	return items, nil
+}
+
+// readTrashItem loads the record of id, which must have its entry beside it.
+func readTrashItem(t tree, id string) (*models.TrashItem, error) {
+	if !validTrashID(id) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	f, err := t.Open(path.Join(trashDir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var item models.TrashItem
+	if err := json.NewDecoder(f).Decode(&item); err != nil {
+		return nil, err
+	}
+	if _, err := t.Lstat(path.Join(trashDir, id)); err != nil {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	item.ID = id
+	return &item, nil
+}
+
+// writeTrashItem stores the record of item through a temporary file.
+func writeTrashItem(t tree, item *models.TrashItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	tmp, tmpName, err := createTemp(t, trashDir)
+	if err != nil {
+		return err
+	}
+	defer t.Remove(tmpName)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return t.Rename(tmpName, path.Join(trashDir, item.ID+".json"))
+}
+
+// purgeTrashed is purgeTrashItem, no longer holding what it removed.
+func (r *LocalFileRepository) purgeTrashed(t tree, id string) error {
+	before := trashedSize(t, id)
+	err := purgeTrashItem(t, id)
+	r.usage.hold(trashedSize(t, id) - before)
+	return err
+}
+
+// trashedSize returns the bytes in trashed entry id and its versions.
+func trashedSize(t tree, id string) int64 {
+	data := path.Join(trashDir, id)
+	return sizeAt(t, data) + sizeAt(t, data+".versions")
+}
+
+// purgeTrashItem deletes the entry and its versions first, so a failure
+// leaves the record pointing at what remains rather than orphaned bytes.
+func purgeTrashItem(t tree, id string) error {
+	data := path.Join(trashDir, id)
+	if err := t.RemoveAll(data); err != nil {
+		return err
+	}
+	if err := t.RemoveAll(data + ".versions"); err != nil {
+		return err
+	}
+	return t.Remove(data + ".json")
+}
+
+// newTrashID returns a random ID that sorts after IDs created earlier.
+func newTrashID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b), nil
+}
+
+// validTrashID rejects IDs that could name anything outside the trash.
+func validTrashID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c == 'T' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+var _ ports.TrashRepository = (*LocalFileRepository)(nil)
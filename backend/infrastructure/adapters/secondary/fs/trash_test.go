@@ -0,0 +1,161 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+func TestTrashIsHiddenFromTheTree(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	repo := NewLocalFileRepository(root)
+
+	item, err := repo.MoveToTrash("/docs", "alice")
+	if err != nil {
+		t.Fatalf("Failed to trash directory: %v", err)
+	}
+	if !item.IsDir || item.OriginalPath != "/docs" || item.DeletedBy != "alice" || item.Size != 5 {
+		t.Errorf("Unexpected trash item %+v", item)
+	}
+
+	entries, err := repo.ListDirectory("/")
+	if err != nil {
+		t.Fatalf("Failed to list root: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected the trash to be hidden, got %+v", entries)
+	}
+	if _, err := repo.Stat("/" + trashDir); err == nil {
+		t.Error("Expected the trash to be out of reach")
+	}
+	if _, err := repo.MoveToTrash("/"+trashDir, "alice"); err == nil {
+		t.Error("Expected the trash itself not to be trashable")
+	}
+	if names := strings.Join(zipNames(t, repo, "/"), " "); names != "./" {
+		t.Errorf("Expected the trash to be left out of zips, got %q", names)
+	}
+
+	items, err := repo.ListTrash()
+	if err != nil {
+		t.Fatalf("Failed to list trash: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != item.ID {
+		t.Fatalf("Expected the trashed directory, got %+v", items)
+	}
+	if _, err := repo.RestoreFromTrash("../docs", "", "alice", models.ConflictReject); err == nil {
+		t.Error("Expected an ID outside the trash to be refused")
+	}
+
+	restored, err := repo.RestoreFromTrash(item.ID, "", "alice", models.ConflictReject)
+	if err != nil {
+		t.Fatalf("Failed to restore directory: %v", err)
+	}
+	if restored != "/docs" {
+		t.Errorf("Expected /docs, got %s", restored)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "docs", "a.txt")); err != nil || string(data) != "alpha" {
+		t.Errorf("Expected the restored contents, got %q %v", data, err)
+	}
+	if items, _ := repo.ListTrash(); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", items)
+	}
+}
+
+func TestRestoreFromTrashConflicts(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	trashFile := func(content string) *models.TrashItem {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		item, err := repo.MoveToTrash("/a.txt", "alice")
+		if err != nil {
+			t.Fatalf("Failed to trash file: %v", err)
+		}
+		return item
+	}
+	first, second, third := trashFile("one"), trashFile("two"), trashFile("three")
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("current"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	_, err := repo.RestoreFromTrash(first.ID, "", "alice", models.ConflictReject)
+	if _, ok := err.(*domainerrors.ConflictError); !ok {
+		t.Errorf("Expected ConflictError, got %T %v", err, err)
+	}
+	if restored, err := repo.RestoreFromTrash(first.ID, "", "alice", models.ConflictRename); err != nil || restored != "/a (1).txt" {
+		t.Errorf("Expected /a (1).txt, got %q %v", restored, err)
+	}
+	if restored, err := repo.RestoreFromTrash(second.ID, "/old/a.txt", "alice", models.ConflictReject); err != nil || restored != "/old/a.txt" {
+		t.Errorf("Expected /old/a.txt, got %q %v", restored, err)
+	}
+	for name, want := range map[string]string{"a.txt": "current", "a (1).txt": "one", "old/a.txt": "two"} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q %v", name, want, data, err)
+		}
+	}
+
+	// Overwriting trashes what was in the way rather than deleting it
+	if restored, err := repo.RestoreFromTrash(third.ID, "", "bob", models.ConflictOverwrite); err != nil || restored != "/a.txt" {
+		t.Fatalf("Expected /a.txt, got %q %v", restored, err)
+	}
+	items, err := repo.ListTrash()
+	if err != nil || len(items) != 1 || items[0].OriginalPath != "/a.txt" || items[0].DeletedBy != "bob" {
+		t.Fatalf("Expected the replaced file in the trash, got %+v %v", items, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, trashDir, items[0].ID)); err != nil || string(data) != "current" {
+		t.Errorf("Expected the replaced content to be kept, got %q %v", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "three" {
+		t.Errorf("Expected a.txt to hold the restored content, got %q %v", data, err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	a, err := repo.MoveToTrash("/a.txt", "alice")
+	if err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+	if _, err := repo.MoveToTrash("/b.txt", "alice"); err != nil {
+		t.Fatalf("Failed to trash file: %v", err)
+	}
+
+	if purged, err := repo.PurgeTrashBefore(time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Errorf("Expected nothing to be old enough, got %d %v", purged, err)
+	}
+	if err := repo.PurgeTrash(a.ID); err != nil {
+		t.Fatalf("Failed to purge entry: %v", err)
+	}
+	if _, err := repo.RestoreFromTrash(a.ID, "", "alice", models.ConflictReject); err == nil {
+		t.Error("Expected a purged entry to be gone")
+	}
+	if purged, err := repo.PurgeTrashBefore(time.Now().Add(time.Minute)); err != nil || purged != 1 {
+		t.Errorf("Expected one entry to be purged, got %d %v", purged, err)
+	}
+	entries, err := os.ReadDir(filepath.Join(root, trashDir))
+	if err != nil {
+		t.Fatalf("Failed to read trash: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected an empty trash directory, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,408 @@
+package fs
+
+import (
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+// tree is the shared directory as seen by one repository operation. Names
+// are slash-separated and relative to the root, with "." for the root itself.
+type tree interface {
+	Open(name string) (*os.File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	Readlink(name string) (string, error)
+	Mkdir(name string, perm os.FileMode) error
+	MkdirAll(name string, perm os.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldname, newname string) error
+	// RenameNoReplace is Rename that fails with an error satisfying
+	// os.IsExist if newname exists. It reports false, having done nothing,
+	// where that cannot be done in one step.
+	RenameNoReplace(oldname, newname string) (bool, error)
+	Link(oldname, newname string) error
+	Close() error
+}
+
+// openTree opens rootDir under policy. Only SymlinkFollowAll can reach
+// outside rootDir, and only through links placed inside it.
+func openTree(rootDir string, policy models.SymlinkPolicy) (tree, error) {
+	if policy == models.SymlinkFollowAll {
+		return hostTree(rootDir), nil
+	}
+	root, err := os.OpenRoot(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	return &rootTree{root: root, deny: policy == models.SymlinkDeny}, nil
+}
+
+// treeName turns a repository path into a tree name. Cleaning it as an
+// absolute path first means ".." can never climb above the root.
+func treeName(p string) string {
+	name := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// relative returns name relative to base, both tree names below one another.
+func relative(base, name string) string {
+	switch {
+	case base == ".":
+		return name
+	case name == base:
+		return "."
+	}
+	return strings.TrimPrefix(name, base+"/")
+}
+
+// rootTree confines every operation to the root with os.Root, which follows
+// relative symlinks that stay inside and refuses everything else.
+type rootTree struct {
+	root *os.Root
+	deny bool // Refuse symlinks altogether
+}
+
+func (t *rootTree) Open(name string) (*os.File, error) {
+	if err := t.check(name, true); err != nil {
+		return nil, err
+	}
+	f, err := t.root.Open(name)
+	return f, t.wrap(name, err)
+}
+
+func (t *rootTree) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if err := t.check(name, true); err != nil {
+		return nil, err
+	}
+	f, err := t.root.OpenFile(name, flag, perm)
+	return f, t.wrap(name, err)
+}
+
+func (t *rootTree) Stat(name string) (os.FileInfo, error) {
+	if err := t.check(name, true); err != nil {
+		return nil, err
+	}
+	info, err := t.root.Stat(name)
+	return info, t.wrap(name, err)
+}
+
+func (t *rootTree) Lstat(name string) (os.FileInfo, error) {
+	if err := t.check(name, false); err != nil {
+		return nil, err
+	}
+	info, err := t.root.Lstat(name)
+	return info, t.wrap(name, err)
+}
+
+func (t *rootTree) Readlink(name string) (string, error) {
+	if err := t.check(name, false); err != nil {
+		return "", err
+	}
+	target, err := t.root.Readlink(name)
+	return target, t.wrap(name, err)
+}
+
+func (t *rootTree) Mkdir(name string, perm os.FileMode) error {
+	if err := t.check(name, false); err != nil {
+		return err
+	}
+	return t.wrap(name, t.root.Mkdir(name, perm))
+}
+
+func (t *rootTree) MkdirAll(name string, perm os.FileMode) error {
+	if err := t.check(name, true); err != nil {
+		return err
+	}
+	return t.wrap(name, t.root.MkdirAll(name, perm))
+}
+
+func (t *rootTree) Remove(name string) error {
+	if err := t.check(name, false); err != nil {
+		return err
+	}
+	return t.wrap(name, t.root.Remove(name))
+}
+
+func (t *rootTree) RemoveAll(name string) error {
+	if err := t.check(name, false); err != nil {
+		return err
+	}
+	return t.wrap(name, t.root.RemoveAll(name))
+}
+
+func (t *rootTree) Rename(oldname, newname string) error {
+	if err := t.check(oldname, false); err != nil {
+		return err
+	}
+	if err := t.check(newname, false); err != nil {
+		return err
+	}
+	return t.wrap(newname, t.root.Rename(oldname, newname))
+}
+
+func (t *rootTree) RenameNoReplace(oldname, newname string) (bool, error) {
+	if err := t.check(oldname, false); err != nil {
+		return true, err
+	}
+	if err := t.check(newname, false); err != nil {
+		return true, err
+	}
+	// The directories are opened through the root, so a link swapped in
+	// on the way cannot lead out of it
+	ok, err := renameBetween(t.root.Open, oldname, newname)
+	return ok, t.wrap(newname, err)
+}
+
+func (t *rootTree) Link(oldname, newname string) error {
+	if err := t.check(oldname, false); err != nil {
+		return err
+	}
+	if err := t.check(newname, false); err != nil {
+		return err
+	}
+	return t.wrap(newname, t.root.Link(oldname, newname))
+}
+
+func (t *rootTree) Close() error { return t.root.Close() }
+
+// check enforces SymlinkDeny: no directory on the way to name may be a
+// symlink, nor name itself when the operation would follow it. os.Root
+// still keeps a link swapped in after the check from leaving the root.
+func (t *rootTree) check(name string, followLast bool) error {
+	if !t.deny || name == "." {
+		return nil
+	}
+	parts := strings.Split(name, "/")
+	if !followLast {
+		parts = parts[:len(parts)-1]
+	}
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "/")
+		info, err := t.root.Lstat(prefix)
+		if err != nil {
+			return nil // Let the operation itself report the missing path
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return symlinkRefused(prefix)
+		}
+	}
+	return nil
+}
+
+// wrap reports links that would leave the root as a ForbiddenError.
+func (t *rootTree) wrap(name string, err error) error {
+	if escapesRoot(err) {
+		return symlinkRefused(name)
+	}
+	return err
+}
+
+// escapesRoot reports whether err is os.Root refusing a path that leaves
+// the root. The os package does not export that error, so the message is
+// compared instead.
+func escapesRoot(err error) bool {
+	var cause error
+	switch e := err.(type) {
+	case *os.PathError:
+		cause = e.Err
+	case *os.LinkError:
+		cause = e.Err
+	}
+	return cause != nil && cause.Error() == "path escapes from parent"
+}
+
+func symlinkRefused(name string) error {
+	return &errors.ForbiddenError{Path: path.Join("/", name), Action: "follow symlink"}
+}
+
+// hostTree is the unconfined directory used by SymlinkFollowAll.
+type hostTree string
+
+func (t hostTree) host(name string) string {
+	return filepath.Join(string(t), filepath.FromSlash(name))
+}
+
+func (t hostTree) Open(name string) (*os.File, error) { return os.Open(t.host(name)) }
+func (t hostTree) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(t.host(name), flag, perm)
+}
+func (t hostTree) Stat(name string) (os.FileInfo, error)  { return os.Stat(t.host(name)) }
+func (t hostTree) Lstat(name string) (os.FileInfo, error) { return os.Lstat(t.host(name)) }
+func (t hostTree) Readlink(name string) (string, error)   { return os.Readlink(t.host(name)) }
+func (t hostTree) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(t.host(name), perm)
+}
+func (t hostTree) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(t.host(name), perm)
+}
+func (t hostTree) Remove(name string) error    { return os.Remove(t.host(name)) }
+func (t hostTree) RemoveAll(name string) error { return os.RemoveAll(t.host(name)) }
+func (t hostTree) Rename(oldname, newname string) error {
+	return os.Rename(t.host(oldname), t.host(newname))
+}
+func (t hostTree) RenameNoReplace(oldname, newname string) (bool, error) {
+	return renameBetween(func(name string) (*os.File, error) { return os.Open(t.host(name)) }, oldname, newname)
+}
+func (t hostTree) Link(oldname, newname string) error {
+	return os.Link(t.host(oldname), t.host(newname))
+}
+func (t hostTree) Close() error { return nil }
+
+// reservedDirs sit at the root of every repository for the server's own
+// bookkeeping. They are left out of listings and archives, and hidingTree
+// makes them unreachable through request paths.
+var reservedDirs = []string{trashDir, versionsDir}
+
+// reserved reports whether the tree name lies in a reserved directory.
+func reserved(name string) bool {
+	first, _, _ := strings.Cut(name, "/")
+	return slices.Contains(reservedDirs, first)
+}
+
+// hidingTree is a tree in which the reserved directories do not exist.
+type hidingTree struct{ tree }
+
+func hidden(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+}
+
+func (t hidingTree) Open(name string) (*os.File, error) {
+	if reserved(name) {
+		return nil, hidden("open", name)
+	}
+	return t.tree.Open(name)
+}
+
+func (t hidingTree) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if reserved(name) {
+		return nil, hidden("open", name)
+	}
+	return t.tree.OpenFile(name, flag, perm)
+}
+
+func (t hidingTree) Stat(name string) (os.FileInfo, error) {
+	if reserved(name) {
+		return nil, hidden("stat", name)
+	}
+	return t.tree.Stat(name)
+}
+
+func (t hidingTree) Lstat(name string) (os.FileInfo, error) {
+	if reserved(name) {
+		return nil, hidden("lstat", name)
+	}
+	return t.tree.Lstat(name)
+}
+
+func (t hidingTree) Readlink(name string) (string, error) {
+	if reserved(name) {
+		return "", hidden("readlink", name)
+	}
+	return t.tree.Readlink(name)
+}
+
+func (t hidingTree) Mkdir(name string, perm os.FileMode) error {
+	if reserved(name) {
+		return hidden("mkdir", name)
+	}
+	return t.tree.Mkdir(name, perm)
+}
+
+func (t hidingTree) MkdirAll(name string, perm os.FileMode) error {
+	if reserved(name) {
+		return hidden("mkdir", name)
+	}
+	return t.tree.MkdirAll(name, perm)
+}
+
+func (t hidingTree) Remove(name string) error {
+	if reserved(name) {
+		return hidden("remove", name)
+	}
+	return t.tree.Remove(name)
+}
+
+func (t hidingTree) RemoveAll(name string) error {
+	if reserved(name) {
+		return hidden("remove", name)
+	}
+	return t.tree.RemoveAll(name)
+}
+
+func (t hidingTree) Rename(oldname, newname string) error {
+	if reserved(oldname) || reserved(newname) {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	return t.tree.Rename(oldname, newname)
+}
+
+func (t hidingTree) RenameNoReplace(oldname, newname string) (bool, error) {
+	if reserved(oldname) || reserved(newname) {
+		return true, &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	return t.tree.RenameNoReplace(oldname, newname)
+}
+
+func (t hidingTree) Link(oldname, newname string) error {
+	if reserved(oldname) || reserved(newname) {
+		return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	return t.tree.Link(oldname, newname)
+}
+
+// renameBetween opens the directories holding oldname and newname with
+// open and renames between them without replacing newname.
+func renameBetween(open func(name string) (*os.File, error), oldname, newname string) (bool, error) {
+	oldDir, err := open(path.Dir(oldname))
+	if err != nil {
+		return true, err
+	}
+	defer oldDir.Close()
+	newDir, err := open(path.Dir(newname))
+	if err != nil {
+		return true, err
+	}
+	defer newDir.Close()
+	ok, err := renameNoReplace(oldDir, path.Base(oldname), newDir, path.Base(newname))
+	if err != nil {
+		err = &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	return ok, err
+}
+
+// treeFS presents a tree as an fs.FS for fs.WalkDir and archiving.
+type treeFS struct{ tree tree }
+
+func (f treeFS) Open(name string) (fs.File, error) {
+	file, err := f.tree.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// createTemp creates a new temporary file in dir, like os.CreateTemp.
+func createTemp(t tree, dir string) (*os.File, string, error) {
+	for {
+		name := path.Join(dir, tempFilePrefix+strconv.FormatUint(rand.Uint64(), 36))
+		f, err := t.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, name, err
+	}
+}
@@ -0,0 +1,278 @@
+package fs
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	domainerrors "github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+const secret = "top secret"
+
+// newLinkedRoot creates a shared directory beside a secret one, with links
+// that stay inside the share and links that leave it:
+//
+//	share/docs/plan.txt
+//	share/plan-link   -> docs/plan.txt
+//	share/docs-link   -> docs
+//	share/secret-link -> ../secret/key.txt
+//	share/escape      -> /abs/path/to/secret
+func newLinkedRoot(t *testing.T) string {
+	t.Helper()
+	parent := t.TempDir()
+	root, outside := filepath.Join(parent, "share"), filepath.Join(parent, "secret")
+	for _, dir := range []string{filepath.Join(root, "docs"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "plan.txt"), []byte("plan"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "key.txt"), []byte(secret), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	links := map[string]string{
+		"plan-link":   "docs/plan.txt",
+		"docs-link":   "docs",
+		"secret-link": "../secret/key.txt",
+		"escape":      outside,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+	return root
+}
+
+// readAll serves p from repo and returns its content.
+func readAll(repo *LocalFileRepository, p string) (string, error) {
+	file, err := repo.ServeFile(p)
+	if err != nil {
+		return "", err
+	}
+	defer file.Content.Close()
+	data, err := io.ReadAll(file.Content)
+	return string(data), err
+}
+
+// zipNames archives p and returns the sorted entry names.
+func zipNames(t *testing.T, repo *LocalFileRepository, p string) []string {
+	t.Helper()
+	stream, err := repo.ZipDirectory(p, nil)
+	if err != nil {
+		t.Fatalf("Failed to start archive: %v", err)
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("Failed to archive: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSymlinkPolicies(t *testing.T) {
+	root := newLinkedRoot(t)
+
+	tests := []struct {
+		policy   models.SymlinkPolicy
+		readable map[string]bool // Path -> whether ServeFile succeeds
+		zip      string
+	}{
+		{models.SymlinkDeny,
+			map[string]bool{"/docs/plan.txt": true, "/plan-link": false, "/docs-link/plan.txt": false, "/secret-link": false, "/escape/key.txt": false},
+			"./ docs/ docs/plan.txt"},
+		{models.SymlinkFollowWithinRoot,
+			map[string]bool{"/docs/plan.txt": true, "/plan-link": true, "/docs-link/plan.txt": true, "/secret-link": false, "/escape/key.txt": false},
+			"./ docs/ docs/plan.txt plan-link"},
+		{models.SymlinkFollowAll,
+			map[string]bool{"/docs/plan.txt": true, "/plan-link": true, "/docs-link/plan.txt": true, "/secret-link": true, "/escape/key.txt": true},
+			"./ docs/ docs/plan.txt plan-link secret-link"},
+	}
+	for _, tt := range tests {
+		repo := NewLocalFileRepository(root).WithSymlinkPolicy(tt.policy)
+		for p, want := range tt.readable {
+			_, err := readAll(repo, p)
+			if got := err == nil; got != want {
+				t.Errorf("%s: reading %s succeeded = %v, want %v (%v)", tt.policy, p, got, want, err)
+			}
+			if err != nil {
+				if _, ok := err.(*domainerrors.ForbiddenError); !ok {
+					t.Errorf("%s: expected ForbiddenError for %s, got %T %v", tt.policy, p, err, err)
+				}
+			}
+		}
+		if got := strings.Join(zipNames(t, repo, "/"), " "); got != tt.zip {
+			t.Errorf("%s: expected archive %q, got %q", tt.policy, tt.zip, got)
+		}
+
+		// Links are always listed and described, whatever the policy
+		entries, err := repo.ListDirectory("/")
+		if err != nil {
+			t.Fatalf("%s: failed to list: %v", tt.policy, err)
+		}
+		if len(entries) != 5 {
+			t.Errorf("%s: expected 5 entries, got %d", tt.policy, len(entries))
+		}
+		if info, err := repo.Stat("/secret-link"); err != nil || info.SymlinkTarget != "../secret/key.txt" {
+			t.Errorf("%s: expected the link target, got %+v %v", tt.policy, info, err)
+		}
+	}
+}
+
+func TestSymlinkPoliciesProtectWrites(t *testing.T) {
+	root := newLinkedRoot(t)
+	outside := filepath.Join(filepath.Dir(root), "secret")
+
+	for _, policy := range []models.SymlinkPolicy{models.SymlinkDeny, models.SymlinkFollowWithinRoot} {
+		repo := NewLocalFileRepository(root).WithSymlinkPolicy(policy)
+		if _, _, err := repo.WriteFile("/escape/new.txt", io.NopCloser(strings.NewReader("x")), models.ConflictOverwrite); err == nil {
+			t.Errorf("%s: expected writing through an escaping link to fail", policy)
+		}
+		if err := repo.CreateDirectory("/escape/dir"); err == nil {
+			t.Errorf("%s: expected creating a directory through an escaping link to fail", policy)
+		}
+		if err := repo.Delete("/escape/key.txt", false); err == nil {
+			t.Errorf("%s: expected deleting through an escaping link to fail", policy)
+		}
+		if err := repo.Copy("/secret-link", "/copied.txt", false, nil); err == nil {
+			t.Errorf("%s: expected copying an escaping link to fail", policy)
+		}
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 1 {
+		t.Errorf("Expected the outside directory to be untouched, got %d entries", len(entries))
+	}
+
+	// Deleting the link itself is fine and leaves its target alone
+	repo := NewLocalFileRepository(root).WithSymlinkPolicy(models.SymlinkDeny)
+	if err := repo.Delete("/escape", false); err != nil {
+		t.Errorf("Failed to delete a link: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "key.txt")); err != nil {
+		t.Errorf("Expected the link target to survive: %v", err)
+	}
+}
+
+// FuzzPathsStayInRoot feeds arbitrary request paths to every operation and
+// checks that nothing outside the shared directory is read or changed.
+func FuzzPathsStayInRoot(f *testing.F) {
+	for _, seed := range []string{
+		"../secret/key.txt", "/../../secret/key.txt", "..\\secret\\key.txt", "docs/../../secret/key.txt",
+		"secret-link", "escape/key.txt", "escape/../escape/key.txt", "docs-link/../../secret/key.txt",
+		"./../secret", "//..//secret/key.txt", "%2e%2e/secret/key.txt", "docs/\x00/../..", "",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, p string) {
+		root := newLinkedRoot(t)
+		outside := filepath.Join(filepath.Dir(root), "secret")
+		repo := NewLocalFileRepository(root)
+
+		if content, err := readAll(repo, p); err == nil && content == secret {
+			t.Fatalf("ServeFile(%q) read a file outside the root", p)
+		}
+		if stream, err := repo.ZipDirectory(p, nil); err == nil {
+			data, _ := io.ReadAll(stream)
+			if bytes.Contains(data, []byte("key.txt")) {
+				t.Fatalf("ZipDirectory(%q) archived a file outside the root", p)
+			}
+		}
+		if entries, err := repo.ListDirectory(p); err == nil {
+			for _, entry := range entries {
+				if entry.Name == "key.txt" {
+					t.Fatalf("ListDirectory(%q) listed a directory outside the root", p)
+				}
+			}
+		}
+
+		repo.WriteFile(p, io.NopCloser(strings.NewReader("x")), models.ConflictOverwrite)
+		repo.CreateDirectory(p + "/made")
+		repo.Copy("/docs/plan.txt", p, true, nil)
+		repo.Move("/plan-link", p, true)
+		repo.Delete(p, true)
+
+		entries, err := os.ReadDir(outside)
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("Operations on %q changed the outside directory: %v %v", p, entries, err)
+		}
+		if data, err := os.ReadFile(filepath.Join(outside, "key.txt")); err != nil || string(data) != secret {
+			t.Fatalf("Operations on %q changed a file outside the root", p)
+		}
+		siblings, err := os.ReadDir(filepath.Dir(root))
+		if err != nil || len(siblings) != 2 {
+			t.Fatalf("Operations on %q created files beside the root: %v", p, siblings)
+		}
+	})
+}
+
+// noRenameNoReplace is a tree on a filesystem without a one-step rename
+// that keeps the destination.
+type noRenameNoReplace struct{ tree }
+
+func (noRenameNoReplace) RenameNoReplace(string, string) (bool, error) { return false, nil }
+
+func TestRenameNewNeverReplaces(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		open func(root string) (tree, error)
+	}{
+		{"root", func(root string) (tree, error) { return openTree(root, models.SymlinkFollowWithinRoot) }},
+		{"host", func(root string) (tree, error) { return openTree(root, models.SymlinkFollowAll) }},
+		{"fallback", func(root string) (tree, error) {
+			inner, err := openTree(root, models.SymlinkFollowWithinRoot)
+			return noRenameNoReplace{inner}, err
+		}},
+	} {
+		root := t.TempDir()
+		for name, content := range map[string]string{"a.txt": "a", "b.txt": "b"} {
+			if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, dir := range []string{"src", "empty"} {
+			if err := os.Mkdir(filepath.Join(root, dir), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tr, err := tt.open(root)
+		if err != nil {
+			t.Fatalf("%s: failed to open tree: %v", tt.name, err)
+		}
+		defer tr.Close()
+
+		if err := renameNew(tr, "a.txt", "b.txt"); !os.IsExist(err) {
+			t.Errorf("%s: expected a file in the way to be kept, got %v", tt.name, err)
+		}
+		if err := renameNew(tr, "src", "empty"); !os.IsExist(err) {
+			t.Errorf("%s: expected an empty directory in the way to be kept, got %v", tt.name, err)
+		}
+		for name, want := range map[string]string{"a.txt": "a", "b.txt": "b"} {
+			if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+				t.Errorf("%s: expected %s to hold %q, got %q %v", tt.name, name, want, data, err)
+			}
+		}
+		if err := renameNew(tr, "a.txt", "src/a.txt"); err != nil {
+			t.Errorf("%s: failed to rename to a free name: %v", tt.name, err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+			t.Errorf("%s: expected the old name to be gone, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package fs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// usageCounter holds the bytes in a repository's files, and apart from
+// them the bytes held in its trash and versions. The tree is walked once,
+// before the first change; after that every change adjusts the counts.
+// Files changed on disk behind the server's back are only picked up by a
+// restart.
+type usageCounter struct {
+	mu       sync.Mutex
+	measured bool
+	used     int64
+	held     int64
+	evicting sync.Mutex // One eviction at a time
+}
+
+// measure walks t, which must reach the reserved directories, the first
+// time it is called and returns the files' count. Every change calls it
+// before touching the tree, so no change can happen while the walk runs
+// and be counted twice.
+func (c *usageCounter) measure(t tree) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.measured {
+		if info, err := t.Stat("."); err == nil {
+			c.used = treeSize(t, ".", info)
+		}
+		c.held = heldSize(t)
+		c.measured = true
+	}
+	return c.used
+}
+
+func (c *usageCounter) add(delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used += delta
+}
+
+// hold adjusts the bytes held in the trash and versions.
+func (c *usageCounter) hold(delta int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.held += delta
+}
+
+// excess returns how many held bytes have to go for the files and what is
+// held to fit in limit.
+func (c *usageCounter) excess(limit int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.used + c.held - limit
+}
+
+// reserve counts n more bytes unless that takes the count over limit
+// (zero means no limit).
+func (c *usageCounter) reserve(n, limit int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if limit > 0 && c.used+n > limit {
+		return false
+	}
+	c.used += n
+	return true
+}
+
+// WithQuota limits the bytes the repository's files may take; zero means no
+// limit. Trashed entries and versions take what the files leave free: once
+// a change needs their room, the oldest of them are removed for good.
+func (r *LocalFileRepository) WithQuota(quota int64) *LocalFileRepository {
+	r.quota = quota
+	return r
+}
+
+// UsageAt reports the whole repository, which is a single area.
+func (r *LocalFileRepository) UsageAt(string) (models.Usage, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return models.Usage{}, err
+	}
+	defer t.Close()
+	return models.Usage{Name: "/", Used: r.usage.measure(t), Limit: r.quota}, nil
+}
+
+func (r *LocalFileRepository) Usages() ([]models.Usage, error) {
+	usage, err := r.UsageAt("/")
+	if err != nil {
+		return nil, err
+	}
+	return []models.Usage{usage}, nil
+}
+
+// quotaFor returns the error for a write to p that does not fit.
+func (r *LocalFileRepository) quotaFor(p string) error {
+	return &errors.QuotaExceededError{Path: p, Quota: "/", Limit: r.quota}
+}
+
+// sizeAt returns the bytes in the files at or below name, or zero if
+// there is nothing there.
+func sizeAt(t tree, name string) int64 {
+	info, err := t.Lstat(name)
+	if err != nil {
+		return 0
+	}
+	return treeSize(t, name, info)
+}
+
+// treeSize adds up the regular files at or below name, leaving out
+// temporary files and, unless name is in one, the reserved directories.
+func treeSize(t tree, name string, info os.FileInfo) int64 {
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return 0
+		}
+		return info.Size()
+	}
+	var total int64
+	fs.WalkDir(treeFS{t}, name, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if reserved(current) && !reserved(name) {
+			return fs.SkipDir
+		}
+		if entry.Type().IsRegular() && !strings.HasPrefix(path.Base(current), tempFilePrefix) {
+			if info, err := entry.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// reservingReader counts the bytes of a write against the repository's
+// usage as they arrive, so concurrent writes see each other, and fails
+// once the quota would be crossed. credit is what the write frees when it
+// replaces a file. makeRoom evicts what is held in the trash and versions
+// when the bytes need its room.
+type reservingReader struct {
+	models.ReadCloser
+	usage    *usageCounter
+	limit    int64
+	credit   int64
+	reserved int64
+	makeRoom func(credit int64)
+	err      error
+}
+
+func (r *reservingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		limit := r.limit
+		if limit > 0 {
+			limit += r.credit
+		}
+		if !r.usage.reserve(int64(n), limit) {
+			return n, r.err
+		}
+		r.reserved += int64(n)
+		r.makeRoom(r.credit)
+	}
+	return n, err
+}
+
+// heldSize adds up the versions and the trashed entries that have a
+// record, with their versions; anything else in the reserved directories
+// could not be evicted and is left out.
+func heldSize(t tree) int64 {
+	total := sizeAt(t, versionsDir)
+	items, _ := listTrash(t)
+	for _, item := range items {
+		total += trashedSize(t, item.ID)
+	}
+	return total
+}
+
+// countVersions runs change, which adds or removes versions of name or of
+// the files below it, and counts what they hold afterwards.
+func (r *LocalFileRepository) countVersions(all tree, name string, change func() error) error {
+	dir := path.Join(versionsDir, name)
+	before := sizeAt(all, dir)
+	err := change()
+	r.usage.hold(sizeAt(all, dir) - before)
+	return err
+}
+
+// heldEntry is what eviction can remove: a trashed entry with its
+// versions, or a single version.
+type heldEntry struct {
+	since   time.Time // When it was trashed or replaced
+	trashID string    // Of a trashed entry
+	version string    // Tree name of a version
+}
+
+// makeRoom removes trashed entries and versions, oldest first, until the
+// files and what is held fit in the quota plus credit, the bytes a running
+// write will free. Files always come first; it is their quota.
+func (r *LocalFileRepository) makeRoom(all tree, credit int64) {
+	if r.quota <= 0 || r.usage.excess(r.quota+credit) <= 0 {
+		return
+	}
+	r.usage.evicting.Lock()
+	defer r.usage.evicting.Unlock()
+	for _, entry := range heldEntries(all) {
+		if r.usage.excess(r.quota+credit) <= 0 {
+			return
+		}
+		if entry.trashID != "" {
+			r.purgeTrashed(all, entry.trashID)
+			continue
+		}
+		if info, err := all.Lstat(entry.version); err == nil && all.Remove(entry.version) == nil {
+			r.usage.hold(-info.Size())
+			all.Remove(path.Dir(entry.version)) // Only if that left it empty
+		}
+	}
+}
+
+// heldEntries lists the trashed entries and versions, oldest first.
+func heldEntries(all tree) []heldEntry {
+	var entries []heldEntry
+	items, _ := listTrash(all)
+	for _, item := range items {
+		entries = append(entries, heldEntry{since: item.DeletedAt, trashID: item.ID})
+	}
+	fs.WalkDir(treeFS{all}, versionsDir, func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() && validVersionID(entry.Name()) {
+			entries = append(entries, heldEntry{since: versionTime(entry.Name()), version: name})
+		}
+		return nil
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].since.Before(entries[j].since) })
+	return entries
+}
+
+var _ ports.UsageRepository = (*LocalFileRepository)(nil)
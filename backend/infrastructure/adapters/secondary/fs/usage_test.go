@@ -0,0 +1,177 @@
+package fs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	domainerrors "github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+func usedBytes(t *testing.T, repo *LocalFileRepository) int64 {
+	t.Helper()
+	usage, err := repo.UsageAt("/")
+	if err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	return usage.Used
+}
+
+func writeString(repo *LocalFileRepository, p, content string, policy models.ConflictPolicy) error {
+	_, _, err := repo.WriteFile(p, io.NopCloser(strings.NewReader(content)), policy)
+	return err
+}
+
+func TestUsageFollowsChanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+
+	steps := []struct {
+		name string
+		run  func() error
+		want int64
+	}{
+		{"measure", func() error { return nil }, 5},
+		{"write", func() error { return writeString(repo, "/b.txt", "bravo!", models.ConflictOverwrite) }, 11},
+		// The replaced content becomes a version, held apart from the files
+		{"overwrite", func() error { return writeString(repo, "/b.txt", "b", models.ConflictOverwrite) }, 6},
+		{"rename", func() error { return writeString(repo, "/b.txt", "bb", models.ConflictRename) }, 8},
+		{"copy", func() error { return repo.Copy("/docs", "/copy", false, nil) }, 13},
+		{"move over", func() error { return repo.Move("/copy", "/docs", true) }, 8},
+		{"delete", func() error { return repo.Delete("/b.txt", false) }, 7},
+		{"trash", func() error { _, err := repo.MoveToTrash("/docs", "alice"); return err }, 2},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := usedBytes(t, repo); got != step.want {
+			t.Errorf("%s: expected %d bytes in use, got %d", step.name, step.want, got)
+		}
+	}
+
+	items, err := repo.ListTrash()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Failed to list trash: %v %v", items, err)
+	}
+	if _, err := repo.RestoreFromTrash(items[0].ID, "", "alice", models.ConflictReject); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if got := usedBytes(t, repo); got != 7 {
+		t.Errorf("Expected the restored entry to count again, got %d", got)
+	}
+}
+
+func TestQuotaRejectsWrites(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	repo := NewLocalFileRepository(root).WithQuota(10)
+
+	err := writeString(repo, "/big.txt", "123456", models.ConflictOverwrite)
+	if quota, ok := err.(*domainerrors.QuotaExceededError); !ok || quota.Limit != 10 {
+		t.Fatalf("Expected a QuotaExceededError, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "big.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the rejected file to be discarded")
+	}
+	if got := usedBytes(t, repo); got != 5 {
+		t.Errorf("Expected a rejected write to release its bytes, got %d", got)
+	}
+
+	// Replacing a file frees its bytes first
+	if err := writeString(repo, "/a.txt", "1234567890", models.ConflictOverwrite); err != nil {
+		t.Errorf("Expected an overwrite that fits to succeed, got %v", err)
+	}
+	if err := repo.Copy("/a.txt", "/b.txt", false, nil); err == nil {
+		t.Error("Expected a copy over quota to fail")
+	}
+	if err := repo.Delete("/a.txt", false); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if err := writeString(repo, "/c.txt", "1234567890", models.ConflictOverwrite); err != nil {
+		t.Errorf("Expected deleted bytes to be free again, got %v", err)
+	}
+}
+
+// diskBytes adds up the files under root as they are on disk, trash
+// records aside.
+func diskBytes(t *testing.T, root string) int64 {
+	t.Helper()
+	var total int64
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		if filepath.Base(filepath.Dir(p)) == trashDir && strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err == nil {
+			total += info.Size()
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk %s: %v", root, err)
+	}
+	return total
+}
+
+func TestQuotaBoundsTrashAndVersions(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithQuota(10).WithVersions(models.VersionPolicy{Keep: 5})
+
+	// Every round keeps a version and trashes the file, so only evicting
+	// what is held lets the next one fit
+	for _, content := range []string{"aaaaaa", "bbbbbb", "cccccc", "dddddd"} {
+		if err := writeString(repo, "/a.txt", content, models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write %q: %v", content, err)
+		}
+		if err := writeString(repo, "/a.txt", content[:4], models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to overwrite %q: %v", content, err)
+		}
+		if _, err := repo.MoveToTrash("/a.txt", "alice"); err != nil {
+			t.Fatalf("Failed to trash %q: %v", content, err)
+		}
+		if got := diskBytes(t, root); got > 10 {
+			t.Errorf("Expected at most 10 bytes on disk after %q, got %d", content, got)
+		}
+	}
+
+	items, err := repo.ListTrash()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Expected only the latest entry in the trash, got %+v %v", items, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, trashDir, items[0].ID)); err != nil || string(data) != "dddd" {
+		t.Errorf("Expected the latest entry to be kept, got %q %v", data, err)
+	}
+	if got := usedBytes(t, repo); got != 0 {
+		t.Errorf("Expected trashed entries not to count as files, got %d", got)
+	}
+
+	// Restoring a file needs no more room than it already took
+	if _, err := repo.RestoreFromTrash(items[0].ID, "", "alice", models.ConflictReject); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if err := writeString(repo, "/b.txt", "123456", models.ConflictReject); err != nil {
+		t.Fatalf("Failed to write beside the restored file: %v", err)
+	}
+	if got := diskBytes(t, root); got > 10 {
+		t.Errorf("Expected at most 10 bytes on disk, got %d", got)
+	}
+	if versions, err := repo.ListVersions("/a.txt"); err != nil || len(versions) != 0 {
+		t.Errorf("Expected the version to make room for the files, got %+v %v", versions, err)
+	}
+}
@@ -0,0 +1,360 @@
+package fs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// versionsDir holds the earlier contents of overwritten files at the root of
+// the repository. The versions of "docs/a.txt" are the files in
+// ".versions/docs/a.txt", each named by its ID.
+const versionsDir = ".versions"
+
+// versionTimeLayout starts every version ID, so IDs sort by when the
+// version was replaced.
+const versionTimeLayout = "20060102T150405.000000000"
+
+// WithVersions keeps earlier contents of files that writes overwrite
+func (r *LocalFileRepository) WithVersions(policy models.VersionPolicy) *LocalFileRepository {
+	r.versions = policy
+	return r
+}
+
+func (r *LocalFileRepository) ListVersions(p string) ([]*models.FileVersion, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	name, err := versionedName(p)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := versionIDs(t, name)
+	if err != nil {
+		return nil, err
+	}
+	versions := []*models.FileVersion{}
+	for _, id := range ids {
+		info, err := t.Lstat(path.Join(versionsDir, name, id))
+		if err != nil {
+			continue // Pruned meanwhile
+		}
+		versions = append(versions, &models.FileVersion{
+			ID:         id,
+			Path:       path.Join("/", name),
+			Size:       info.Size(),
+			Modified:   info.ModTime(),
+			ReplacedAt: versionTime(id),
+		})
+	}
+	return versions, nil
+}
+
+func (r *LocalFileRepository) OpenVersion(p, id string) (*models.FileContent, error) {
+	t, err := r.openAll()
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close() // The open file stays usable
+
+	name, err := versionedName(p)
+	if err != nil {
+		return nil, err
+	}
+	if !validVersionID(id) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	file, err := t.Open(path.Join(versionsDir, name, id))
+	if os.IsNotExist(err) {
+		return nil, &errors.NotFoundError{Path: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &models.FileContent{
+		Content: file,
+		Name:    path.Base(name),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// RestoreVersion writes the version through WriteFile, so the restore is
+// atomic and, when it overwrites, the current content becomes a version.
+func (r *LocalFileRepository) RestoreVersion(p, id string, policy models.ConflictPolicy) (string, error) {
+	file, err := r.OpenVersion(p, id)
+	if err != nil {
+		return "", err
+	}
+	stored, _, err := r.WriteFile(p, file.Content, policy)
+	return stored, err
+}
+
+// PruneVersions applies the policy to every versioned file. With
+// versioning off, existing versions are left alone.
+func (r *LocalFileRepository) PruneVersions() (int, error) {
+	if !r.versions.Enabled() {
+		return 0, nil
+	}
+	t, err := r.openAll()
+	if err != nil {
+		return 0, err
+	}
+	defer t.Close()
+
+	r.usage.measure(t)
+	held := sizeAt(t, versionsDir)
+	pruned := 0
+	var dirs []string
+	err = fs.WalkDir(treeFS{t}, versionsDir, func(name string, entry fs.DirEntry, err error) error {
+		if os.IsNotExist(err) && name == versionsDir {
+			return fs.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() || name == versionsDir {
+			return nil
+		}
+		dirs = append(dirs, name)
+		n, err := pruneVersions(t, strings.TrimPrefix(name, versionsDir+"/"), r.versions)
+		pruned += n
+		return err
+	})
+	// Drop directories left empty, deepest first; non-empty ones stay
+	for _, dir := range slices.Backward(dirs) {
+		t.Remove(dir)
+	}
+	r.usage.hold(sizeAt(t, versionsDir) - held)
+	return pruned, err
+}
+
+// keepVersion makes the file at name a version before a write replaces it,
+// and prunes the older versions.
+func keepVersion(t tree, name string, policy models.VersionPolicy) error {
+	kept, err := saveVersion(t, name)
+	if err != nil || kept == "" {
+		return err
+	}
+	_, err = pruneVersions(t, name, policy)
+	return err
+}
+
+// saveVersion adds the file at name to its versions, without pruning, and
+// returns the version's tree name, or "" when there is no file to keep. A
+// hard link keeps the file in place until the replacement lands.
+func saveVersion(t tree, name string) (string, error) {
+	info, err := t.Lstat(name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return "", err
+	}
+	dir := path.Join(versionsDir, name)
+	if err := t.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	id, err := newVersionID()
+	if err != nil {
+		return "", err
+	}
+	if err := t.Link(name, path.Join(dir, id)); err != nil {
+		// No hard links on this filesystem; copy instead
+		if err := copyVersion(t, name, dir, id); err != nil {
+			return "", err
+		}
+	}
+	return path.Join(dir, id), nil
+}
+
+// keepReplaced deals with the versions at name before another entry takes
+// its place, as WriteFile does: with versioning on, a file there becomes a
+// version and its history stays with the path, while the versions of
+// anything else are dropped with it.
+func (r *LocalFileRepository) keepReplaced(t tree, name string) error {
+	info, err := t.Lstat(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return dropVersions(t, name)
+	}
+	if r.versions.Enabled() {
+		return keepVersion(t, name, r.versions)
+	}
+	return nil
+}
+
+// copyVersion copies name into dir as version id through a temporary file.
+func copyVersion(t tree, name, dir, id string) error {
+	src, err := t.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp, tmpName, err := createTemp(t, dir)
+	if err != nil {
+		return err
+	}
+	defer t.Remove(tmpName)
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return t.Rename(tmpName, path.Join(dir, id))
+}
+
+// pruneVersions removes the versions of name beyond policy.Keep or older
+// than policy.MaxAge, returning how many it removed.
+func pruneVersions(t tree, name string, policy models.VersionPolicy) (int, error) {
+	ids, err := versionIDs(t, name)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for i, id := range ids {
+		expired := policy.MaxAge > 0 && time.Since(versionTime(id)) > policy.MaxAge
+		if i < policy.Keep && !expired {
+			continue
+		}
+		if err := t.Remove(path.Join(versionsDir, name, id)); err != nil && !os.IsNotExist(err) {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// moveVersions moves the versions kept under from to to, both directories
+// of the tree, merging them into any already kept there. The versions of
+// every file below a directory move with it.
+func moveVersions(t tree, from, to string) error {
+	info, err := t.Lstat(from)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := t.Lstat(to); os.IsNotExist(err) {
+		if err := t.MkdirAll(path.Dir(to), 0700); err != nil {
+			return err
+		}
+		return t.Rename(from, to)
+	}
+	if !info.IsDir() {
+		return t.Rename(from, to) // IDs are unique, so nothing is lost
+	}
+	d, err := t.Open(from)
+	if err != nil {
+		return err
+	}
+	entries, err := d.ReadDir(-1)
+	d.Close()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := moveVersions(t, path.Join(from, entry.Name()), path.Join(to, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return t.Remove(from)
+}
+
+// dropVersions removes the versions of name and of every file below it.
+func dropVersions(t tree, name string) error {
+	return t.RemoveAll(path.Join(versionsDir, name))
+}
+
+// versionIDs lists the version IDs kept for name, newest first.
+func versionIDs(t tree, name string) ([]string, error) {
+	d, err := t.Open(path.Join(versionsDir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := d.ReadDir(-1)
+	d.Close()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && validVersionID(entry.Name()) {
+			ids = append(ids, entry.Name())
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// versionedName turns a request path into the tree name of a file that can
+// have versions.
+func versionedName(p string) (string, error) {
+	name := treeName(p)
+	if name == "." || reserved(name) {
+		return "", &errors.NotFoundError{Path: p}
+	}
+	return name, nil
+}
+
+// newVersionID returns an ID starting with the current time.
+func newVersionID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format(versionTimeLayout) + "-" + hex.EncodeToString(b), nil
+}
+
+// versionTime returns when version id was replaced.
+func versionTime(id string) time.Time {
+	stamp, _, _ := strings.Cut(id, "-")
+	t, _ := time.Parse(versionTimeLayout, stamp)
+	return t
+}
+
+// validVersionID rejects names that newVersionID could not have made, and
+// with them anything that could reach outside the versions directory.
+func validVersionID(id string) bool {
+	stamp, suffix, ok := strings.Cut(id, "-")
+	if !ok || len(suffix) != 8 {
+		return false
+	}
+	if _, err := hex.DecodeString(suffix); err != nil {
+		return false
+	}
+	_, err := time.Parse(versionTimeLayout, stamp)
+	return err == nil
+}
+
+var _ ports.VersionRepository = (*LocalFileRepository)(nil)
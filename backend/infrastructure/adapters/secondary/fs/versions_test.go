@@ -0,0 +1,303 @@
+package fs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	domainerrors "github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+)
+
+func TestOverwriteKeepsVersions(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 2})
+	write := func(content string, policy models.ConflictPolicy) {
+		t.Helper()
+		if _, _, err := repo.WriteFile("/docs/a.txt", io.NopCloser(strings.NewReader(content)), policy); err != nil {
+			t.Fatalf("Failed to write %q: %v", content, err)
+		}
+	}
+	for _, content := range []string{"one", "two", "three", "four"} {
+		write(content, models.ConflictOverwrite)
+	}
+	// Writes that do not replace the file keep no version
+	write("copy", models.ConflictRename)
+
+	versions, err := repo.ListVersions("/docs/a.txt")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	var contents []string
+	for _, version := range versions {
+		if version.Path != "/docs/a.txt" || version.ReplacedAt.IsZero() {
+			t.Errorf("Unexpected version %+v", version)
+		}
+		file, err := repo.OpenVersion("/docs/a.txt", version.ID)
+		if err != nil {
+			t.Fatalf("Failed to open version: %v", err)
+		}
+		data, _ := io.ReadAll(file.Content)
+		file.Content.Close()
+		contents = append(contents, string(data))
+	}
+	if got := strings.Join(contents, " "); got != "three two" {
+		t.Errorf("Expected the two latest versions newest first, got %q", got)
+	}
+
+	entries, err := repo.ListDirectory("/")
+	if err != nil {
+		t.Fatalf("Failed to list root: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "docs" {
+		t.Errorf("Expected the versions to be hidden, got %+v", entries)
+	}
+	if names := strings.Join(zipNames(t, repo, "/"), " "); names != "./ docs/ docs/a (1).txt docs/a.txt" {
+		t.Errorf("Expected the versions to be left out of zips, got %q", names)
+	}
+	if _, err := repo.ServeFile("/" + versionsDir + "/docs/a.txt/" + versions[0].ID); err == nil {
+		t.Error("Expected versions to be out of reach through paths")
+	}
+	if _, err := repo.OpenVersion("/docs/a.txt", "../../../docs/a.txt"); err == nil {
+		t.Error("Expected an ID outside the versions to be refused")
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, content := range []string{"one", "two"} {
+		if _, _, err := repo.WriteFile("/a.txt", io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+	versions, err := repo.ListVersions("/a.txt")
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("Expected one version, got %+v %v", versions, err)
+	}
+	one := versions[0].ID
+
+	_, err = repo.RestoreVersion("/a.txt", one, models.ConflictReject)
+	if _, ok := err.(*domainerrors.ConflictError); !ok {
+		t.Errorf("Expected ConflictError, got %T %v", err, err)
+	}
+	if stored, err := repo.RestoreVersion("/a.txt", one, models.ConflictRename); err != nil || stored != "/a (1).txt" {
+		t.Errorf("Expected /a (1).txt, got %q %v", stored, err)
+	}
+	if _, err := repo.RestoreVersion("/a.txt", one, models.ConflictOverwrite); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	for name, want := range map[string]string{"a.txt": "one", "a (1).txt": "one"} {
+		if data, err := os.ReadFile(filepath.Join(root, name)); err != nil || string(data) != want {
+			t.Errorf("Expected %s to hold %q, got %q %v", name, want, data, err)
+		}
+	}
+	// The replaced content is a version in turn
+	if versions, _ := repo.ListVersions("/a.txt"); len(versions) != 2 {
+		t.Errorf("Expected the restore to keep the replaced content, got %+v", versions)
+	}
+	if _, err := repo.RestoreVersion("/a.txt", "20000101T000000.000000000-00000000", models.ConflictOverwrite); err == nil {
+		t.Error("Expected an unknown version to be refused")
+	} else if _, ok := err.(*domainerrors.NotFoundError); !ok {
+		t.Errorf("Expected NotFoundError, got %T %v", err, err)
+	}
+}
+
+func TestPruneVersions(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, content := range []string{"one", "two", "three"} {
+		if _, _, err := repo.WriteFile("/docs/a.txt", io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	if pruned, err := repo.PruneVersions(); err != nil || pruned != 0 {
+		t.Errorf("Expected nothing to prune, got %d %v", pruned, err)
+	}
+	repo.WithVersions(models.VersionPolicy{Keep: 0})
+	if pruned, err := repo.PruneVersions(); err != nil || pruned != 0 {
+		t.Errorf("Expected versioning off to leave versions alone, got %d %v", pruned, err)
+	}
+	repo.WithVersions(models.VersionPolicy{Keep: 5, MaxAge: 1})
+	if pruned, err := repo.PruneVersions(); err != nil || pruned != 2 {
+		t.Errorf("Expected both versions to have expired, got %d %v", pruned, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, versionsDir, "docs")); !os.IsNotExist(err) {
+		t.Errorf("Expected emptied version directories to be removed, got %v", err)
+	}
+}
+
+func TestVersionsFollowTheirFile(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, p := range []string{"/docs/a.txt", "/docs/a.txt", "/b.txt", "/b.txt"} {
+		if err := writeString(repo, p, "content", models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write %s: %v", p, err)
+		}
+	}
+	count := func(p string) int {
+		t.Helper()
+		versions, err := repo.ListVersions(p)
+		if err != nil {
+			t.Fatalf("Failed to list versions of %s: %v", p, err)
+		}
+		return len(versions)
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+		want map[string]int // Versions expected per path
+	}{
+		{"move directory", func() error { return repo.Move("/docs", "/archive", false) },
+			map[string]int{"/docs/a.txt": 0, "/archive/a.txt": 1}},
+		{"rename file", func() error { return repo.Move("/archive/a.txt", "/archive/c.txt", false) },
+			map[string]int{"/archive/a.txt": 0, "/archive/c.txt": 1}},
+		{"trash", func() error { _, err := repo.MoveToTrash("/archive", "alice"); return err },
+			map[string]int{"/archive/c.txt": 0}},
+		{"delete", func() error { return repo.Delete("/b.txt", false) },
+			map[string]int{"/b.txt": 0}},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		for p, want := range step.want {
+			if got := count(p); got != want {
+				t.Errorf("%s: expected %d versions of %s, got %d", step.name, want, p, got)
+			}
+		}
+	}
+
+	items, err := repo.ListTrash()
+	if err != nil || len(items) != 1 {
+		t.Fatalf("Failed to list trash: %+v %v", items, err)
+	}
+	if _, err := repo.RestoreFromTrash(items[0].ID, "/restored", "alice", models.ConflictReject); err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if got := count("/restored/c.txt"); got != 1 {
+		t.Errorf("Expected the versions to come back with the entry, got %d", got)
+	}
+	if _, err := repo.MoveToTrash("/restored", "alice"); err != nil {
+		t.Fatalf("Failed to trash: %v", err)
+	}
+	if purged, err := repo.PurgeTrashBefore(time.Now().Add(time.Minute)); err != nil || purged != 1 {
+		t.Fatalf("Failed to purge: %d %v", purged, err)
+	}
+	// Moves leave empty directories behind for pruning, but no versions
+	err = filepath.WalkDir(filepath.Join(root, versionsDir), func(p string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			t.Errorf("Expected every version to be gone, found %s", p)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk versions: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, trashDir)); len(entries) != 0 {
+		t.Errorf("Expected the trash to be empty, got %d entries", len(entries))
+	}
+}
+
+func TestReplacingKeepsVersions(t *testing.T) {
+	root := t.TempDir()
+	repo := NewLocalFileRepository(root).WithVersions(models.VersionPolicy{Keep: 5})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if err := writeString(repo, "/"+name+".txt", name, models.ConflictReject); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	trashed, err := repo.MoveToTrash("/d.txt", "alice")
+	if err != nil {
+		t.Fatalf("Failed to trash: %v", err)
+	}
+	latest := func(p string) string {
+		t.Helper()
+		versions, err := repo.ListVersions(p)
+		if err != nil || len(versions) == 0 {
+			t.Fatalf("Expected versions of %s, got %+v %v", p, versions, err)
+		}
+		file, err := repo.OpenVersion(p, versions[0].ID)
+		if err != nil {
+			t.Fatalf("Failed to open version: %v", err)
+		}
+		defer file.Content.Close()
+		data, _ := io.ReadAll(file.Content)
+		return string(data)
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+		path string
+		want string // Latest version of path afterwards
+	}{
+		{"move", func() error { return repo.Move("/a.txt", "/b.txt", true) }, "/b.txt", "b"},
+		{"copy", func() error { return repo.Copy("/b.txt", "/c.txt", true, nil) }, "/c.txt", "c"},
+		{"restore", func() error {
+			_, err := repo.RestoreFromTrash(trashed.ID, "/c.txt", "alice", models.ConflictOverwrite)
+			return err
+		}, "/c.txt", "a"},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := latest(step.path); got != step.want {
+			t.Errorf("%s: expected the replaced %q as the latest version, got %q", step.name, step.want, got)
+		}
+	}
+	if versions, _ := repo.ListVersions("/c.txt"); len(versions) != 2 {
+		t.Errorf("Expected c.txt to keep both replaced contents, got %+v", versions)
+	}
+}
+
+// failingRename is a tree whose renames fail, like a write whose file
+// cannot be put in place.
+type failingRename struct{ tree }
+
+func (failingRename) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: os.ErrPermission}
+}
+
+func TestFailedOverwriteKeepsNoVersion(t *testing.T) {
+	root := t.TempDir()
+	policy := models.VersionPolicy{Keep: 1}
+	repo := NewLocalFileRepository(root).WithVersions(policy)
+	for _, content := range []string{"one", "two"} {
+		if _, _, err := repo.WriteFile("/a.txt", io.NopCloser(strings.NewReader(content)), models.ConflictOverwrite); err != nil {
+			t.Fatalf("Failed to write %q: %v", content, err)
+		}
+	}
+
+	inner, err := openTree(root, models.SymlinkFollowWithinRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inner.Close()
+	if err := os.WriteFile(filepath.Join(root, "new.tmp"), []byte("three"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := placeVersioned(failingRename{inner}, "new.tmp", "a.txt", models.ConflictOverwrite, policy); err == nil {
+		t.Fatal("Expected the placement to fail")
+	}
+
+	// The older version survives, and the file is not kept a second time
+	versions, err := repo.ListVersions("/a.txt")
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("Expected the one earlier version, got %+v %v", versions, err)
+	}
+	file, err := repo.OpenVersion("/a.txt", versions[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to open version: %v", err)
+	}
+	defer file.Content.Close()
+	if data, _ := io.ReadAll(file.Content); string(data) != "one" {
+		t.Errorf("Expected version %q, got %q", "one", data)
+	}
+}
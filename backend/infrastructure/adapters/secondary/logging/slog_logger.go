@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// LevelFatal ranks above slog.LevelError; Fatal logs at this level before exiting.
+const LevelFatal = slog.Level(12)
+
+// SlogLogger implements ports.Logger on log/slog.
+type SlogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar // Shared with child loggers so SetLevel affects them too
+	exit   func(code int)
+}
+
+// NewSlogLogger writes to w using format "json" or "text", dropping messages
+// below level ("debug", "info", "warn" or "error").
+func NewSlogLogger(w io.Writer, format, level string) (*SlogLogger, error) {
+	minLevel := new(slog.LevelVar)
+	if err := setLevel(minLevel, level); err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{
+		Level: minLevel,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.LevelKey {
+				if lvl, ok := attr.Value.Any().(slog.Level); ok && lvl >= LevelFatal {
+					attr.Value = slog.StringValue("FATAL")
+				}
+			}
+			return attr
+		},
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+	}
+	return &SlogLogger{logger: slog.New(handler), level: minLevel, exit: os.Exit}, nil
+}
+
+// SetLevel changes the minimum level of this logger and every logger derived
+// from it. An unknown level leaves the current one in place.
+func (l *SlogLogger) SetLevel(level string) error {
+	return setLevel(l.level, level)
+}
+
+// setLevel parses level ("debug", "info", "warn" or "error") into v.
+func setLevel(v *slog.LevelVar, level string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q (want debug, info, warn or error)", level)
+	}
+	v.Set(parsed)
+	return nil
+}
+
+// Debug logs a diagnostic message.
+func (l *SlogLogger) Debug(msg string, keysAndValues ...any) {
+	l.logger.Debug(msg, keysAndValues...)
+}
+
+// Info logs an informational message.
+func (l *SlogLogger) Info(msg string, keysAndValues ...any) {
+	l.logger.Info(msg, keysAndValues...)
+}
+
+// Warn logs a warning message.
+func (l *SlogLogger) Warn(msg string, keysAndValues ...any) {
+	l.logger.Warn(msg, keysAndValues...)
+}
+
+// Error logs an error message.
+func (l *SlogLogger) Error(msg string, keysAndValues ...any) {
+	l.logger.Error(msg, keysAndValues...)
+}
+
+// Fatal logs a message at LevelFatal and exits with status 1.
+func (l *SlogLogger) Fatal(msg string, keysAndValues ...any) {
+	l.logger.Log(context.Background(), LevelFatal, msg, keysAndValues...)
+	l.exit(1)
+}
+
+// With returns a child logger carrying keysAndValues on every message.
+func (l *SlogLogger) With(keysAndValues ...any) ports.Logger {
+	return &SlogLogger{logger: l.logger.With(keysAndValues...), level: l.level, exit: l.exit}
+}
+
+var _ ports.Logger = (*SlogLogger)(nil)
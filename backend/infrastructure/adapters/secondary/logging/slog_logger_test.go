@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Failed to parse log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestSlogLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewSlogLogger(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	exitCode := -1
+	logger.exit = func(code int) { exitCode = code }
+
+	logger.Debug("hidden")
+	logger.Info("Downloaded 100% of /a%20b", "path", "/a%20b")
+	logger.With("request_id", "abc").Warn("Slow request", "ms", 1200)
+	logger.Fatal("Cannot continue", "error", "boom")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines (debug filtered out), got %d: %s", len(lines), buf.String())
+	}
+	if lines[0]["msg"] != "Downloaded 100% of /a%20b" || lines[0]["path"] != "/a%20b" {
+		t.Errorf("Expected message and path to survive verbatim, got %v", lines[0])
+	}
+	if lines[1]["level"] != "WARN" || lines[1]["request_id"] != "abc" || lines[1]["ms"] != float64(1200) {
+		t.Errorf("Expected child logger attributes, got %v", lines[1])
+	}
+	if lines[2]["level"] != "FATAL" || lines[2]["error"] != "boom" {
+		t.Errorf("Expected FATAL line with attributes, got %v", lines[2])
+	}
+	if exitCode != 1 {
+		t.Errorf("Expected Fatal to exit with 1, got %d", exitCode)
+	}
+}
+
+func TestSlogLoggerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewSlogLogger(&buf, "text", "debug")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	logger.Debug("visible", "key", "value")
+	if !strings.Contains(buf.String(), "level=DEBUG") || !strings.Contains(buf.String(), "key=value") {
+		t.Errorf("Expected text debug line, got %q", buf.String())
+	}
+
+	if _, err := NewSlogLogger(&buf, "xml", "info"); err == nil {
+		t.Error("Expected unknown format to fail")
+	}
+	if _, err := NewSlogLogger(&buf, "json", "verbose"); err == nil {
+		t.Error("Expected unknown level to fail")
+	}
+}
+
+func TestSlogLoggerSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewSlogLogger(&buf, "json", "warn")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	child := logger.With("component", "test")
+
+	child.Info("before")
+	if err := logger.SetLevel("debug"); err != nil {
+		t.Fatalf("Failed to set level: %v", err)
+	}
+	child.Debug("after")
+	if err := logger.SetLevel("loud"); err == nil {
+		t.Error("Expected unknown level to fail")
+	}
+	child.Debug("still debug")
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 2 || lines[0]["msg"] != "after" || lines[1]["msg"] != "still debug" {
+		t.Errorf("Expected the new level to reach child loggers and survive a bad update, got %s", buf.String())
+	}
+}
@@ -1,15 +1,17 @@
 package logging
 
 import (
+	"fmt"
 	"log"
-	"sync"
+	"os"
+	"strings"
 
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
 // StdLogger implements domain.Logger using Go's standard log.
 type StdLogger struct {
-	mu sync.Mutex // thread-safe
+	attrs []any // Attributes added with With
 }
 
 // NewStdLogger creates a new standard logger.
@@ -17,52 +19,56 @@ func NewStdLogger() *StdLogger {
 	return &StdLogger{}
 }
 
+// Debug logs a diagnostic message.
+func (l *StdLogger) Debug(msg string, keysAndValues ...any) {
+	l.log("DEBUG", msg, keysAndValues...)
+}
+
 // Info logs an informational message.
-func (l *StdLogger) Info(msg string, keysAndValues ...interface{}) {
+func (l *StdLogger) Info(msg string, keysAndValues ...any) {
 	l.log("INFO", msg, keysAndValues...)
 }
 
 // Warn logs a warning message.
-func (l *StdLogger) Warn(msg string, keysAndValues ...interface{}) {
+func (l *StdLogger) Warn(msg string, keysAndValues ...any) {
 	l.log("WARN", msg, keysAndValues...)
 }
 
 // Error logs an error message.
-func (l *StdLogger) Error(msg string, keysAndValues ...interface{}) {
+func (l *StdLogger) Error(msg string, keysAndValues ...any) {
 	l.log("ERROR", msg, keysAndValues...)
 }
 
 // Fatal logs a fatal message and exits.
-func (l *StdLogger) Fatal(msg string, keysAndValues ...interface{}) {
-	log.Fatalf("FATAL: "+msg, keysAndValues...)
+func (l *StdLogger) Fatal(msg string, keysAndValues ...any) {
+	l.log("FATAL", msg, keysAndValues...)
+	os.Exit(1)
 }
 
-// log writes a formatted log entry.
-func (l *StdLogger) log(level, msg string, keysAndValues ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	if len(keysAndValues) == 0 {
-		log.Printf("%s: %s", level, msg)
-		return
-	}
+// With returns a child logger that prefixes keysAndValues to every message.
+func (l *StdLogger) With(keysAndValues ...any) ports.Logger {
+	attrs := append(append([]any{}, l.attrs...), keysAndValues...)
+	return &StdLogger{attrs: attrs}
+}
 
-	// Simple key=value formatting
-	var args []interface{}
-	format := "%s: " + msg
-	args = append(args, level)
+// log writes "LEVEL: msg key=value ..." without treating msg as a format string.
+func (l *StdLogger) log(level, msg string, keysAndValues ...any) {
+	var b strings.Builder
+	b.WriteString(level)
+	b.WriteString(": ")
+	b.WriteString(msg)
 
-	for i := 0; i < len(keysAndValues); i += 2 {
-		if i+1 < len(keysAndValues) {
-			format += " %v=%v"
-			args = append(args, keysAndValues[i], keysAndValues[i+1])
+	all := append(append([]any{}, l.attrs...), keysAndValues...)
+	for i := 0; i < len(all); i += 2 {
+		if i+1 < len(all) {
+			fmt.Fprintf(&b, " %v=%v", all[i], all[i+1])
 		} else {
-			format += " %v=<missing>"
-			args = append(args, keysAndValues[i])
+			fmt.Fprintf(&b, " %v=<missing>", all[i])
 		}
 	}
 
-	log.Printf(format, args...)
+	// log.Print is safe for concurrent use
+	log.Print(b.String())
 }
 
 var _ ports.Logger = (*StdLogger)(nil)
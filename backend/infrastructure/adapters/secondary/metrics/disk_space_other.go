@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package metrics
+
+import "errors"
+
+// diskSpace is not available on this platform.
+func diskSpace(string) (free, total uint64, err error) {
+	return 0, 0, errors.New("disk space not supported on this platform")
+}
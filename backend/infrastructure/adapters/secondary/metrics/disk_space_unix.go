@@ -0,0 +1,16 @@
+//go:build unix
+
+package metrics
+
+import "golang.org/x/sys/unix"
+
+// diskSpace returns the bytes available to unprivileged users and the total
+// size of the filesystem holding path.
+func diskSpace(path string) (free, total uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, 0, err
+	}
+	blockSize := uint64(stat.Bsize)
+	return stat.Bavail * blockSize, stat.Blocks * blockSize, nil
+}
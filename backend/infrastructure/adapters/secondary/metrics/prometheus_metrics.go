@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request latency histogram.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// requestKey identifies one request counter series.
+type requestKey struct {
+	method, route string
+	status        int
+}
+
+// routeKey identifies one latency histogram series.
+type routeKey struct {
+	method, route string
+}
+
+// histogram holds cumulative bucket counts as Prometheus expects them.
+type histogram struct {
+	buckets []uint64 // buckets[i] counts observations <= durationBuckets[i]
+	count   uint64
+	sum     float64
+}
+
+// PrometheusMetrics implements ports.Metrics and serves the values in the
+// Prometheus text exposition format.
+type PrometheusMetrics struct {
+	rootDir string
+
+	bytesUploaded   atomic.Int64
+	bytesDownloaded atomic.Int64
+	zipStreams      atomic.Int64
+
+	mu             sync.Mutex
+	requests       map[requestKey]uint64
+	durations      map[routeKey]*histogram
+	uploadFailures map[string]uint64
+}
+
+// NewPrometheusMetrics creates an empty registry; rootDir is the volume
+// whose free space is reported.
+func NewPrometheusMetrics(rootDir string) *PrometheusMetrics {
+	return &PrometheusMetrics{
+		rootDir:        rootDir,
+		requests:       make(map[requestKey]uint64),
+		durations:      make(map[routeKey]*histogram),
+		uploadFailures: make(map[string]uint64),
+	}
+}
+
+func (m *PrometheusMetrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method: method, route: route, status: status}]++
+
+	key := routeKey{method: method, route: route}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(durationBuckets))}
+		m.durations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *PrometheusMetrics) AddBytesUploaded(n int64)   { m.bytesUploaded.Add(n) }
+func (m *PrometheusMetrics) AddBytesDownloaded(n int64) { m.bytesDownloaded.Add(n) }
+func (m *PrometheusMetrics) ZipStreamStarted()          { m.zipStreams.Add(1) }
+func (m *PrometheusMetrics) ZipStreamFinished()         { m.zipStreams.Add(-1) }
+
+func (m *PrometheusMetrics) UploadFailed(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploadFailures[reason]++
+}
+
+// ServeHTTP writes every metric in the text exposition format.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.Export(w)
+}
+
+// Export writes every metric in the text exposition format, with series
+// sorted so consecutive scrapes are easy to diff.
+func (m *PrometheusMetrics) Export(w io.Writer) error {
+	var b strings.Builder
+
+	m.mu.Lock()
+	writeHeader(&b, "fileshare_http_requests_total", "counter", "HTTP requests by method, route pattern and status.")
+	requestKeys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, c := requestKeys[i], requestKeys[j]
+		if a.route != c.route {
+			return a.route < c.route
+		}
+		if a.method != c.method {
+			return a.method < c.method
+		}
+		return a.status < c.status
+	})
+	for _, key := range requestKeys {
+		fmt.Fprintf(&b, "fileshare_http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n",
+			quote(key.method), quote(key.route), key.status, m.requests[key])
+	}
+
+	writeHeader(&b, "fileshare_http_request_duration_seconds", "histogram", "HTTP request latency by method and route pattern.")
+	routeKeys := make([]routeKey, 0, len(m.durations))
+	for key := range m.durations {
+		routeKeys = append(routeKeys, key)
+	}
+	sort.Slice(routeKeys, func(i, j int) bool {
+		if routeKeys[i].route != routeKeys[j].route {
+			return routeKeys[i].route < routeKeys[j].route
+		}
+		return routeKeys[i].method < routeKeys[j].method
+	})
+	for _, key := range routeKeys {
+		h := m.durations[key]
+		labels := "method=" + quote(key.method) + ",route=" + quote(key.route)
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "fileshare_http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(&b, "fileshare_http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "fileshare_http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "fileshare_http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	writeHeader(&b, "fileshare_upload_failures_total", "counter", "Failed uploads by reason.")
+	reasons := make([]string, 0, len(m.uploadFailures))
+	for reason := range m.uploadFailures {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "fileshare_upload_failures_total{reason=%s} %d\n", quote(reason), m.uploadFailures[reason])
+	}
+	m.mu.Unlock()
+
+	writeHeader(&b, "fileshare_uploaded_bytes_total", "counter", "File content bytes received from clients.")
+	fmt.Fprintf(&b, "fileshare_uploaded_bytes_total %d\n", m.bytesUploaded.Load())
+	writeHeader(&b, "fileshare_downloaded_bytes_total", "counter", "File and archive bytes sent to clients.")
+	fmt.Fprintf(&b, "fileshare_downloaded_bytes_total %d\n", m.bytesDownloaded.Load())
+	writeHeader(&b, "fileshare_zip_streams_active", "gauge", "ZIP archives currently being streamed.")
+	fmt.Fprintf(&b, "fileshare_zip_streams_active %d\n", m.zipStreams.Load())
+
+	// Free space is left out rather than reported as zero when it cannot be read
+	if free, total, err := diskSpace(m.rootDir); err == nil {
+		writeHeader(&b, "fileshare_root_free_bytes", "gauge", "Bytes available to the server on the root directory's volume.")
+		fmt.Fprintf(&b, "fileshare_root_free_bytes %d\n", free)
+		writeHeader(&b, "fileshare_root_size_bytes", "gauge", "Total size of the root directory's volume.")
+		fmt.Fprintf(&b, "fileshare_root_size_bytes %d\n", total)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeHeader emits the HELP and TYPE lines that precede a metric family.
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// quote renders a label value with the escaping the exposition format requires.
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+var _ ports.Metrics = (*PrometheusMetrics)(nil)
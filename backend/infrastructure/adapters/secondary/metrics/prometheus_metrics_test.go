@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetricsExport(t *testing.T) {
+	m := NewPrometheusMetrics(t.TempDir())
+	m.ObserveRequest("GET", "GET /api/files", 200, 20*time.Millisecond)
+	m.ObserveRequest("GET", "GET /api/files", 200, 2*time.Second)
+	m.ObserveRequest("POST", `/odd "route"`+"\n", 500, time.Millisecond)
+	m.AddBytesUploaded(100)
+	m.AddBytesDownloaded(42)
+	m.ZipStreamStarted()
+	m.ZipStreamStarted()
+	m.ZipStreamFinished()
+	m.UploadFailed("conflict")
+	m.UploadFailed("conflict")
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+
+	want := []string{
+		`fileshare_http_requests_total{method="GET",route="GET /api/files",status="200"} 2`,
+		`fileshare_http_requests_total{method="POST",route="/odd \"route\"\n",status="500"} 1`,
+		`fileshare_http_request_duration_seconds_bucket{method="GET",route="GET /api/files",le="0.025"} 1`,
+		`fileshare_http_request_duration_seconds_bucket{method="GET",route="GET /api/files",le="2.5"} 2`,
+		`fileshare_http_request_duration_seconds_bucket{method="GET",route="GET /api/files",le="+Inf"} 2`,
+		`fileshare_http_request_duration_seconds_count{method="GET",route="GET /api/files"} 2`,
+		`fileshare_upload_failures_total{reason="conflict"} 2`,
+		"fileshare_uploaded_bytes_total 100",
+		"fileshare_downloaded_bytes_total 42",
+		"fileshare_zip_streams_active 1",
+		"# TYPE fileshare_http_request_duration_seconds histogram",
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, body)
+		}
+	}
+	if _, _, err := diskSpace(t.TempDir()); err == nil && !strings.Contains(body, "fileshare_root_free_bytes ") {
+		t.Errorf("Expected free space gauge in:\n%s", body)
+	}
+}
@@ -0,0 +1,32 @@
+package shares
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Authorizer applies each share's policy on top of a server-wide authorizer
+// that sees registry paths.
+type Authorizer struct {
+	registry *Registry
+	global   ports.Authorizer
+}
+
+// NewAuthorizer checks global first, then the rules of the share a path
+// belongs to. Paths outside any share are left to global alone.
+func NewAuthorizer(registry *Registry, global ports.Authorizer) *Authorizer {
+	return &Authorizer{registry: registry, global: global}
+}
+
+func (a *Authorizer) Allowed(user string, action models.Action, p string) bool {
+	if !a.global.Allowed(user, action, p) {
+		return false
+	}
+	m, inner, err := a.registry.locate(p)
+	if err != nil || m == nil {
+		return true
+	}
+	return m.acl == nil || m.acl.Allowed(user, action, inner)
+}
+
+var _ ports.Authorizer = (*Authorizer)(nil)
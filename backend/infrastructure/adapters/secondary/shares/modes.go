@@ -0,0 +1,32 @@
+package shares
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// ModeResolver reports each share's access mode, falling back to the
+// server's mode for the list of shares and for shares that set none.
+type ModeResolver struct {
+	registry   *Registry
+	serverMode models.AccessMode
+}
+
+// NewModeResolver resolves modes through registry; a nil registry applies
+// serverMode everywhere, as when the root directory is served.
+func NewModeResolver(registry *Registry, serverMode models.AccessMode) *ModeResolver {
+	return &ModeResolver{registry: registry, serverMode: serverMode}
+}
+
+func (r *ModeResolver) ModeFor(p string) models.AccessMode {
+	if r.registry == nil {
+		return r.serverMode
+	}
+	m, _, err := r.registry.locate(p)
+	if err != nil || m == nil || m.share.Mode == "" {
+		return r.serverMode
+	}
+	return m.share.Mode
+}
+
+var _ ports.ModeResolver = (*ModeResolver)(nil)
@@ -0,0 +1,356 @@
+package shares
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+)
+
+// Registry serves several shares as one tree: "/" lists the shares and
+// "/name/rest" is "/rest" inside the share called name. It implements
+// ports.FileRepository by routing each call to the share's own repository,
+// and never lets an operation span two shares.
+type Registry struct {
+	open    func(share models.Share) ports.FileRepository
+	current atomic.Pointer[table]
+}
+
+// mount is a share together with the repository holding its files.
+type mount struct {
+	share models.Share
+	repo  ports.FileRepository
+	acl   ports.Authorizer // Share policy on inner paths; nil if there is none
+}
+
+// table is one immutable set of shares, swapped as a whole by Replace.
+type table struct {
+	mounts map[string]*mount
+	names  []string // Sorted share names
+}
+
+// NewRegistry mounts shares, creating each share's repository with open.
+func NewRegistry(shares []models.Share, open func(share models.Share) ports.FileRepository) (*Registry, error) {
+	r := &Registry{open: open}
+	if err := r.Replace(shares); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Replace swaps in a new set of shares, e.g. on config reload. Operations
+// already running finish against the old set. On error nothing changes.
+func (r *Registry) Replace(shares []models.Share) error {
+	commit, err := r.Prepare(shares)
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// Prepare validates shares and loads their policies without mounting them,
+// so a reload can check everything before changing anything. Calling the
+// returned function swaps the shares in. A share whose files are kept as
+// before keeps its repository, so writes still running there and its
+// measured usage carry over; only new or changed shares are opened.
+func (r *Registry) Prepare(shares []models.Share) (func(), error) {
+	previous := r.current.Load()
+	t := &table{mounts: make(map[string]*mount, len(shares))}
+	for _, share := range shares {
+		if _, dup := t.mounts[share.Name]; dup {
+			return nil, fmt.Errorf("share %q is defined twice", share.Name)
+		}
+		m := &mount{share: share}
+		if kept, ok := previous.mount(share.Name); ok && sameStorage(kept.share, share) {
+			m.repo = kept.repo
+		} else {
+			m.repo = r.open(share)
+		}
+		if share.ACLFile != "" {
+			policy, err := acl.LoadRuleAuthorizer(share.ACLFile)
+			if err != nil {
+				return nil, fmt.Errorf("share %q: %w", share.Name, err)
+			}
+			m.acl = policy
+		}
+		t.mounts[share.Name] = m
+		t.names = append(t.names, share.Name)
+	}
+	sort.Strings(t.names)
+	return func() { r.current.Store(t) }, nil
+}
+
+// mount returns the share called name; t may be nil before anything is
+// mounted.
+func (t *table) mount(name string) (*mount, bool) {
+	if t == nil {
+		return nil, false
+	}
+	m, ok := t.mounts[name]
+	return m, ok
+}
+
+// sameStorage reports whether a and b keep their files alike, so b can use
+// the repository opened for a. The access mode and policy are applied
+// outside the repository and may differ.
+func sameStorage(a, b models.Share) bool {
+	if a.Path != b.Path || a.Quota != b.Quota {
+		return false
+	}
+	if a.Versions == nil || b.Versions == nil {
+		return a.Versions == b.Versions
+	}
+	return *a.Versions == *b.Versions
+}
+
+// Shares returns the mounted shares in name order.
+func (r *Registry) Shares() []models.Share {
+	t := r.current.Load()
+	shares := make([]models.Share, 0, len(t.names))
+	for _, name := range t.names {
+		shares = append(shares, t.mounts[name].share)
+	}
+	return shares
+}
+
+// locate splits p into its share and the path inside it. The root belongs
+// to no share and yields a nil mount.
+func (r *Registry) locate(p string) (*mount, string, error) {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return nil, "/", nil
+	}
+	name, rest, _ := strings.Cut(p[1:], "/")
+	m, ok := r.current.Load().mounts[name]
+	if !ok {
+		return nil, "", &errors.NotFoundError{Path: p}
+	}
+	return m, "/" + rest, nil
+}
+
+// locateInShare is locate for operations that change a share: the root and
+// unknown top-level names are rejected.
+func (r *Registry) locateInShare(field, p string) (*mount, string, error) {
+	m, inner, err := r.locate(p)
+	if m == nil {
+		return nil, "", errors.NewValidationError(field, p, "must be inside a share")
+	}
+	return m, inner, err
+}
+
+// outer turns a path inside m into the registry path clients see.
+func (m *mount) outer(inner string) string {
+	return path.Join("/", m.share.Name, inner)
+}
+
+// present rewrites a FileInfo from the share's repository into registry paths.
+func (m *mount) present(info *models.FileInfo) *models.FileInfo {
+	info.URL = m.outer(info.URL)
+	info.ZipURL = info.URL + ".zip"
+	return info
+}
+
+// ListDirectory lists the shares at the root and delegates everywhere else.
+func (r *Registry) ListDirectory(p string) ([]*models.FileInfo, error) {
+	m, inner, err := r.locate(p)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		t := r.current.Load()
+		files := make([]*models.FileInfo, 0, len(t.names))
+		for _, name := range t.names {
+			files = append(files, t.mounts[name].describeRoot())
+		}
+		return files, nil
+	}
+
+	files, err := m.repo.ListDirectory(inner)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		m.present(f)
+	}
+	return files, nil
+}
+
+// describeRoot returns the directory entry representing the share itself.
+func (m *mount) describeRoot() *models.FileInfo {
+	info, err := m.repo.Stat("/")
+	if err != nil {
+		// Still list an unavailable share; opening it reports the error
+		info = &models.FileInfo{IsDir: true, MimeType: "inode/directory"}
+	}
+	info.Name = m.share.Name
+	info.URL = "/"
+	return m.present(info)
+}
+
+func (r *Registry) Stat(p string) (*models.FileInfo, error) {
+	m, inner, err := r.locate(p)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return &models.FileInfo{Name: "/", URL: "/", IsDir: true, MimeType: "inode/directory"}, nil
+	}
+	if inner == "/" {
+		return m.describeRoot(), nil
+	}
+	info, err := m.repo.Stat(inner)
+	if err != nil {
+		return nil, err
+	}
+	return m.present(info), nil
+}
+
+func (r *Registry) IsDirectory(p string) (bool, error) {
+	m, inner, err := r.locate(p)
+	if err != nil {
+		return false, err
+	}
+	if m == nil {
+		return true, nil
+	}
+	return m.repo.IsDirectory(inner)
+}
+
+func (r *Registry) FileExists(p string) (bool, error) {
+	m, inner, err := r.locate(p)
+	if _, missing := err.(*errors.NotFoundError); missing {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if m == nil {
+		return true, nil
+	}
+	return m.repo.FileExists(inner)
+}
+
+func (r *Registry) ServeFile(p string) (*models.FileContent, error) {
+	m, inner, err := r.locate(p)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.NewValidationError("path", p, "is the list of shares, not a file")
+	}
+	return m.repo.ServeFile(inner)
+}
+
+func (r *Registry) CreateDirectory(p string) error {
+	if isRoot(p) {
+		return nil
+	}
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		return err
+	}
+	return m.repo.CreateDirectory(inner)
+}
+
+// WriteFile stores the file in its share. The share's repository enforces
+// its quota.
+func (r *Registry) WriteFile(p string, reader models.ReadCloser, policy models.ConflictPolicy) (string, int64, error) {
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		reader.Close()
+		return "", 0, err
+	}
+	stored, written, err := m.repo.WriteFile(inner, reader, policy)
+	if err != nil {
+		return "", 0, m.presentQuota(err)
+	}
+	return m.outer(stored), written, nil
+}
+
+// ZipDirectory archives a directory inside one share. The root is refused
+// because an archive may not cross share boundaries.
+func (r *Registry) ZipDirectory(root string, include func(path string, isDir bool) bool) (models.ReadCloser, error) {
+	m, inner, err := r.locate(root)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, errors.NewValidationError("path", root, "archives cannot span shares; download each share separately")
+	}
+	return m.repo.ZipDirectory(inner, m.outerFilter(include))
+}
+
+// outerFilter adapts an include filter on registry paths to the share's inner paths.
+func (m *mount) outerFilter(include func(path string, isDir bool) bool) func(path string, isDir bool) bool {
+	if include == nil {
+		return nil
+	}
+	return func(inner string, isDir bool) bool {
+		return include(m.outer(inner), isDir)
+	}
+}
+
+func (r *Registry) Delete(p string, recursive bool) error {
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		return err
+	}
+	if inner == "/" {
+		return errors.NewValidationError("path", p, "cannot delete a share")
+	}
+	return m.repo.Delete(inner, recursive)
+}
+
+func (r *Registry) Move(src, dst string, overwrite bool) error {
+	m, from, to, err := r.locatePair(src, dst)
+	if err != nil {
+		return err
+	}
+	return m.repo.Move(from, to, overwrite)
+}
+
+// Copy duplicates src within its share.
+func (r *Registry) Copy(src, dst string, overwrite bool, include func(path string, isDir bool) bool) error {
+	m, from, to, err := r.locatePair(src, dst)
+	if err != nil {
+		return err
+	}
+	return m.presentQuota(m.repo.Copy(from, to, overwrite, m.outerFilter(include)))
+}
+
+// locatePair resolves the two ends of a move or copy, which must lie below
+// the root of the same share.
+func (r *Registry) locatePair(src, dst string) (*mount, string, string, error) {
+	from, fromInner, err := r.locateInShare("from", src)
+	if err != nil {
+		return nil, "", "", err
+	}
+	to, toInner, err := r.locateInShare("to", dst)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if fromInner == "/" {
+		return nil, "", "", errors.NewValidationError("from", src, "cannot move or copy a whole share")
+	}
+	if toInner == "/" {
+		return nil, "", "", errors.NewValidationError("to", dst, "cannot replace a share")
+	}
+	if from != to {
+		return nil, "", "", errors.NewValidationError("to", dst, "must be in the same share as the source")
+	}
+	return from, fromInner, toInner, nil
+}
+
+// isRoot reports whether p names the list of shares.
+func isRoot(p string) bool {
+	return path.Clean("/"+p) == "/"
+}
+
+var _ ports.FileRepository = (*Registry)(nil)
@@ -0,0 +1,288 @@
+package shares
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/acl"
+	"github.com/EslamYasser-Dev/simple-file-share/infrastructure/adapters/secondary/fs"
+)
+
+func openLocal(share models.Share) ports.FileRepository {
+	return fs.NewLocalFileRepository(share.Path).WithQuota(share.Quota)
+}
+
+// newTestRegistry mounts "projects" and "releases" with a file in each.
+func newTestRegistry(t *testing.T, configure func(shares []models.Share)) (*Registry, string, string) {
+	t.Helper()
+	projects, releases := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(projects, "plan.txt"), []byte("plan"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(releases, "v1"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	list := []models.Share{{Name: "releases", Path: releases}, {Name: "projects", Path: projects}}
+	if configure != nil {
+		configure(list)
+	}
+	registry, err := NewRegistry(list, openLocal)
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	return registry, projects, releases
+}
+
+func body(s string) models.ReadCloser {
+	return io.NopCloser(strings.NewReader(s))
+}
+
+func TestRegistryRouting(t *testing.T) {
+	registry, projects, _ := newTestRegistry(t, nil)
+
+	root, err := registry.ListDirectory("/")
+	if err != nil {
+		t.Fatalf("Failed to list shares: %v", err)
+	}
+	if len(root) != 2 || root[0].Name != "projects" || root[1].Name != "releases" || !root[0].IsDir || root[0].URL != "/projects" {
+		t.Fatalf("Expected the shares in name order, got %+v %+v", root[0], root[1])
+	}
+
+	files, err := registry.ListDirectory("/projects")
+	if err != nil {
+		t.Fatalf("Failed to list share: %v", err)
+	}
+	if len(files) != 1 || files[0].URL != "/projects/plan.txt" || files[0].ZipURL != "/projects/plan.txt.zip" {
+		t.Errorf("Expected share entries under the share's name, got %+v", files)
+	}
+
+	stored, _, err := registry.WriteFile("/projects/docs/new.txt", body("hello"), models.ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if stored != "/projects/docs/new.txt" {
+		t.Errorf("Expected the stored path in registry form, got %q", stored)
+	}
+	if data, err := os.ReadFile(filepath.Join(projects, "docs", "new.txt")); err != nil || string(data) != "hello" {
+		t.Errorf("Expected the file in the share's directory, got %q %v", data, err)
+	}
+	if info, err := registry.Stat("/releases"); err != nil || info.Name != "releases" || !info.IsDir {
+		t.Errorf("Expected the share root to describe the share, got %+v %v", info, err)
+	}
+	if exists, err := registry.FileExists("/nope/file"); exists || err != nil {
+		t.Errorf("Expected unknown shares not to exist, got %v %v", exists, err)
+	}
+}
+
+func TestRegistryBoundaries(t *testing.T) {
+	registry, _, _ := newTestRegistry(t, nil)
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"zip the share list", func() error { _, err := registry.ZipDirectory("/", nil); return err }},
+		{"move between shares", func() error { return registry.Move("/projects/plan.txt", "/releases/plan.txt", false) }},
+		{"copy between shares", func() error { return registry.Copy("/projects/plan.txt", "/releases/plan.txt", false, nil) }},
+		{"move a share", func() error { return registry.Move("/projects", "/releases/projects", false) }},
+		{"delete a share", func() error { return registry.Delete("/releases", true) }},
+		{"write beside the shares", func() error {
+			_, _, err := registry.WriteFile("/loose.txt", body("x"), models.ConflictOverwrite)
+			return err
+		}},
+		{"create a top-level directory", func() error { return registry.CreateDirectory("/newshare") }},
+	}
+	for _, tt := range tests {
+		if _, ok := tt.run().(*errors.ValidationError); !ok {
+			t.Errorf("%s: expected a validation error", tt.name)
+		}
+	}
+
+	if _, err := registry.ListDirectory("/missing"); err == nil {
+		t.Error("Expected listing an unknown share to fail")
+	}
+	if err := registry.Move("/projects/plan.txt", "/projects/renamed.txt", false); err != nil {
+		t.Errorf("Expected moves inside a share to work, got %v", err)
+	}
+}
+
+func TestRegistryQuota(t *testing.T) {
+	registry, _, _ := newTestRegistry(t, func(list []models.Share) { list[1].Quota = 10 })
+
+	// plan.txt already uses 4 of the 10 bytes
+	if _, _, err := registry.WriteFile("/projects/six.txt", body("123456"), models.ConflictOverwrite); err != nil {
+		t.Fatalf("Expected a write that fills the quota to succeed, got %v", err)
+	}
+	_, _, err := registry.WriteFile("/projects/more.txt", body("1"), models.ConflictOverwrite)
+	if quota, ok := err.(*errors.QuotaExceededError); !ok || quota.Quota != "/projects" || quota.Path != "/projects/more.txt" {
+		t.Errorf("Expected QuotaExceededError for /projects over quota, got %v", err)
+	}
+	if exists, _ := registry.FileExists("/projects/more.txt"); exists {
+		t.Error("Expected the rejected file to be discarded")
+	}
+	if err := registry.Copy("/projects/plan.txt", "/projects/copy.txt", false, nil); err == nil {
+		t.Error("Expected a copy over quota to fail")
+	}
+	usages, err := registry.Usages()
+	if err != nil {
+		t.Fatalf("Failed to read usage: %v", err)
+	}
+	if len(usages) != 2 || usages[0] != (models.Usage{Name: "/projects", Used: 10, Limit: 10}) || usages[1].Name != "/releases" {
+		t.Errorf("Expected the usage of each share, got %+v", usages)
+	}
+	if _, err := registry.UsageAt("/"); err == nil {
+		t.Error("Expected the list of shares to have no usage")
+	}
+	if _, _, err := registry.WriteFile("/releases/big.txt", body(strings.Repeat("x", 100)), models.ConflictOverwrite); err != nil {
+		t.Errorf("Expected shares without a quota to be unlimited, got %v", err)
+	}
+}
+
+func TestRegistryReplaceKeepsUnchangedShares(t *testing.T) {
+	opened := map[string]int{}
+	projects, releases := t.TempDir(), t.TempDir()
+	list := []models.Share{{Name: "projects", Path: projects, Quota: 10}, {Name: "releases", Path: releases}}
+	registry, err := NewRegistry(list, func(share models.Share) ports.FileRepository {
+		opened[share.Name]++
+		return openLocal(share)
+	})
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	before := registry.current.Load()
+
+	// A new mode is applied outside the repository; a new quota is not
+	next := []models.Share{
+		{Name: "projects", Path: projects, Quota: 10, Mode: models.AccessReadOnly},
+		{Name: "releases", Path: releases, Quota: 5},
+		{Name: "archive", Path: t.TempDir()},
+	}
+	if err := registry.Replace(next); err != nil {
+		t.Fatalf("Failed to replace shares: %v", err)
+	}
+	after := registry.current.Load()
+	if opened["projects"] != 1 || after.mounts["projects"].repo != before.mounts["projects"].repo {
+		t.Errorf("Expected the unchanged share to keep its repository, opened %d times", opened["projects"])
+	}
+	if after.mounts["projects"].share.Mode != models.AccessReadOnly {
+		t.Error("Expected the new mode to apply")
+	}
+	if opened["releases"] != 2 || opened["archive"] != 1 {
+		t.Errorf("Expected changed and new shares to be opened, got %v", opened)
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	projects, releases := t.TempDir(), t.TempDir()
+	list := []models.Share{
+		{Name: "projects", Path: projects, Versions: &models.VersionPolicy{Keep: 1}},
+		{Name: "releases", Path: releases},
+	}
+	registry, err := NewRegistry(list, func(share models.Share) ports.FileRepository {
+		repo := fs.NewLocalFileRepository(share.Path)
+		if share.Versions != nil {
+			repo.WithVersions(*share.Versions)
+		}
+		return repo
+	})
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+	for _, p := range []string{"/projects/plan.txt", "/releases/notes.txt"} {
+		for _, content := range []string{"one", "two", "three"} {
+			if _, _, err := registry.WriteFile(p, body(content), models.ConflictOverwrite); err != nil {
+				t.Fatalf("Failed to write %s: %v", p, err)
+			}
+		}
+	}
+
+	versions, err := registry.ListVersions("/projects/plan.txt")
+	if err != nil {
+		t.Fatalf("Failed to list versions: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Path != "/projects/plan.txt" {
+		t.Fatalf("Expected the share's policy to keep one version, got %+v", versions)
+	}
+	if versions, _ := registry.ListVersions("/releases/notes.txt"); len(versions) != 0 {
+		t.Errorf("Expected a share without versioning to keep none, got %+v", versions)
+	}
+	if stored, err := registry.RestoreVersion("/projects/plan.txt", versions[0].ID, models.ConflictOverwrite); err != nil || stored != "/projects/plan.txt" {
+		t.Fatalf("Failed to restore: %q %v", stored, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(projects, "plan.txt")); err != nil || string(data) != "two" {
+		t.Errorf("Expected the restored content, got %q %v", data, err)
+	}
+	if _, err := registry.ListVersions("/"); err == nil {
+		t.Error("Expected the list of shares to have no versions")
+	}
+}
+
+func TestShareAuthorizer(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "acl.json")
+	policy := `{"rules": [{"path": "/", "allow": {"*": ["list", "read"]}}, {"path": "/inbox", "allow": {"bob": ["write"]}}]}`
+	if err := os.WriteFile(policyFile, []byte(policy), 0600); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	registry, _, _ := newTestRegistry(t, func(list []models.Share) {
+		list[1].ACLFile = policyFile
+	})
+	authorizer := NewAuthorizer(registry, acl.AllowAllAuthorizer{})
+
+	tests := []struct {
+		user   string
+		action models.Action
+		path   string
+		want   bool
+	}{
+		{"alice", models.ActionList, "/", true},
+		{"alice", models.ActionRead, "/releases/v1", true},
+		{"alice", models.ActionWrite, "/releases/v1/new.txt", true},
+		{"alice", models.ActionRead, "/projects/plan.txt", true},
+		{"alice", models.ActionWrite, "/projects/inbox/a.txt", false},
+		{"bob", models.ActionWrite, "/projects/inbox/a.txt", true},
+		{"bob", models.ActionWrite, "/projects/plan.txt", false},
+	}
+	for _, tt := range tests {
+		if got := authorizer.Allowed(tt.user, tt.action, tt.path); got != tt.want {
+			t.Errorf("Allowed(%s, %s, %s) = %v, want %v", tt.user, tt.action, tt.path, got, tt.want)
+		}
+	}
+
+	// Reloading with a broken policy keeps the mounted shares
+	if err := registry.Replace([]models.Share{{Name: "other", Path: t.TempDir(), ACLFile: "/missing.json"}}); err == nil {
+		t.Fatal("Expected a missing share policy to fail")
+	}
+	if names := registry.Shares(); len(names) != 2 {
+		t.Errorf("Expected the previous shares after a failed replace, got %+v", names)
+	}
+}
+
+func TestModeResolver(t *testing.T) {
+	registry, _, _ := newTestRegistry(t, func(list []models.Share) { list[0].Mode = models.AccessDropBox })
+	modes := NewModeResolver(registry, models.AccessReadOnly)
+
+	tests := []struct {
+		path string
+		want models.AccessMode
+	}{
+		{"/", models.AccessReadOnly},
+		{"/releases", models.AccessDropBox},
+		{"/releases/v1/new.txt", models.AccessDropBox},
+		{"/projects/plan.txt", models.AccessReadOnly},
+		{"/missing/file", models.AccessReadOnly},
+	}
+	for _, tt := range tests {
+		if got := modes.ModeFor(tt.path); got != tt.want {
+			t.Errorf("ModeFor(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+	if got := NewModeResolver(nil, models.AccessWriteOnce).ModeFor("/any"); got != models.AccessWriteOnce {
+		t.Errorf("Expected the server mode without shares, got %s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package shares
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Each share keeps its own trash. Registry trash IDs are "share/id" and
+// their paths are registry paths.
+
+// trash returns the trash of m's repository.
+func (m *mount) trash() (ports.TrashRepository, error) {
+	trash, ok := m.repo.(ports.TrashRepository)
+	if !ok {
+		return nil, errors.NewValidationError("path", m.outer("/"), "share has no trash")
+	}
+	return trash, nil
+}
+
+// presentTrash rewrites a trash item from the share's repository into registry terms.
+func (m *mount) presentTrash(item *models.TrashItem) *models.TrashItem {
+	item.ID = m.share.Name + "/" + item.ID
+	item.OriginalPath = m.outer(item.OriginalPath)
+	return item
+}
+
+// locateTrash splits a registry trash ID into its share and the share's own ID.
+func (r *Registry) locateTrash(id string) (*mount, ports.TrashRepository, string, error) {
+	name, inner, ok := strings.Cut(id, "/")
+	m, found := r.current.Load().mounts[name]
+	if !ok || !found {
+		return nil, nil, "", &errors.NotFoundError{Path: id}
+	}
+	trash, err := m.trash()
+	return m, trash, inner, err
+}
+
+func (r *Registry) MoveToTrash(p, user string) (*models.TrashItem, error) {
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		return nil, err
+	}
+	if inner == "/" {
+		return nil, errors.NewValidationError("path", p, "cannot delete a share")
+	}
+	trash, err := m.trash()
+	if err != nil {
+		return nil, err
+	}
+	item, err := trash.MoveToTrash(inner, user)
+	if err != nil {
+		return nil, err
+	}
+	return m.presentTrash(item), nil
+}
+
+// ListTrash merges the trash of every share, oldest first.
+func (r *Registry) ListTrash() ([]*models.TrashItem, error) {
+	t := r.current.Load()
+	items := []*models.TrashItem{}
+	for _, name := range t.names {
+		m := t.mounts[name]
+		trash, err := m.trash()
+		if err != nil {
+			continue
+		}
+		shareItems, err := trash.ListTrash()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range shareItems {
+			items = append(items, m.presentTrash(item))
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletedAt.Before(items[j].DeletedAt) })
+	return items, nil
+}
+
+// RestoreFromTrash restores within the entry's own share.
+func (r *Registry) RestoreFromTrash(id, dst, user string, policy models.ConflictPolicy) (string, error) {
+	m, trash, inner, err := r.locateTrash(id)
+	if err != nil {
+		return "", err
+	}
+	innerDst := ""
+	if dst != "" {
+		to, toInner, err := r.locateInShare("to", dst)
+		if err != nil {
+			return "", err
+		}
+		if to != m {
+			return "", errors.NewValidationError("to", dst, "must be in the share the entry was deleted from")
+		}
+		innerDst = toInner
+	}
+	restored, err := trash.RestoreFromTrash(inner, innerDst, user, policy)
+	if err != nil {
+		return "", m.presentQuota(err)
+	}
+	return m.outer(restored), nil
+}
+
+func (r *Registry) PurgeTrash(id string) error {
+	_, trash, inner, err := r.locateTrash(id)
+	if err != nil {
+		return err
+	}
+	return trash.PurgeTrash(inner)
+}
+
+func (r *Registry) PurgeTrashBefore(cutoff time.Time) (int, error) {
+	t := r.current.Load()
+	purged := 0
+	for _, name := range t.names {
+		trash, err := t.mounts[name].trash()
+		if err != nil {
+			continue
+		}
+		n, err := trash.PurgeTrashBefore(cutoff)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+var _ ports.TrashRepository = (*Registry)(nil)
@@ -0,0 +1,67 @@
+package shares
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Each share is an area of storage with its own quota, kept by the share's
+// repository.
+
+// usage returns the usage store of m's repository.
+func (m *mount) usage() (ports.UsageRepository, error) {
+	usage, ok := m.repo.(ports.UsageRepository)
+	if !ok {
+		return nil, errors.NewValidationError("path", m.outer("/"), "share does not track usage")
+	}
+	return usage, nil
+}
+
+// presentQuota rewrites a quota error from the share's repository into
+// registry terms; other errors pass through.
+func (m *mount) presentQuota(err error) error {
+	if quota, ok := err.(*errors.QuotaExceededError); ok {
+		return &errors.QuotaExceededError{Path: m.outer(quota.Path), Quota: m.outer("/"), Limit: quota.Limit}
+	}
+	return err
+}
+
+// UsageAt reports the share holding p. The list of shares holds no files
+// and has no usage of its own.
+func (r *Registry) UsageAt(p string) (models.Usage, error) {
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		return models.Usage{}, err
+	}
+	usage, err := m.usage()
+	if err != nil {
+		return models.Usage{}, err
+	}
+	found, err := usage.UsageAt(inner)
+	if err != nil {
+		return models.Usage{}, err
+	}
+	found.Name = m.outer("/")
+	return found, nil
+}
+
+// Usages reports every share in name order.
+func (r *Registry) Usages() ([]models.Usage, error) {
+	t := r.current.Load()
+	usages := make([]models.Usage, 0, len(t.names))
+	for _, name := range t.names {
+		m := t.mounts[name]
+		if _, err := m.usage(); err != nil {
+			continue
+		}
+		usage, err := r.UsageAt(m.outer("/"))
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+var _ ports.UsageRepository = (*Registry)(nil)
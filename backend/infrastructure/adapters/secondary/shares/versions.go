@@ -0,0 +1,81 @@
+package shares
+
+import (
+	"github.com/EslamYasser-Dev/simple-file-share/domain/errors"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/models"
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Each share keeps the versions of its own files, pruned by its own policy.
+
+// versions returns the version store of m's repository.
+func (m *mount) versions() (ports.VersionRepository, error) {
+	versions, ok := m.repo.(ports.VersionRepository)
+	if !ok {
+		return nil, errors.NewValidationError("path", m.outer("/"), "share keeps no versions")
+	}
+	return versions, nil
+}
+
+// locateVersions finds the share holding p and its version store.
+func (r *Registry) locateVersions(p string) (*mount, ports.VersionRepository, string, error) {
+	m, inner, err := r.locateInShare("path", p)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	versions, err := m.versions()
+	return m, versions, inner, err
+}
+
+func (r *Registry) ListVersions(p string) ([]*models.FileVersion, error) {
+	m, versions, inner, err := r.locateVersions(p)
+	if err != nil {
+		return nil, err
+	}
+	list, err := versions.ListVersions(inner)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range list {
+		version.Path = m.outer(version.Path)
+	}
+	return list, nil
+}
+
+func (r *Registry) OpenVersion(p, id string) (*models.FileContent, error) {
+	_, versions, inner, err := r.locateVersions(p)
+	if err != nil {
+		return nil, err
+	}
+	return versions.OpenVersion(inner, id)
+}
+
+// RestoreVersion writes the version through WriteFile, so the share's quota
+// applies to it like to any other write.
+func (r *Registry) RestoreVersion(p, id string, policy models.ConflictPolicy) (string, error) {
+	file, err := r.OpenVersion(p, id)
+	if err != nil {
+		return "", err
+	}
+	stored, _, err := r.WriteFile(p, file.Content, policy)
+	return stored, err
+}
+
+func (r *Registry) PruneVersions() (int, error) {
+	t := r.current.Load()
+	pruned := 0
+	for _, name := range t.names {
+		versions, err := t.mounts[name].versions()
+		if err != nil {
+			continue
+		}
+		n, err := versions.PruneVersions()
+		pruned += n
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+var _ ports.VersionRepository = (*Registry)(nil)
@@ -0,0 +1,101 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// ACMEOptions configures an ACMECertSource.
+type ACMEOptions struct {
+	Domains      []string // Names certificates may be issued for; others are refused
+	Email        string   // Contact address registered with the CA, optional
+	DirectoryURL string   // ACME directory; Let's Encrypt production when empty
+	CacheDir     string   // Where the account key and certificates are kept
+	CAFile       string   // Extra PEM roots for reaching the directory, e.g. a local Pebble
+}
+
+// ACMECertSource obtains certificates from an ACME CA on first use and
+// renews them in the background before they expire. Both TLS-ALPN-01 (on
+// the TLS port) and HTTP-01 (via HTTPHandler on port 80) challenges are
+// answered. Certificates and the account key are cached on disk.
+type ACMECertSource struct {
+	manager *autocert.Manager
+}
+
+// NewACMECertSource creates a source for opts.Domains.
+func NewACMECertSource(opts ACMEOptions) (*ACMECertSource, error) {
+	if len(opts.Domains) == 0 {
+		return nil, errors.New("ACME needs at least one domain")
+	}
+	if opts.CacheDir == "" {
+		return nil, errors.New("ACME needs a cache directory")
+	}
+	if err := os.MkdirAll(opts.CacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create ACME cache directory: %w", err)
+	}
+
+	client := &acme.Client{DirectoryURL: opts.DirectoryURL}
+	if client.DirectoryURL == "" {
+		client.DirectoryURL = acme.LetsEncryptURL
+	}
+	if opts.CAFile != "" {
+		httpClient, err := httpClientTrusting(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		client.HTTPClient = httpClient
+	}
+
+	return &ACMECertSource{manager: &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(opts.CacheDir),
+		HostPolicy: autocert.HostWhitelist(opts.Domains...),
+		Email:      opts.Email,
+		Client:     client,
+	}}, nil
+}
+
+// GetCertificate returns a cached certificate or obtains one, and answers
+// TLS-ALPN-01 challenge handshakes.
+func (s *ACMECertSource) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return s.manager.GetCertificate(hello)
+}
+
+// NextProtos returns the ALPN protocol used by TLS-ALPN-01 challenges.
+func (s *ACMECertSource) NextProtos() []string {
+	return []string{acme.ALPNProto}
+}
+
+// HTTPHandler answers HTTP-01 challenges and passes everything else to fallback.
+func (s *ACMECertSource) HTTPHandler(fallback http.Handler) http.Handler {
+	return s.manager.HTTPHandler(fallback)
+}
+
+// httpClientTrusting returns a client that also trusts the roots in caFile.
+func httpClientTrusting(caFile string) (*http.Client, error) {
+	pemBytes, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ACME CA file: %w", err)
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pemBytes) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	return &http.Client{Transport: transport}, nil
+}
+
+var _ ports.TLSCertSource = (*ACMECertSource)(nil)
@@ -0,0 +1,74 @@
+package tls
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestACMECertSourceHTTPHandler(t *testing.T) {
+	source, err := NewACMECertSource(ACMEOptions{
+		Domains:      []string{"files.example"},
+		DirectoryURL: "https://127.0.0.1:14000/dir",
+		CacheDir:     t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create ACME source: %v", err)
+	}
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := source.HTTPHandler(fallback)
+
+	tests := []struct {
+		host, path string
+		want       int
+	}{
+		{"files.example", "/some/file", http.StatusTeapot},
+		{"files.example", "/.well-known/acme-challenge/unknown-token", http.StatusNotFound},
+		{"other.example", "/.well-known/acme-challenge/unknown-token", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s%s: expected status %d, got %d", tt.host, tt.path, tt.want, rec.Code)
+		}
+	}
+
+	// Names outside the whitelist are refused before contacting the CA
+	if _, err := source.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example"}); err == nil {
+		t.Error("Expected a certificate request for an unlisted host to fail")
+	}
+	if protos := source.NextProtos(); len(protos) != 1 || protos[0] != "acme-tls/1" {
+		t.Errorf("Expected the TLS-ALPN-01 protocol, got %v", protos)
+	}
+}
+
+func TestNewACMECertSourceValidation(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts ACMEOptions
+	}{
+		{"no domains", ACMEOptions{CacheDir: dir}},
+		{"no cache", ACMEOptions{Domains: []string{"files.example"}}},
+		{"missing CA file", ACMEOptions{Domains: []string{"files.example"}, CacheDir: dir, CAFile: filepath.Join(dir, "missing.pem")}},
+		{"invalid CA file", ACMEOptions{Domains: []string{"files.example"}, CacheDir: dir, CAFile: notPEM}},
+	}
+	for _, tt := range tests {
+		if _, err := NewACMECertSource(tt.opts); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
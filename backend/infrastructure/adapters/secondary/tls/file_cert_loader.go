@@ -0,0 +1,45 @@
+package tls
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// FileTLSCertLoader serves a certificate and key that something else
+// manages, such as certificates issued by an internal CA. The files are read
+// again whenever they change.
+type FileTLSCertLoader struct {
+	certFile string
+	keyFile  string
+}
+
+// NewFileTLSCertLoader creates a loader for PEM files at certFile and keyFile.
+// certFile may hold the full chain, leaf first.
+func NewFileTLSCertLoader(certFile, keyFile string) *FileTLSCertLoader {
+	return &FileTLSCertLoader{certFile: certFile, keyFile: keyFile}
+}
+
+// GenerateCert reads the certificate and key files.
+func (l *FileTLSCertLoader) GenerateCert() ([]byte, []byte, error) {
+	certPEM, err := os.ReadFile(l.certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(l.keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return certPEM, keyPEM, nil
+}
+
+// CertVersion changes whenever either file is replaced or rewritten.
+func (l *FileTLSCertLoader) CertVersion() (string, error) {
+	return fileVersion(l.certFile, l.keyFile)
+}
+
+var (
+	_ ports.TLSCertGenerator = (*FileTLSCertLoader)(nil)
+	_ ports.TLSCertWatcher   = (*FileTLSCertLoader)(nil)
+)
@@ -0,0 +1,56 @@
+package tls
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHosts are the names a generated certificate covers when none are configured.
+var DefaultHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// splitHosts sorts hosts into the DNS name and IP address subject
+// alternative names of a certificate.
+func splitHosts(hosts []string) (dnsNames []string, ips []net.IP) {
+	if len(hosts) == 0 {
+		hosts = DefaultHosts
+	}
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			ips = append(ips, ip)
+		} else {
+			dnsNames = append(dnsNames, host)
+		}
+	}
+	return dnsNames, ips
+}
+
+// newSerialNumber returns a random 128-bit certificate serial number.
+func newSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// fileVersion summarizes the size and modification time of paths.
+func fileVersion(paths ...string) (string, error) {
+	var b strings.Builder
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", filepath.Base(p), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
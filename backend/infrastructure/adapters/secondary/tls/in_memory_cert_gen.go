@@ -7,15 +7,17 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
-	"math/big"
-	"net"
 	"time"
 
 	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
 )
 
 // InMemoryTLSCertGenerator generates self-signed TLS certificates in memory.
-type InMemoryTLSCertGenerator struct{}
+// Every call produces a new certificate, so clients must trust it again
+// after each restart; PersistentTLSCertGenerator avoids that.
+type InMemoryTLSCertGenerator struct {
+	Hosts []string // Names and IP addresses to cover; DefaultHosts when empty
+}
 
 // GenerateCert creates a new self-signed certificate and private key.
 func (g *InMemoryTLSCertGenerator) GenerateCert() ([]byte, []byte, error) {
@@ -24,13 +26,14 @@ func (g *InMemoryTLSCertGenerator) GenerateCert() ([]byte, []byte, error) {
 		return nil, nil, fmt.Errorf("failed to generate RSA key: %w", err)
 	}
 
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	serialNumber, err := newSerialNumber()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, nil, err
 	}
 
 	notBefore := time.Now()
 	notAfter := notBefore.Add(365 * 24 * time.Hour)
+	dnsNames, ips := splitHosts(g.Hosts)
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
@@ -43,15 +46,8 @@ func (g *InMemoryTLSCertGenerator) GenerateCert() ([]byte, []byte, error) {
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
-		IPAddresses: []net.IP{
-			net.ParseIP("127.0.0.1"),
-			net.ParseIP("::1"),
-		},
-		DNSNames: []string{
-			"localhost",
-			"127.0.0.1",
-			"go-server.local",
-		},
+		IPAddresses:           ips,
+		DNSNames:              dnsNames,
 	}
 
 	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
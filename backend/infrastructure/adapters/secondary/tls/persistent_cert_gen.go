@@ -0,0 +1,264 @@
+package tls
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EslamYasser-Dev/simple-file-share/domain/ports"
+)
+
+// Files kept in the certificate directory.
+const (
+	caCertFile     = "ca.crt"
+	caKeyFile      = "ca.key"
+	serverCertFile = "server.crt"
+	serverKeyFile  = "server.key"
+)
+
+const (
+	caValidity = 10 * 365 * 24 * time.Hour
+	// leafValidity is the longest lifetime browsers accept for a server certificate
+	leafValidity = 397 * 24 * time.Hour
+	// renewBefore is how close to expiry a stored server certificate is replaced
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// PersistentTLSCertGenerator creates a local certificate authority and a
+// server certificate signed by it on first use, and keeps both in dir.
+// Later starts reuse them, so clients only need to trust ca.crt once. The
+// server certificate is reissued when the hosts change or it nears expiry.
+type PersistentTLSCertGenerator struct {
+	dir   string
+	hosts []string
+
+	mu sync.Mutex
+}
+
+// NewPersistentTLSCertGenerator stores certificates in dir covering hosts
+// (DNS names or IP addresses; DefaultHosts when empty).
+func NewPersistentTLSCertGenerator(dir string, hosts []string) *PersistentTLSCertGenerator {
+	return &PersistentTLSCertGenerator{dir: dir, hosts: hosts}
+}
+
+// CAFile returns the path of the CA certificate clients should trust.
+func (g *PersistentTLSCertGenerator) CAFile() string {
+	return filepath.Join(g.dir, caCertFile)
+}
+
+// GenerateCert returns the stored server certificate and key, creating or
+// reissuing them when needed.
+func (g *PersistentTLSCertGenerator) GenerateCert() ([]byte, []byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := os.MkdirAll(g.dir, 0700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	caCert, caKey, err := g.loadOrCreateCA()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPath := filepath.Join(g.dir, serverCertFile)
+	keyPath := filepath.Join(g.dir, serverKeyFile)
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if certErr == nil && keyErr == nil && g.leafUsable(certPEM, keyPEM, caCert) {
+		return certPEM, keyPEM, nil
+	}
+
+	certPEM, keyPEM, err = g.issueLeaf(caCert, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeFileAtomic(keyPath, keyPEM, 0600); err != nil {
+		return nil, nil, err
+	}
+	if err := writeFileAtomic(certPath, certPEM, 0644); err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+// CertVersion changes whenever the stored server certificate does.
+func (g *PersistentTLSCertGenerator) CertVersion() (string, error) {
+	return fileVersion(filepath.Join(g.dir, serverCertFile), filepath.Join(g.dir, serverKeyFile))
+}
+
+// loadOrCreateCA returns the stored CA, replacing it if missing, unreadable or expired.
+func (g *PersistentTLSCertGenerator) loadOrCreateCA() (*x509.Certificate, crypto.Signer, error) {
+	certPath := filepath.Join(g.dir, caCertFile)
+	keyPath := filepath.Join(g.dir, caKeyFile)
+	if cert, key, err := loadPair(certPath, keyPath); err == nil && time.Now().Before(cert.NotAfter) && cert.IsCA {
+		return cert, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Go File Server"},
+			CommonName:   "Go File Server Local CA",
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeFileAtomic(keyPath, keyPEM, 0600); err != nil {
+		return nil, nil, err
+	}
+	if err := writeFileAtomic(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// issueLeaf creates a server certificate for the configured hosts signed by the CA.
+func (g *PersistentTLSCertGenerator) issueLeaf(caCert *x509.Certificate, caKey crypto.Signer) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate server key: %w", err)
+	}
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	dnsNames, ips := splitHosts(g.hosts)
+	commonName := "localhost"
+	if len(dnsNames) > 0 {
+		commonName = dnsNames[0]
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Go File Server"},
+			CommonName:   commonName,
+		},
+		NotBefore:   now.Add(-time.Hour),
+		NotAfter:    now.Add(leafValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// leafUsable reports whether a stored server certificate can be served as is.
+func (g *PersistentTLSCertGenerator) leafUsable(certPEM, keyPEM []byte, caCert *x509.Certificate) bool {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false
+	}
+	leaf := pair.Leaf
+	if leaf.CheckSignatureFrom(caCert) != nil || time.Now().Add(renewBefore).After(leaf.NotAfter) {
+		return false
+	}
+	dnsNames, ips := splitHosts(g.hosts)
+	return slices.Equal(sortedNames(leaf.DNSNames, leaf.IPAddresses), sortedNames(dnsNames, ips))
+}
+
+// sortedNames flattens subject alternative names for comparison.
+func sortedNames(dnsNames []string, ips []net.IP) []string {
+	names := slices.Clone(dnsNames)
+	for _, ip := range ips {
+		names = append(names, ip.String())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// loadPair reads a PEM certificate and PKCS#8 private key.
+func loadPair(certPath, keyPath string) (*x509.Certificate, crypto.Signer, error) {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("private key cannot sign")
+	}
+	return pair.Leaf, signer, nil
+}
+
+// encodeKey returns key as a PKCS#8 PEM block.
+func encodeKey(key crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// writeFileAtomic replaces path with data so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var (
+	_ ports.TLSCertGenerator = (*PersistentTLSCertGenerator)(nil)
+	_ ports.TLSCertWatcher   = (*PersistentTLSCertGenerator)(nil)
+)
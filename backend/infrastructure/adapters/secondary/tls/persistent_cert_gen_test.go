@@ -0,0 +1,122 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func parseLeaf(t *testing.T, certPEM, keyPEM []byte) *x509.Certificate {
+	t.Helper()
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("Failed to parse key pair: %v", err)
+	}
+	return pair.Leaf
+}
+
+func TestPersistentTLSCertGenerator(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewPersistentTLSCertGenerator(dir, []string{"files.example", "192.168.1.10"})
+
+	certPEM, keyPEM, err := gen.GenerateCert()
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	leaf := parseLeaf(t, certPEM, keyPEM)
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != "files.example" {
+		t.Errorf("Expected DNS names [files.example], got %v", leaf.DNSNames)
+	}
+	if len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "192.168.1.10" {
+		t.Errorf("Expected IP addresses [192.168.1.10], got %v", leaf.IPAddresses)
+	}
+
+	caPEM, err := os.ReadFile(gen.CAFile())
+	if err != nil {
+		t.Fatalf("Failed to read CA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+	for _, host := range []string{"files.example", "192.168.1.10"} {
+		if _, err := leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: host}); err != nil {
+			t.Errorf("Expected certificate to verify for %s against the local CA: %v", host, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dir, serverKeyFile)); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected private key with mode 0600, got %v (%v)", info.Mode(), err)
+	}
+
+	// A restart reuses the stored certificate
+	again, _, err := NewPersistentTLSCertGenerator(dir, []string{"192.168.1.10", "files.example"}).GenerateCert()
+	if err != nil {
+		t.Fatalf("Failed to reload certificate: %v", err)
+	}
+	if !bytes.Equal(again, certPEM) {
+		t.Error("Expected the stored certificate to be reused")
+	}
+
+	// New hosts reissue the server certificate under the same CA
+	reissued, reissuedKey, err := NewPersistentTLSCertGenerator(dir, []string{"other.example"}).GenerateCert()
+	if err != nil {
+		t.Fatalf("Failed to reissue certificate: %v", err)
+	}
+	if bytes.Equal(reissued, certPEM) {
+		t.Fatal("Expected a new certificate after the hosts changed")
+	}
+	if _, err := parseLeaf(t, reissued, reissuedKey).Verify(x509.VerifyOptions{Roots: roots, DNSName: "other.example"}); err != nil {
+		t.Errorf("Expected reissued certificate to chain to the original CA: %v", err)
+	}
+}
+
+func TestInMemoryTLSCertGeneratorSANs(t *testing.T) {
+	certPEM, keyPEM, err := (&InMemoryTLSCertGenerator{}).GenerateCert()
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	leaf := parseLeaf(t, certPEM, keyPEM)
+	if len(leaf.DNSNames) != 1 || leaf.DNSNames[0] != "localhost" {
+		t.Errorf("Expected DNS names [localhost], got %v", leaf.DNSNames)
+	}
+	if len(leaf.IPAddresses) != 2 {
+		t.Errorf("Expected loopback IP addresses, got %v", leaf.IPAddresses)
+	}
+}
+
+func TestFileTLSCertLoaderVersion(t *testing.T) {
+	dir := t.TempDir()
+	certPEM, keyPEM, err := (&InMemoryTLSCertGenerator{}).GenerateCert()
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %v", err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	loader := NewFileTLSCertLoader(certFile, keyFile)
+	gotCert, gotKey, err := loader.GenerateCert()
+	if err != nil || !bytes.Equal(gotCert, certPEM) || !bytes.Equal(gotKey, keyPEM) {
+		t.Fatalf("Expected the files' contents, got error %v", err)
+	}
+	before, err := loader.CertVersion()
+	if err != nil {
+		t.Fatalf("Failed to read version: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatalf("Failed to touch certificate: %v", err)
+	}
+	if after, _ := loader.CertVersion(); after == before {
+		t.Error("Expected version to change after the certificate file changed")
+	}
+	if _, _, err := NewFileTLSCertLoader(filepath.Join(dir, "missing.pem"), keyFile).GenerateCert(); err == nil {
+		t.Error("Expected an error for a missing certificate file")
+	}
+}
@@ -3,31 +3,59 @@ package utils
 import (
 	"archive/zip"
 	"io"
-	"os"
-	"path/filepath"
+	"io/fs"
 )
 
-// ZipDirectory recursively zips a directory and writes to w.
+// ZipDirectory recursively zips the directory root of fsys and writes to w.
+// include, if non-nil, is called with each entry's slash-separated path
+// relative to root; returning false skips a file or a whole subtree.
+// Symlinks to files are archived as the file when fsys lets them be opened;
+// other symlinks and special files are left out.
 // Designed to be used in a goroutine with io.Pipe().
-func ZipDirectory(root string, w io.Writer) error {
+func ZipDirectory(fsys fs.FS, root string, w io.Writer, include func(relPath string, isDir bool) bool) error {
 	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
-	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+	return fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		relPath, err := filepath.Rel(root, path)
+		relPath := "."
+		if name != root {
+			relPath = name
+			if root != "." {
+				relPath = name[len(root)+1:]
+			}
+		}
+
+		if include != nil && relPath != "." && !include(relPath, entry.IsDir()) {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		info, err := entry.Info()
 		if err != nil {
 			return err
 		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// Not followed into directories, which could loop
+			target, err := fs.Stat(fsys, name)
+			if err != nil || !target.Mode().IsRegular() {
+				return nil
+			}
+			info = target
+		} else if !entry.IsDir() && !entry.Type().IsRegular() {
+			return nil
+		}
 
 		header, err := zip.FileInfoHeader(info)
 		if err != nil {
 			return err
 		}
-		header.Name = filepath.ToSlash(relPath)
+		header.Name = relPath
 		if info.IsDir() {
 			header.Name += "/"
 		}
@@ -39,7 +67,7 @@ func ZipDirectory(root string, w io.Writer) error {
 		}
 
 		if !info.IsDir() {
-			file, err := os.Open(path)
+			file, err := fsys.Open(name)
 			if err != nil {
 				return err
 			}